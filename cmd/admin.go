@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	auditModels "fiber-crud/internal/domain/audit"
+	userModels "fiber-crud/internal/domain/user"
+	user "fiber-crud/internal/repository"
+	auditRepository "fiber-crud/internal/repository/audit"
+	authRepository "fiber-crud/internal/repository/auth"
+	auditUsecase "fiber-crud/internal/usecase/audit"
+	authUsecase "fiber-crud/internal/usecase/auth"
+	db "fiber-crud/package"
+	"fiber-crud/package/config"
+	"fiber-crud/package/logger"
+)
+
+const adminUsage = `usage: admin <command>
+
+commands:
+  grant EMAIL    make the account registered with EMAIL an admin`
+
+// runAdmin implements the "admin" subcommand and returns the process exit
+// code. Role changes over HTTP need an admin, so this is how the first one
+// is created.
+func runAdmin(args []string) int {
+	if len(args) != 2 || args[0] != "grant" {
+		fmt.Fprintln(os.Stderr, adminUsage)
+		return 2
+	}
+
+	cfg, err := config.Read()
+	if err == nil {
+		err = errors.Join(cfg.Log.Validate(), cfg.Database.Validate())
+	}
+	if err == nil {
+		err = logger.Setup(cfg.Log)
+	}
+	if err != nil {
+		log.Printf("Failed to load database configuration: %v", err)
+		return 1
+	}
+
+	gormDB := db.InitDB(cfg.Database)
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		log.Printf("Failed to access database pool: %v", err)
+		return 1
+	}
+	defer sqlDB.Close()
+
+	// Refuse to write to a schema that "migrate up" has not brought current.
+	if err := checkMigrated(gormDB); err != nil {
+		log.Printf("Failed to check the schema: %v", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	userRepo := user.NewUserRepository(gormDB)
+	target, err := userRepo.GetByEmail(ctx, args[1])
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	if target == nil {
+		log.Printf("No account is registered with %s", args[1])
+		return 1
+	}
+	if target.Role == userModels.RoleAdmin {
+		log.Printf("%s is already an admin", args[1])
+		return 0
+	}
+
+	before := *target
+	target.Role = userModels.RoleAdmin
+	if err := userRepo.Update(ctx, *target); err != nil {
+		log.Print(err)
+		return 1
+	}
+	// Tokens issued before the change still carry the old role.
+	auth := authUsecase.NewAuthUsecase(authRepository.NewAuthRepository(gormDB), userRepo)
+	if err := auth.RevokeAllSessions(ctx, target.ID); err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	audit := auditUsecase.NewAuditUsecase(auditRepository.NewAuditRepository(gormDB))
+	audit.Record(ctx, auditModels.Actor{}, auditModels.ActionChangeRole, auditModels.EntityUser, target.ID.String(), before, *target)
+
+	log.Printf("%s is now an admin; they need to sign in again", args[1])
+	return 0
+}
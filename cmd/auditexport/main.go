@@ -0,0 +1,89 @@
+// Command auditexport writes audit log entries as JSON Lines, oldest first.
+//
+//	go run ./cmd/auditexport -entity-type product -from 2024-01-01T00:00:00Z -o audit.jsonl
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	auditModels "fiber-crud/internal/domain/audit"
+	auditRepository "fiber-crud/internal/repository/audit"
+	db "fiber-crud/package"
+	"fiber-crud/package/config"
+	"fiber-crud/package/logger"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func main() {
+	actor := flag.String("actor", "", "only entries by this user ID")
+	entityType := flag.String("entity-type", "", "only entries for this entity type ("+strings.Join(auditModels.EntityTypes, ", ")+")")
+	entityID := flag.String("entity-id", "", "only entries for this entity ID")
+	from := flag.String("from", "", "only entries at or after this RFC 3339 time")
+	to := flag.String("to", "", "only entries before this RFC 3339 time")
+	output := flag.String("o", "audit.jsonl", "output file")
+	flag.Parse()
+
+	filter := auditModels.Filter{EntityType: *entityType, EntityID: *entityID}
+	if *actor != "" {
+		actorID, err := uuid.Parse(*actor)
+		if err != nil {
+			log.Fatalf("Invalid -actor: %v", err)
+		}
+		filter.ActorID = &actorID
+	}
+	filter.From = parseTime("from", *from)
+	filter.To = parseTime("to", *to)
+
+	cfg, err := config.Read()
+	if err == nil {
+		err = errors.Join(cfg.Log.Validate(), cfg.Database.Validate())
+	}
+	if err == nil {
+		err = logger.Setup(cfg.Log)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load database configuration: %v", err)
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		log.Fatalf("Failed to create output file: %v", err)
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	repo := auditRepository.NewAuditRepository(db.InitDB(cfg.Database))
+
+	count := 0
+	err = repo.Export(context.Background(), filter, func(entry auditModels.AuditLog) error {
+		count++
+		return enc.Encode(entry)
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		log.Fatalf("Failed to export audit log: %v", err)
+	}
+	log.Printf("Exported %d audit log entries to %s", count, *output)
+}
+
+func parseTime(name, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Fatalf("Invalid -%s: %v", name, err)
+	}
+	return &t
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// worker is a background loop that runs alongside the server until its
+// context is cancelled.
+type worker struct {
+	name   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startWorker(name string, run func(ctx context.Context)) *worker {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &worker{name: name, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		run(ctx)
+	}()
+	return w
+}
+
+// stop cancels the worker and waits for its current pass to finish, or for
+// ctx to expire.
+func (w *worker) stop(ctx context.Context) error {
+	w.cancel()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("worker %s did not stop: %v", w.name, ctx.Err())
+	}
+}
+
+// shutdown stops the servers first so no new work arrives, then the workers
+// in the order given, and closes the database pool because everything
+// before it may still be using it. Buffered spans are flushed last so the
+// drain itself is traced. It reports whether every step succeeded.
+func shutdown(ctx context.Context, apps []*fiber.App, workers []*worker, sqlDB *sql.DB, flushTraces func(context.Context) error) bool {
+	ok := true
+
+	for _, app := range apps {
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			log.Printf("Failed to drain HTTP connections: %v", err)
+			ok = false
+		}
+	}
+
+	for _, w := range workers {
+		if err := w.stop(ctx); err != nil {
+			log.Print(err)
+			ok = false
+		}
+	}
+
+	if err := sqlDB.Close(); err != nil {
+		log.Printf("Failed to close database pool: %v", err)
+		ok = false
+	}
+
+	if err := flushTraces(ctx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+		ok = false
+	}
+
+	return ok
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func TestWorkerStopWaitsForTheCurrentPass(t *testing.T) {
+	finished := false
+	w := startWorker("test", func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished = true
+	})
+
+	if err := w.stop(context.Background()); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if !finished {
+		t.Fatal("stop returned before the worker finished")
+	}
+}
+
+func TestWorkerStopGivesUpAtTheDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	w := startWorker("stuck", func(context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.stop(ctx); err == nil {
+		t.Fatal("stop reported success for a worker that ignores cancellation")
+	}
+}
+
+func TestShutdownStopsWorkersInOrderThenFlushesTraces(t *testing.T) {
+	app := fiber.New()
+	go app.Listen("127.0.0.1:0")
+	t.Cleanup(func() { app.Shutdown() })
+
+	var order []string
+	workers := []*worker{
+		startWorker("first", func(ctx context.Context) { <-ctx.Done(); order = append(order, "first") }),
+		startWorker("second", func(ctx context.Context) { <-ctx.Done(); order = append(order, "second") }),
+	}
+	// Opening does not connect, so the pool closes without a database.
+	sqlDB, err := sql.Open("pgx", "postgres://localhost/unused")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	flushTraces := func(context.Context) error {
+		order = append(order, "traces")
+		return nil
+	}
+	if !shutdown(ctx, []*fiber.App{app, fiber.New()}, workers, sqlDB, flushTraces) {
+		t.Fatal("shutdown reported a failure")
+	}
+	if len(order) != 3 || order[0] != "first" || order[1] != "second" || order[2] != "traces" {
+		t.Fatalf("shutdown order = %v, want first, second, then the trace flush", order)
+	}
+	if err := sqlDB.Ping(); err == nil {
+		t.Fatal("database pool is still open")
+	}
+}
@@ -1,44 +1,134 @@
 package main
 
 import (
+	"context"
+	apiKeyHandler "fiber-crud/internal/handler/apikey"
+	auditHandler "fiber-crud/internal/handler/audit"
+	authHandler "fiber-crud/internal/handler/auth"
 	handler "fiber-crud/internal/handler/cart"
 	commentHandler "fiber-crud/internal/handler/comment"
+	healthHandler "fiber-crud/internal/handler/health"
 	paymentHandler "fiber-crud/internal/handler/payment"
+	privacyHandler "fiber-crud/internal/handler/privacy"
 	ProductHandler "fiber-crud/internal/handler/product"
 	UserHandel "fiber-crud/internal/handler/user"
 	user "fiber-crud/internal/repository"
+	apiKeyRepository "fiber-crud/internal/repository/apikey"
+	auditRepository "fiber-crud/internal/repository/audit"
+	authRepository "fiber-crud/internal/repository/auth"
 	CartRepository "fiber-crud/internal/repository/cart"
 	repository "fiber-crud/internal/repository/comment"
 	paymentRepository "fiber-crud/internal/repository/payment"
+	privacyRepository "fiber-crud/internal/repository/privacy"
 	ProductRepository "fiber-crud/internal/repository/product"
+	retentionRepository "fiber-crud/internal/repository/retention"
 	"fiber-crud/internal/router"
+	apiKeyUsecase "fiber-crud/internal/usecase/apikey"
+	auditUsecase "fiber-crud/internal/usecase/audit"
+	authUsecase "fiber-crud/internal/usecase/auth"
 	usecase "fiber-crud/internal/usecase/cart"
 	commentUsecase "fiber-crud/internal/usecase/comment"
 	paymentUsecase "fiber-crud/internal/usecase/payment"
+	privacyUsecase "fiber-crud/internal/usecase/privacy"
 	productUsecase "fiber-crud/internal/usecase/product"
+	retentionUsecase "fiber-crud/internal/usecase/retention"
 	Userusecase "fiber-crud/internal/usecase/user"
+	"fiber-crud/middleware"
 	db "fiber-crud/package"
+	"fiber-crud/package/config"
+	"fiber-crud/package/logger"
+	"fiber-crud/package/mailer"
+	"fiber-crud/package/metrics"
+	"fiber-crud/package/tracing"
 	"fiber-crud/utils"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func main() {
 
-	utils.InitOAuth2()
-	utils.InitCloudinary()
-	db := db.InitDB()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "admin":
+			os.Exit(runAdmin(os.Args[2:]))
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := logger.Setup(cfg.Log); err != nil {
+		log.Fatalf("Failed to configure logging: %v", err)
+	}
+	flushTraces, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to configure tracing: %v", err)
+	}
+
+	if err := utils.InitOAuth2(cfg.OAuth); err != nil {
+		log.Fatalf("Failed to configure OAuth: %v", err)
+	}
+	if err := utils.InitCloudinary(cfg.Cloudinary); err != nil {
+		log.Fatalf("Failed to configure Cloudinary: %v", err)
+	}
+	if err := utils.InitKeyRing(cfg.JWT); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	if err := utils.InitTOTPEncryption(cfg.Account.TOTPEncryptionKey); err != nil {
+		log.Fatalf("Failed to load the TOTP encryption key: %v", err)
+	}
+	db := db.InitDB(cfg.Database)
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to access database pool: %v", err)
+	}
+	if err := metrics.InstrumentDB(db, cfg.Database.Name); err != nil {
+		log.Fatalf("Failed to instrument database: %v", err)
+	}
+	if err := tracing.InstrumentDB(db); err != nil {
+		log.Fatalf("Failed to instrument database: %v", err)
+	}
+	if err := migrateOnBoot(db, cfg.Database); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+
+	auditRepo := auditRepository.NewAuditRepository(db)
+	auditUsecase := auditUsecase.NewAuditUsecase(auditRepo)
+	auditHandler := auditHandler.NewAuditHandler(auditUsecase)
 
 	userRepo := user.NewUserRepository(db)
-	userUsecase := Userusecase.NewUserUsecase(userRepo)
-	userHandler := UserHandel.NewUserHandler(userUsecase)
+	authRepo := authRepository.NewAuthRepository(db)
+	authUsecase := authUsecase.NewAuthUsecase(authRepo, userRepo)
+	authHandler := authHandler.NewAuthHandler(authUsecase)
+	middleware.SetRevocationChecker(authUsecase)
+
+	apiKeyRepo := apiKeyRepository.NewAPIKeyRepository(db)
+	apiKeyUsecase := apiKeyUsecase.NewAPIKeyUsecase(apiKeyRepo, userRepo)
+	apiKeyHandler := apiKeyHandler.NewAPIKeyHandler(apiKeyUsecase)
+	middleware.SetAPIKeyAuthenticator(apiKeyUsecase)
+
+	userUsecase := Userusecase.NewUserUsecase(userRepo, authRepo, authUsecase, mail, auditUsecase, cfg.Account)
+	userHandler := UserHandel.NewUserHandler(userUsecase, authUsecase)
 
 	productRepo := ProductRepository.NewProductRepository(db)
-	productUsecase := productUsecase.NewProductUsecase(productRepo)
+	productUsecase := productUsecase.NewProductUsecase(productRepo, auditUsecase)
 	productHandler := ProductHandler.NewProductHandler(productUsecase)
 
 	commentRepo := repository.NewCommentRepository(db)
-	commentUsecase := commentUsecase.NewCommentUsecase(commentRepo)
+	commentUsecase := commentUsecase.NewCommentUsecase(commentRepo, auditUsecase)
 	commentHandler := commentHandler.NewCommentHandler(commentUsecase)
 
 	cartRepo := CartRepository.NewCartRepository(db)
@@ -46,22 +136,94 @@ func main() {
 	cartHandler := handler.NewCartHandler(cartUsecase)
 
 	paymentRepo := paymentRepository.NewPaymentRepository(db)
-	paymentUsecase := paymentUsecase.NewPaymentUsecase(paymentRepo, cartRepo)
+	paymentUsecase := paymentUsecase.NewPaymentUsecase(cfg.Midtrans, cfg.Account.RequireEmailVerification, paymentRepo, cartRepo, userRepo, auditUsecase)
 	paymentHandler := paymentHandler.NewPaymentHandler(paymentUsecase)
 
-	app := fiber.New()
+	privacyRepo := privacyRepository.NewPrivacyRepository(db)
+	privacyUsecase := privacyUsecase.NewPrivacyUsecase(cfg.Erasure, privacyRepo, userRepo, mail, auditUsecase)
+	privacyHandler := privacyHandler.NewPrivacyHandler(privacyUsecase)
+
+	healthHandler := healthHandler.NewHealthHandler(db, cfg.App.Production())
+	// Image storage and the payment gateway are checked for configuration
+	// only. Calling the providers from the probe would take every pod out of
+	// rotation during a third-party outage that retries cannot fix.
+	if cfg.Cloudinary.Enabled() {
+		healthHandler.AddCheck("image_storage", func(context.Context) error { return utils.CloudinaryReady() })
+	}
+	healthHandler.AddCheck("payment_gateway", func(context.Context) error { return cfg.Midtrans.Validate() })
+
+	purger := retentionUsecase.NewPurger(cfg.Retention, retentionRepository.NewRetentionRepository(db), authRepo)
+
+	// Workers are stopped in this order once the server has drained.
+	workers := []*worker{
+		startWorker("privacy", privacyUsecase.Run),
+		startWorker("retention", purger.Run),
+	}
 
+	app := fiber.New(fiber.Config{
+		// c.IP() reads ProxyHeader only on requests from a trusted proxy; for
+		// everyone else the client address is the peer address, so the login
+		// throttle cannot be dodged by sending the header directly.
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.Server.TrustedProxies,
+		ProxyHeader:             cfg.Server.ProxyHeader,
+		EnableIPValidation:      true,
+		ErrorHandler:            middleware.ErrorHandler(cfg.App.Production()),
+		ReadTimeout:             cfg.Server.ReadTimeout,
+		WriteTimeout:            cfg.Server.WriteTimeout,
+		IdleTimeout:             cfg.Server.IdleTimeout,
+	})
+	app.Use(middleware.RequestID(), middleware.Tracing(), middleware.Metrics(), middleware.RequestLogger(), middleware.RenderErrors())
+
+	router.SetupHealthRoutes(app, healthHandler)
 	router.SetupUserRoutes(app, userHandler)
+	router.SetupAdminRoutes(app, userHandler)
+	router.SetupPrivacyRoutes(app, privacyHandler)
+	router.SetupAuthRoutes(app, authHandler)
+	router.SetupAPIKeyRoutes(app, apiKeyHandler)
+	router.SetupAuditRoutes(app, auditHandler)
 	router.SetupProductRoutes(app, productHandler)
 	router.SetupComment(app, commentHandler)
 	router.SetupCart(app, cartHandler)
 	router.SetupPayment(app, paymentHandler)
 
-	app.Use(func(c *fiber.Ctx) error {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Route Not Found",
-		})
-	})
+	app.Use(middleware.NotFound())
+
+	metricsApp := fiber.New(fiber.Config{DisableStartupMessage: true})
+	router.SetupMetricsRoutes(metricsApp)
+
+	serverErr := make(chan error, 2)
+	go func() {
+		serverErr <- app.Listen(cfg.Server.Addr())
+	}()
+	go func() {
+		serverErr <- metricsApp.Listen(cfg.Server.MetricsAddr())
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	exitCode := 0
+	select {
+	case sig := <-signals:
+		log.Printf("Received %s, shutting down", sig)
+	case err := <-serverErr:
+		log.Printf("Server stopped: %v", err)
+		exitCode = 1
+	}
+	// A second signal kills the process instead of waiting for the drain.
+	signal.Stop(signals)
+
+	healthHandler.SetShuttingDown()
+	time.Sleep(cfg.Server.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	ok := shutdown(ctx, []*fiber.App{app, metricsApp}, workers, sqlDB, flushTraces)
+	cancel()
+	if !ok {
+		exitCode = 1
+	}
 
-	app.Listen(":3000")
+	log.Print("Shutdown complete")
+	os.Exit(exitCode)
 }
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	db "fiber-crud/package"
+	"fiber-crud/package/config"
+	"fiber-crud/package/logger"
+	"fiber-crud/package/migrate"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up             apply every pending migration
+  down [N]       revert the last N applied migrations (default 1)
+  status         list migrations and when they were applied
+  create NAME    add an empty up/down pair (-dir to change the directory)`
+
+// runMigrate implements the "migrate" subcommand and returns the process
+// exit code.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if args[0] == "create" {
+		return runMigrateCreate(args[1:])
+	}
+
+	cfg, err := config.Read()
+	if err == nil {
+		err = errors.Join(cfg.Log.Validate(), cfg.Database.Validate())
+	}
+	if err == nil {
+		err = logger.Setup(cfg.Log)
+	}
+	if err != nil {
+		log.Printf("Failed to load database configuration: %v", err)
+		return 1
+	}
+
+	sqlDB, err := db.InitDB(cfg.Database).DB()
+	if err != nil {
+		log.Printf("Failed to access database pool: %v", err)
+		return 1
+	}
+	defer sqlDB.Close()
+
+	migrator, err := migrate.New(sqlDB)
+	if err != nil {
+		log.Printf("Failed to load migrations: %v", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Printf("Applied %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		if len(applied) == 0 {
+			log.Print("Database is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Printf("Invalid number of steps %q", args[1])
+				return 2
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			log.Printf("Reverted %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			log.Print("No applied migrations to revert")
+		}
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Local().Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-40s  %s\n", s.Version, s.Name, applied)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
+
+func runMigrateCreate(args []string) int {
+	flags := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+	dir := flags.String("dir", migrate.Dir, "directory holding the migration files")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: migrate create [-dir DIR] NAME")
+		return 2
+	}
+
+	up, down, err := migrate.Create(*dir, flags.Arg(0))
+	if err != nil {
+		log.Printf("Failed to create migration: %v", err)
+		return 1
+	}
+	log.Printf("Created %s and %s", up, down)
+	return 0
+}
+
+// migrateOnBoot applies pending migrations when MigrateOnStart is set and
+// otherwise refuses to serve against an outdated schema.
+func migrateOnBoot(gormDB *gorm.DB, cfg config.DatabaseConfig) error {
+	if !cfg.MigrateOnStart {
+		return checkMigrated(gormDB)
+	}
+
+	migrator, err := newMigrator(gormDB)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(context.Background())
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
+	return err
+}
+
+// checkMigrated fails when migrations are pending, leaving the schema as it
+// is.
+func checkMigrated(gormDB *gorm.DB) error {
+	migrator, err := newMigrator(gormDB)
+	if err != nil {
+		return err
+	}
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d pending migrations, run \"migrate up\" first", len(pending))
+	}
+	return nil
+}
+
+func newMigrator(gormDB *gorm.DB) (*migrate.Migrator, error) {
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(sqlDB)
+}
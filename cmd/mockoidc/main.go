@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"fiber-crud/package/oidcmock"
+)
+
+// A local OpenID Connect provider for trying the /auth/oauth/:provider flow. Point
+// OIDC_PROVIDERS=mock and OIDC_MOCK_DISCOVERY_URL at it.
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9000", "listen address")
+	clientID := flag.String("client-id", "fiber-crud", "accepted client_id")
+	clientSecret := flag.String("client-secret", "secret", "accepted client_secret")
+	email := flag.String("email", "mock.user@example.com", "email returned by userinfo")
+	flag.Parse()
+
+	provider := oidcmock.NewProvider(*clientID, *clientSecret, oidcmock.User{
+		Subject:       "mock-user-1",
+		Email:         *email,
+		EmailVerified: true,
+		Name:          "Mock User",
+	})
+	provider.SetIssuer("http://" + *addr)
+
+	log.Printf("mock OIDC provider listening on http://%s", *addr)
+	log.Printf("discovery: %s", provider.DiscoveryURL())
+	log.Fatal(http.ListenAndServe(*addr, provider.Handler()))
+}
@@ -0,0 +1,48 @@
+package apiKeyModels
+
+import (
+	"fiber-crud/internal/domain/apperror"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KeyPrefix marks API keys so AuthMiddleware can tell them apart from JWTs in
+// a Bearer header.
+const KeyPrefix = "fc_"
+
+var ErrInvalidAPIKey = apperror.Unauthorized("invalid_api_key", "invalid, expired or revoked API key")
+
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"-"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null" json:"prefix"`
+	KeyHash    string     `gorm:"not null;uniqueIndex" json:"-"`
+	Scopes     string     `gorm:"not null" json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return nil
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Principal is what a valid API key authenticates as: the owning user with
+// the key's scopes narrowed to what the user's current role still grants.
+type Principal struct {
+	KeyID  uuid.UUID
+	UserID uuid.UUID
+	Role   string
+	Scopes []string
+}
@@ -0,0 +1,122 @@
+// Package apperror defines the typed errors usecases return to describe
+// failures callers can act on. The HTTP error handler maps each Kind to a
+// status and writes the stable Code and the safe Message to the client; the
+// wrapped cause is only logged.
+package apperror
+
+import "time"
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindLocked
+	KindRateLimited
+	KindUpstream
+)
+
+// FieldError describes one failing request field, named as the client sent
+// it.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Kind Kind
+	// Code is a stable, machine-readable identifier such as
+	// "user_not_found". Clients may branch on it.
+	Code string
+	// Message is safe to show to clients.
+	Message string
+	// Fields lists the failing fields of a validation error.
+	Fields []FieldError
+	// RetryAfter is how long a locked or rate-limited caller should wait.
+	RetryAfter time.Duration
+	// Err is the underlying cause. It is never sent to clients.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any *Error with the same code, so a sentinel still matches
+// after Wrap or WithRetryAfter made a copy of it.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Kind == e.Kind
+}
+
+// Wrap returns a copy of e that records cause.
+func (e *Error) Wrap(cause error) *Error {
+	copied := *e
+	copied.Err = cause
+	return &copied
+}
+
+// WithRetryAfter returns a copy of e that tells the caller when to retry.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	copied := *e
+	copied.RetryAfter = d
+	return &copied
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Invalid(code, message string) *Error {
+	return New(KindInvalid, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Locked(code, message string) *Error {
+	return New(KindLocked, code, message)
+}
+
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+// Upstream reports that a dependency outside this service, such as the
+// payment gateway or image storage, failed. Wrap the cause before returning
+// it.
+func Upstream(code, message string) *Error {
+	return New(KindUpstream, code, message)
+}
+
+// Validation reports request fields that failed validation.
+func Validation(fields []FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: "validation_failed", Message: "request validation failed", Fields: fields}
+}
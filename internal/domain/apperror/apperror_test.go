@@ -0,0 +1,27 @@
+package apperror
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCopiesStillMatchTheirSentinel(t *testing.T) {
+	sentinel := Locked("account_locked", "account locked")
+	cause := errors.New("boom")
+
+	for _, err := range []error{sentinel.Wrap(cause), sentinel.WithRetryAfter(time.Second)} {
+		if !errors.Is(err, sentinel) {
+			t.Errorf("%v does not match its sentinel", err)
+		}
+		if errors.Is(err, Locked("other_code", "account locked")) {
+			t.Errorf("%v matches a sentinel with another code", err)
+		}
+	}
+	if !errors.Is(sentinel.Wrap(cause), cause) {
+		t.Error("wrapped error does not unwrap to its cause")
+	}
+	if sentinel.RetryAfter != 0 || sentinel.Err != nil {
+		t.Fatalf("sentinel was modified: %+v", sentinel)
+	}
+}
@@ -0,0 +1,108 @@
+package auditModels
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ActionCreate         = "create"
+	ActionUpdate         = "update"
+	ActionDelete         = "delete"
+	ActionUnlock         = "unlock"
+	ActionReset2FA       = "reset_2fa"
+	ActionStatusChange   = "status_change"
+	ActionSuspend        = "suspend"
+	ActionReactivate     = "reactivate"
+	ActionForceReset     = "force_password_reset"
+	ActionChangeRole     = "change_role"
+	ActionImpersonate    = "impersonate"
+	ActionRestore        = "restore"
+	ActionRequestErasure = "request_erasure"
+	ActionCancelErasure  = "cancel_erasure"
+	ActionErase          = "erase"
+)
+
+const (
+	EntityUser    = "user"
+	EntityProduct = "product"
+	EntityPayment = "payment"
+	EntityComment = "comment"
+)
+
+// EntityTypes lists every entity type the audit log records.
+var EntityTypes = []string{EntityUser, EntityProduct, EntityPayment, EntityComment}
+
+// Actor identifies who triggered a change. UserID is nil for anonymous
+// callers such as sign-up or the payment gateway webhook. ImpersonatorID is
+// the admin behind an impersonation token.
+type Actor struct {
+	UserID         *uuid.UUID
+	ImpersonatorID *uuid.UUID
+	IP             string
+	RequestID      string
+}
+
+// Change holds the before and after value of a single field.
+type Change struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Changes is stored as a jsonb object keyed by field name.
+type Changes map[string]Change
+
+func (c Changes) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (c *Changes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("auditModels: cannot scan %T into Changes", value)
+	}
+}
+
+// AuditLog rows are never updated or deleted; InitDB installs a trigger that
+// rejects both.
+type AuditLog struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	ActorID        *uuid.UUID `gorm:"type:uuid;index" json:"actor_id"`
+	ImpersonatorID *uuid.UUID `gorm:"type:uuid" json:"impersonator_id,omitempty"`
+	Action         string     `gorm:"not null" json:"action"`
+	EntityType     string     `gorm:"not null;index:idx_audit_entity" json:"entity_type"`
+	EntityID       string     `gorm:"not null;index:idx_audit_entity" json:"entity_id"`
+	Changes        Changes    `gorm:"type:jsonb;not null" json:"changes"`
+	IP             string     `json:"ip"`
+	RequestID      string     `json:"request_id"`
+	CreatedAt      time.Time  `gorm:"index" json:"created_at"`
+}
+
+// Filter narrows an audit log query. Zero values are ignored.
+type Filter struct {
+	ActorID    *uuid.UUID
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
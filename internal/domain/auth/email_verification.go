@@ -0,0 +1,19 @@
+package authModels
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailVerificationToken proves ownership of Email. The address is stored so
+// a token issued before an email change cannot verify the new address.
+type EmailVerificationToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Email     string    `gorm:"not null"`
+	TokenHash string    `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,18 @@
+package authModels
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExchangeCode is handed to the frontend after an OAuth callback and traded
+// once, server to server, for the actual token pair.
+type ExchangeCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	CodeHash  string    `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,12 @@
+package authModels
+
+import "time"
+
+// LoginThrottle counts recent failed logins for one key, either an account
+// ("user:<id>" or "email:<address>") or a client ("ip:<address>").
+type LoginThrottle struct {
+	Key           string `gorm:"primary_key"`
+	Failures      int    `gorm:"not null;default:0"`
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
@@ -0,0 +1,30 @@
+package authModels
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a single link in a rotation chain. Every token minted from
+// the same login shares a FamilyID so that reuse of an already rotated token
+// can revoke the whole chain.
+type RefreshToken struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	FamilyID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	TokenHash  string    `gorm:"not null;uniqueIndex"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	ReplacedBy *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt  time.Time
+}
+
+// RevokedToken records the jti of an access token that was revoked before it
+// expired, or of a single-use token such as an MFA challenge once it was
+// redeemed.
+type RevokedToken struct {
+	JTI       string    `gorm:"primary_key"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
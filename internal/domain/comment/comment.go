@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Comment struct {
@@ -12,4 +13,5 @@ type Comment struct {
 	ProductID uuid.UUID `gorm:"type:uuid;not null"`
 	Content   string
 	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
@@ -0,0 +1,22 @@
+package privacyModels
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErasureRequest schedules the anonymization of an account. It runs once
+// ScheduledFor has passed unless the user cancels first.
+type ErasureRequest struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"-"`
+	RequestedAt  time.Time  `gorm:"not null" json:"requested_at"`
+	ScheduledFor time.Time  `gorm:"not null;index" json:"scheduled_for"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+func (r ErasureRequest) Pending() bool {
+	return r.CancelledAt == nil && r.CompletedAt == nil
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Product struct {
@@ -17,4 +18,5 @@ type Product struct {
 	ImageURL    string
 	Comments    []CommentModels.Comment `gorm:"foreignKey:ProductID"`
 	CreatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
 }
@@ -0,0 +1,18 @@
+package userModels
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity links a user to an account at an external OAuth/OIDC provider. A
+// provider subject can belong to at most one user.
+type Identity struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider  string    `gorm:"not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject   string    `gorm:"not null;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package userModels
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+const (
+	PermUsersRead        = "users:read"
+	PermUsersWrite       = "users:write"
+	PermUsersImpersonate = "users:impersonate"
+	PermProductsRead     = "products:read"
+	PermProductsWrite    = "products:write"
+	PermCommentsWrite    = "comments:write"
+	PermCommentsModerate = "comments:moderate"
+	PermCartRead         = "cart:read"
+	PermCartWrite        = "cart:write"
+	PermPaymentsWrite    = "payments:write"
+	PermAuditRead        = "audit:read"
+)
+
+// RolePermissions maps every known role to the permissions it grants.
+var RolePermissions = map[string][]string{
+	RoleAdmin: {PermUsersRead, PermUsersWrite, PermUsersImpersonate, PermProductsRead, PermProductsWrite, PermCommentsWrite, PermCommentsModerate, PermCartRead, PermCartWrite, PermPaymentsWrite, PermAuditRead},
+	RoleUser:  {PermProductsRead, PermCommentsWrite, PermCartRead, PermCartWrite, PermPaymentsWrite},
+}
+
+func ValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+func HasPermission(role, permission string) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
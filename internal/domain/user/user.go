@@ -1,17 +1,65 @@
 package userModels
 
 import (
+	"fiber-crud/internal/domain/apperror"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// ErrSuspended is returned wherever a suspended account tries to sign in or
+// use an existing credential.
+var ErrSuspended = apperror.Forbidden("account_suspended", "account is suspended")
+
 type User struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
-	Name      string    `gorm:"unique;not null" json:"name"`
-	Email     string    `gorm:"unique;not null" json:"email"`
-	Password  string    `gorm:"not null" json:"password"`
-	Avatar    string    `json:"avatar"`
-	GoogleID  string    `json:"google_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primary_key" json:"id"`
+	Name            string     `gorm:"unique;not null" json:"name"`
+	Email           string     `gorm:"unique;not null" json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// Password keeps its JSON name so audit diffs can record, redacted, that
+	// it changed. Handlers map User to a response DTO and never serialize it.
+	Password        string     `gorm:"not null" json:"password"`
+	Avatar          string     `json:"avatar"`
+	Role            string     `gorm:"not null;default:user" json:"role"`
+	TOTPSecret      string     `json:"-"`
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at"`
+	TOTPLastStep    int64      `gorm:"not null;default:0" json:"-"`
+	SuspendedAt     *time.Time `gorm:"index" json:"suspended_at"`
+	SuspendedReason string     `json:"suspended_reason,omitempty"`
+	// PasswordResetRequired blocks password logins until the user completes
+	// a reset, set when an admin forces one.
+	PasswordResetRequired bool      `gorm:"not null;default:false" json:"password_reset_required"`
+	CreatedAt             time.Time `json:"created_at"`
+	// DeletedAt makes deletes soft; the row is purged once the retention
+	// period has passed.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+func (u User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u User) MFAEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+func (u User) Suspended() bool {
+	return u.SuspendedAt != nil
+}
+
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusDeleted   = "deleted"
+)
+
+// ListFilter selects a page of users for the admin listing. Zero values are
+// ignored.
+type ListFilter struct {
+	Query  string
+	Role   string
+	Status string
+	Page   int
+	Limit  int
 }
@@ -0,0 +1,116 @@
+package apiKeyHandler
+
+import (
+	apiKeyModels "fiber-crud/internal/domain/apikey"
+	"fiber-crud/internal/handler/binder"
+	apiKeyUsecase "fiber-crud/internal/usecase/apikey"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyUsecase apiKeyUsecase.APIKeyUsecase
+}
+
+func NewAPIKeyHandler(usecase apiKeyUsecase.APIKeyUsecase) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyUsecase: usecase}
+}
+
+type apiKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type createAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=0,max=365"`
+}
+
+func (r createAPIKeyRequest) toInput() apiKeyUsecase.CreateAPIKeyInput {
+	return apiKeyUsecase.CreateAPIKeyInput{
+		Name:          r.Name,
+		Scopes:        r.Scopes,
+		ExpiresInDays: r.ExpiresInDays,
+	}
+}
+
+func toResponse(key apiKeyModels.APIKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// Create returns the plaintext key exactly once.
+func (h *APIKeyHandler) Create(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	var request createAPIKeyRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
+	}
+
+	created, err := h.apiKeyUsecase.CreateAPIKey(c.UserContext(), userID, request.toInput())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"api_key": toResponse(created.APIKey),
+		"key":     created.Key,
+	})
+}
+
+func (h *APIKeyHandler) List(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	keys, err := h.apiKeyUsecase.ListAPIKeys(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	response := make([]apiKeyResponse, len(keys))
+	for i, key := range keys {
+		response[i] = toResponse(key)
+	}
+	return c.JSON(response)
+}
+
+func (h *APIKeyHandler) Revoke(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	keyID, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.apiKeyUsecase.RevokeAPIKey(c.UserContext(), userID, keyID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,87 @@
+package auditHandler
+
+import (
+	auditModels "fiber-crud/internal/domain/audit"
+	"fiber-crud/internal/handler/binder"
+	auditUsecase "fiber-crud/internal/usecase/audit"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AuditHandler struct {
+	auditUsecase auditUsecase.AuditUsecase
+}
+
+func NewAuditHandler(usecase auditUsecase.AuditUsecase) *AuditHandler {
+	return &AuditHandler{auditUsecase: usecase}
+}
+
+// Actor describes the caller for audit entries: the authenticated user if
+// any, the admin behind an impersonation token, the client IP and the
+// request ID assigned by the requestid middleware.
+func Actor(c *fiber.Ctx) auditModels.Actor {
+	actor := auditModels.Actor{IP: c.IP()}
+	if userIDStr, ok := c.Locals("userID").(string); ok {
+		if userID, err := uuid.Parse(userIDStr); err == nil {
+			actor.UserID = &userID
+		}
+	}
+	if adminIDStr, ok := c.Locals("impersonatorID").(string); ok {
+		if adminID, err := uuid.Parse(adminIDStr); err == nil {
+			actor.ImpersonatorID = &adminID
+		}
+	}
+	if requestID, ok := c.Locals("requestid").(string); ok {
+		actor.RequestID = requestID
+	}
+	return actor
+}
+
+// auditLogQuery holds the GET /admin/audit-logs query parameters. Times
+// are RFC 3339.
+type auditLogQuery struct {
+	ActorID    string `query:"actor_id" validate:"omitempty,uuid"`
+	EntityType string `query:"entity_type" validate:"omitempty,entity_type"`
+	EntityID   string `query:"entity_id" validate:"max=64"`
+	From       string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To         string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Limit      int    `query:"limit" validate:"min=0,max=500"`
+	Offset     int    `query:"offset" validate:"min=0"`
+}
+
+// toFilter converts a validated query, so the values already parse.
+func (q auditLogQuery) toFilter() auditModels.Filter {
+	filter := auditModels.Filter{
+		EntityType: q.EntityType,
+		EntityID:   q.EntityID,
+		Limit:      q.Limit,
+		Offset:     q.Offset,
+	}
+	if actorID, err := uuid.Parse(q.ActorID); err == nil {
+		filter.ActorID = &actorID
+	}
+	if from, err := time.Parse(time.RFC3339, q.From); err == nil {
+		filter.From = &from
+	}
+	if to, err := time.Parse(time.RFC3339, q.To); err == nil {
+		filter.To = &to
+	}
+	return filter
+}
+
+func (h *AuditHandler) GetAuditLogs(c *fiber.Ctx) error {
+	var query auditLogQuery
+	if err := binder.Query(c, &query); err != nil {
+		return err
+	}
+	filter := query.toFilter()
+
+	entries, total, err := h.auditUsecase.Find(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"data": toAuditLogResponses(entries), "total": total})
+}
@@ -0,0 +1,50 @@
+package auditHandler
+
+import (
+	"time"
+
+	auditModels "fiber-crud/internal/domain/audit"
+
+	"github.com/google/uuid"
+)
+
+type changeResponse struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+type auditLogResponse struct {
+	ID             uuid.UUID                 `json:"id"`
+	ActorID        *uuid.UUID                `json:"actor_id"`
+	ImpersonatorID *uuid.UUID                `json:"impersonator_id,omitempty"`
+	Action         string                    `json:"action"`
+	EntityType     string                    `json:"entity_type"`
+	EntityID       string                    `json:"entity_id"`
+	Changes        map[string]changeResponse `json:"changes"`
+	IP             string                    `json:"ip"`
+	RequestID      string                    `json:"request_id"`
+	CreatedAt      time.Time                 `json:"created_at"`
+}
+
+func toAuditLogResponses(entries []auditModels.AuditLog) []auditLogResponse {
+	response := make([]auditLogResponse, len(entries))
+	for i, entry := range entries {
+		changes := make(map[string]changeResponse, len(entry.Changes))
+		for field, change := range entry.Changes {
+			changes[field] = changeResponse{Before: change.Before, After: change.After}
+		}
+		response[i] = auditLogResponse{
+			ID:             entry.ID,
+			ActorID:        entry.ActorID,
+			ImpersonatorID: entry.ImpersonatorID,
+			Action:         entry.Action,
+			EntityType:     entry.EntityType,
+			EntityID:       entry.EntityID,
+			Changes:        changes,
+			IP:             entry.IP,
+			RequestID:      entry.RequestID,
+			CreatedAt:      entry.CreatedAt,
+		}
+	}
+	return response
+}
@@ -0,0 +1,135 @@
+package authHandler
+
+import (
+	"fiber-crud/internal/handler/binder"
+	authUsecase "fiber-crud/internal/usecase/auth"
+	"fiber-crud/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuthHandler struct {
+	authUsecase authUsecase.AuthUsecase
+}
+
+func NewAuthHandler(usecase authUsecase.AuthUsecase) *AuthHandler {
+	return &AuthHandler{authUsecase: usecase}
+}
+
+// ClientInfo captures the caller's address and user agent for the session
+// record.
+func ClientInfo(c *fiber.Ctx) authUsecase.ClientInfo {
+	return authUsecase.ClientInfo{
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// logoutRequest optionally names the refresh token to revoke along with the
+// access token.
+type logoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type exchangeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var request refreshRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
+	}
+
+	tokens, err := h.authUsecase.Refresh(c.UserContext(), request.RefreshToken, ClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tokens)
+}
+
+// Exchange trades the one-time code from an OAuth redirect for tokens.
+func (h *AuthHandler) Exchange(c *fiber.Ctx) error {
+	var request exchangeRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
+	}
+
+	tokens, err := h.authUsecase.RedeemExchangeCode(c.UserContext(), request.Code, ClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tokens)
+}
+
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var request logoutRequest
+	if len(c.Body()) > 0 {
+		if err := binder.Body(c, &request); err != nil {
+			return err
+		}
+	}
+
+	claims, _ := c.Locals("claims").(*utils.Claims)
+
+	if err := h.authUsecase.Logout(c.UserContext(), claims, request.RefreshToken); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *AuthHandler) GetSessions(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := h.authUsecase.GetSessions(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	current := ""
+	if claims, ok := c.Locals("claims").(*utils.Claims); ok {
+		current = claims.SessionID
+	}
+
+	return c.JSON(toSessionResponses(sessions, current))
+}
+
+func (h *AuthHandler) RevokeSession(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	sessionID, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.authUsecase.RevokeSession(c.UserContext(), userID, sessionID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// JWKS publishes the public verification keys so other services can validate
+// tokens issued by this API.
+func (h *AuthHandler) JWKS(c *fiber.Ctx) error {
+	keys, err := utils.JWKS()
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(fiber.Map{"keys": keys})
+}
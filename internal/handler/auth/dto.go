@@ -0,0 +1,37 @@
+package authHandler
+
+import (
+	"time"
+
+	authModels "fiber-crud/internal/domain/auth"
+
+	"github.com/google/uuid"
+)
+
+type sessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// toSessionResponses marks the session whose ID is currentID as the one the
+// request was made with.
+func toSessionResponses(sessions []authModels.Session, currentID string) []sessionResponse {
+	response := make([]sessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = sessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID.String() == currentID,
+		}
+	}
+	return response
+}
@@ -0,0 +1,201 @@
+// Package binder parses request bodies and query strings into DTOs and
+// validates them against their `validate` struct tags. Failures are
+// *apperror.Error values that handlers return as they are.
+package binder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"fiber-crud/internal/domain/apperror"
+	auditModels "fiber-crud/internal/domain/audit"
+	userModels "fiber-crud/internal/domain/user"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ErrMalformedBody is returned when the body cannot be decoded at all, as
+// opposed to decoding into values that fail validation.
+var ErrMalformedBody = apperror.Invalid("malformed_body", "invalid request body")
+
+// domainRules are validation tags backed by lists the domain packages own,
+// so the accepted values cannot drift from what the usecases enforce.
+var domainRules = map[string][]string{
+	"role":        roleNames(),
+	"entity_type": auditModels.EntityTypes,
+}
+
+func roleNames() []string {
+	roles := make([]string, 0, len(userModels.RolePermissions))
+	for role := range userModels.RolePermissions {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(fieldName)
+	for tag, allowed := range domainRules {
+		allowed := allowed
+		v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			value := fl.Field().String()
+			for _, a := range allowed {
+				if value == a {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	return v
+}
+
+// fieldName reports fields by their json, form or query name so errors
+// match the request the client sent.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// Body decodes the request body into out and validates it.
+func Body(c *fiber.Ctx, out interface{}) error {
+	if err := c.BodyParser(out); err != nil {
+		return ErrMalformedBody
+	}
+	return Validate(out)
+}
+
+// Query decodes the query string into out and validates it.
+func Query(c *fiber.Ctx, out interface{}) error {
+	if err := c.QueryParser(out); err != nil {
+		return apperror.Validation([]apperror.FieldError{{
+			Field:   "query",
+			Rule:    "type",
+			Message: "contains a value of the wrong type",
+		}})
+	}
+	return Validate(out)
+}
+
+// Validate runs the struct's validation tags and collects every failure
+// into one validation error.
+func Validate(v interface{}) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return err
+	}
+
+	fields := make([]apperror.FieldError, len(invalid))
+	for i, fe := range invalid {
+		fields[i] = apperror.FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(fe),
+		}
+	}
+	return apperror.Validation(fields)
+}
+
+// fieldPath drops the top-level struct name from the namespace, leaving
+// e.g. "scopes[0]".
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func message(fe validator.FieldError) string {
+	if allowed, ok := domainRules[fe.Tag()]; ok {
+		return "must be one of: " + strings.Join(allowed, ", ")
+	}
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "datetime":
+		return "must be an RFC 3339 timestamp"
+	case "min":
+		if hasLength(fe.Kind()) {
+			return fmt.Sprintf("must contain at least %s %s", fe.Param(), unit(fe.Kind()))
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if hasLength(fe.Kind()) {
+			return fmt.Sprintf("must contain at most %s %s", fe.Param(), unit(fe.Kind()))
+		}
+		return "must be at most " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " rule"
+	}
+}
+
+func hasLength(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Map || kind == reflect.Array
+}
+
+func unit(kind reflect.Kind) string {
+	if kind == reflect.String {
+		return "characters"
+	}
+	return "items"
+}
+
+// UUIDParam reads a UUID route parameter.
+func UUIDParam(c *fiber.Ctx, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params(name))
+	if err != nil {
+		return uuid.Nil, apperror.Validation([]apperror.FieldError{{
+			Field:   name,
+			Rule:    "uuid",
+			Message: "must be a valid UUID",
+		}})
+	}
+	return id, nil
+}
+
+var errUnauthenticated = apperror.Unauthorized("unauthenticated", "authentication required")
+
+// UserID reads the authenticated user's ID set by the auth middleware.
+func UserID(c *fiber.Ctx) (uuid.UUID, error) {
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return uuid.Nil, errUnauthenticated
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, errUnauthenticated
+	}
+	return userID, nil
+}
@@ -0,0 +1,106 @@
+package binder
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fiber-crud/internal/domain/apperror"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type signupRequest struct {
+	Name   string   `json:"name" validate:"required,max=5"`
+	Email  string   `json:"email" validate:"required,email"`
+	Role   string   `json:"role" validate:"omitempty,role"`
+	Scopes []string `json:"scopes" validate:"dive,required"`
+}
+
+type listQuery struct {
+	Limit int `query:"limit" validate:"max=10"`
+}
+
+// bind runs Body or Query inside a request and returns its error.
+func bind(t *testing.T, req *http.Request) error {
+	t.Helper()
+	var bindErr error
+	app := fiber.New()
+	app.Post("/", func(c *fiber.Ctx) error {
+		bindErr = Body(c, &signupRequest{})
+		return nil
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		bindErr = Query(c, &listQuery{})
+		return nil
+	})
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+	return bindErr
+}
+
+func post(t *testing.T, body string) error {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return bind(t, req)
+}
+
+func TestBodyReportsEveryFailingField(t *testing.T) {
+	err := post(t, `{"name":"too-long","email":"nope","role":"root","scopes":["read",""]}`)
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || appErr.Kind != apperror.KindValidation {
+		t.Fatalf("Body = %v, want a validation error", err)
+	}
+
+	got := map[string]apperror.FieldError{}
+	for _, field := range appErr.Fields {
+		got[field.Field] = field
+	}
+	want := map[string]string{
+		"name":      "must contain at most 5 characters",
+		"email":     "must be a valid email address",
+		"role":      "must be one of: admin, user",
+		"scopes[1]": "is required",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("fields = %+v, want %d entries", appErr.Fields, len(want))
+	}
+	for field, message := range want {
+		if got[field].Message != message {
+			t.Errorf("%s = %+v, want message %q", field, got[field], message)
+		}
+	}
+}
+
+func TestBodyAcceptsValidRequest(t *testing.T) {
+	if err := post(t, `{"name":"alice","email":"alice@example.com","role":"admin"}`); err != nil {
+		t.Fatalf("Body = %v, want nil", err)
+	}
+}
+
+func TestMalformedBodyIsNotAValidationError(t *testing.T) {
+	if err := post(t, `{"name":`); !errors.Is(err, ErrMalformedBody) {
+		t.Fatalf("Body = %v, want ErrMalformedBody", err)
+	}
+}
+
+func TestQueryValidation(t *testing.T) {
+	for query, valid := range map[string]bool{
+		"/?limit=5":   true,
+		"/?limit=50":  false,
+		"/?limit=abc": false,
+	} {
+		err := bind(t, httptest.NewRequest(http.MethodGet, query, nil))
+		var appErr *apperror.Error
+		if valid && err != nil {
+			t.Errorf("GET %s = %v, want nil", query, err)
+		}
+		if !valid && (!errors.As(err, &appErr) || appErr.Kind != apperror.KindValidation) {
+			t.Errorf("GET %s = %v, want a validation error", query, err)
+		}
+	}
+}
@@ -1,10 +1,10 @@
 package handler
 
 import (
+	"fiber-crud/internal/handler/binder"
 	usecase "fiber-crud/internal/usecase/cart"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type CartHandler struct {
@@ -18,60 +18,38 @@ func NewCartHandler(cartUsecase usecase.CartUsecase) *CartHandler {
 }
 
 func (h *CartHandler) AddItemToCart(c *fiber.Ctx) error {
-	productIDStr := c.Params("id")
-	productID, err := uuid.Parse(productIDStr)
+	productID, err := binder.UUIDParam(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid product ID format"})
+		return err
 	}
 
-	var request struct {
-		Quantity int `json:"quantity"`
+	var request addItemRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
 	}
 
-	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
-	}
-
-	if request.Quantity <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Quantity must be greater than zero"})
-	}
-
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User not authenticated"})
-	}
-
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	err = h.cartUsecase.AddItemToCart(userID, productID, request.Quantity)
-	if err != nil {
-		if err == usecase.ErrNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.cartUsecase.AddItemToCart(c.UserContext(), userID, productID, request.Quantity); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Item added to cart successfully"})
 }
 
 func (h *CartHandler) GetAllcartItems(c *fiber.Ctx) error {
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User not authenticated"})
-	}
-
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	items, err := h.cartUsecase.GetAllcartItems(userID)
+	items, err := h.cartUsecase.GetAllcartItems(c.UserContext(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(items)
+	return c.Status(fiber.StatusOK).JSON(toCartItemResponses(items))
 }
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	cartModels "fiber-crud/internal/domain/cart"
+
+	"github.com/google/uuid"
+)
+
+type addItemRequest struct {
+	Quantity int `json:"quantity" validate:"min=1,max=1000"`
+}
+
+type cartProductResponse struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Price    float64   `json:"price"`
+	Stock    int       `json:"stock"`
+	ImageURL string    `json:"image_url"`
+}
+
+type cartItemResponse struct {
+	ID        uuid.UUID           `json:"id"`
+	ProductID uuid.UUID           `json:"product_id"`
+	Quantity  int                 `json:"quantity"`
+	Product   cartProductResponse `json:"product"`
+}
+
+func toCartItemResponses(items []cartModels.CartModels) []cartItemResponse {
+	response := make([]cartItemResponse, len(items))
+	for i, item := range items {
+		response[i] = cartItemResponse{
+			ID:        item.ID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Product: cartProductResponse{
+				ID:       item.Product.ID,
+				Name:     item.Product.Name,
+				Price:    item.Product.Price,
+				Stock:    item.Product.Stock,
+				ImageURL: item.Product.ImageURL,
+			},
+		}
+	}
+	return response
+}
@@ -2,10 +2,13 @@ package commentHandler
 
 import (
 	CommentModels "fiber-crud/internal/domain/comment"
+	userModels "fiber-crud/internal/domain/user"
+	auditHandler "fiber-crud/internal/handler/audit"
+	"fiber-crud/internal/handler/binder"
 	commentUsecase "fiber-crud/internal/usecase/comment"
+	"fiber-crud/middleware"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type CommentHandler struct {
@@ -19,30 +22,19 @@ func NewCommentHandler(usecase commentUsecase.CommentUsecase) *CommentHandler {
 }
 
 func (h *CommentHandler) CreateCommentProductID(c *fiber.Ctx) error {
-	// Parse the product ID from the URL parameters
-	idstr := c.Params("id")
-	id, err := uuid.Parse(idstr)
+	id, err := binder.UUIDParam(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid product ID format"})
+		return err
 	}
 
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
-	}
-
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	type CommentRequest struct {
-		Content string `json:"content"`
-	}
-
-	var requestBody CommentRequest
-	if err := c.BodyParser(&requestBody); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	var requestBody commentRequest
+	if err := binder.Body(c, &requestBody); err != nil {
+		return err
 	}
 
 	comment := &CommentModels.Comment{
@@ -51,38 +43,61 @@ func (h *CommentHandler) CreateCommentProductID(c *fiber.Ctx) error {
 		Content:   requestBody.Content,
 	}
 
-	err = h.commentUsecase.CreateComment(comment)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.commentUsecase.CreateComment(c.UserContext(), comment); err != nil {
+		return err
 	}
 
 	return c.JSON(fiber.Map{
 		"status":  "success",
-		"comment": comment,
+		"comment": toCommentResponse(*comment),
 	})
 }
 
 func (h *CommentHandler) GetCommentsByProductid(c *fiber.Ctx) error {
-	idstr := c.Params("id")
-	id, err := uuid.Parse(idstr)
+	id, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	comments, err := h.commentUsecase.Getcommentproductid(c.UserContext(), id, userID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid product ID format"})
+		return err
 	}
 
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+	return c.JSON(toCommentResponses(comments))
+}
+
+func (h *CommentHandler) DeleteComment(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	comments, err := h.commentUsecase.Getcommentproductid(id, userID)
+	canModerate := middleware.HasPermission(c, userModels.PermCommentsModerate)
+	if err := h.commentUsecase.DeleteComment(c.UserContext(), auditHandler.Actor(c), id, userID, canModerate); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *CommentHandler) RestoreComment(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(comments)
+	if err := h.commentUsecase.RestoreComment(c.UserContext(), auditHandler.Actor(c), id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
 }
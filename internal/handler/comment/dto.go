@@ -0,0 +1,39 @@
+package commentHandler
+
+import (
+	"time"
+
+	CommentModels "fiber-crud/internal/domain/comment"
+
+	"github.com/google/uuid"
+)
+
+type commentRequest struct {
+	Content string `json:"content" validate:"required,max=2000"`
+}
+
+type commentResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toCommentResponse(comment CommentModels.Comment) commentResponse {
+	return commentResponse{
+		ID:        comment.ID,
+		UserID:    comment.UserID,
+		ProductID: comment.ProductID,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+func toCommentResponses(comments []CommentModels.Comment) []commentResponse {
+	response := make([]commentResponse, len(comments))
+	for i, comment := range comments {
+		response[i] = toCommentResponse(comment)
+	}
+	return response
+}
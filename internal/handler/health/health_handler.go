@@ -0,0 +1,113 @@
+package healthHandler
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// checkTimeout bounds each dependency check so a hung dependency cannot
+// stall the probe past its own deadline.
+const checkTimeout = 2 * time.Second
+
+// Check reports whether a dependency is usable.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type checkResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type readinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+type HealthHandler struct {
+	checks       []namedCheck
+	hideErrors   bool
+	shuttingDown atomic.Bool
+}
+
+// NewHealthHandler creates a handler that checks Postgres through db. With
+// hideErrors set, failing checks are reported without their error text.
+func NewHealthHandler(db *gorm.DB, hideErrors bool) *HealthHandler {
+	h := &HealthHandler{hideErrors: hideErrors}
+	h.AddCheck("postgres", databaseCheck(db))
+	return h
+}
+
+// AddCheck registers a dependency that must pass for /readyz to succeed.
+func (h *HealthHandler) AddCheck(name string, check Check) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown makes /readyz fail from now on so load balancers stop
+// routing new requests while in-flight ones drain.
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness answers as long as the process can serve requests at all. It
+// never checks dependencies, so an outage does not get the pod restarted.
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// Readiness runs every check and answers 503 when any of them fails or the
+// server is shutting down.
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	if h.shuttingDown.Load() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(readinessResponse{Status: "shutting_down"})
+	}
+
+	response := readinessResponse{Status: "ready", Checks: make(map[string]checkResult, len(h.checks))}
+	for _, nc := range h.checks {
+		result := h.run(c.UserContext(), nc.check)
+		if result.Status != "ok" {
+			response.Status = "not_ready"
+		}
+		response.Checks[nc.name] = result
+	}
+
+	if response.Status != "ready" {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+	}
+	return c.JSON(response)
+}
+
+func (h *HealthHandler) run(ctx context.Context, check Check) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := checkResult{Status: "ok", LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = "fail"
+		if !h.hideErrors {
+			result.Error = err.Error()
+		}
+	}
+	return result
+}
+
+// databaseCheck pings Postgres through the GORM connection pool.
+func databaseCheck(db *gorm.DB) Check {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
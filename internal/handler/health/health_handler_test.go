@@ -0,0 +1,117 @@
+package healthHandler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func passing(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func probe(t *testing.T, h *HealthHandler, path string) (int, readinessResponse) {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/healthz", h.Liveness)
+	app.Get("/readyz", h.Readiness)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body readinessResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestReadinessPassesWhenEveryCheckPasses(t *testing.T) {
+	h := &HealthHandler{}
+	h.AddCheck("postgres", passing)
+	h.AddCheck("image_storage", passing)
+
+	status, body := probe(t, h, "/readyz")
+	if status != http.StatusOK || body.Status != "ready" || len(body.Checks) != 2 {
+		t.Fatalf("GET /readyz = %d %+v, want 200 with both checks", status, body)
+	}
+}
+
+func TestReadinessFailsWithTheFailingCheck(t *testing.T) {
+	h := &HealthHandler{}
+	h.AddCheck("postgres", failing)
+	h.AddCheck("image_storage", passing)
+
+	status, body := probe(t, h, "/readyz")
+	if status != http.StatusServiceUnavailable || body.Status != "not_ready" {
+		t.Fatalf("GET /readyz = %d %+v, want 503 not_ready", status, body)
+	}
+	if got := body.Checks["postgres"]; got.Status != "fail" || got.Error != "connection refused" {
+		t.Fatalf("postgres check = %+v, want a failure with its error", got)
+	}
+	if got := body.Checks["image_storage"]; got.Status != "ok" {
+		t.Fatalf("image_storage check = %+v, want ok", got)
+	}
+}
+
+func TestReadinessHidesErrorsWhenAsked(t *testing.T) {
+	h := &HealthHandler{hideErrors: true}
+	h.AddCheck("postgres", failing)
+
+	_, body := probe(t, h, "/readyz")
+	if got := body.Checks["postgres"]; got.Status != "fail" || got.Error != "" {
+		t.Fatalf("postgres check = %+v, want a failure without its error", got)
+	}
+}
+
+func TestReadinessFailsWhileShuttingDown(t *testing.T) {
+	h := &HealthHandler{}
+	h.AddCheck("postgres", passing)
+	h.SetShuttingDown()
+
+	status, body := probe(t, h, "/readyz")
+	if status != http.StatusServiceUnavailable || body.Status != "shutting_down" {
+		t.Fatalf("GET /readyz during shutdown = %d %+v, want 503 shutting_down", status, body)
+	}
+	if status, _ := probe(t, h, "/healthz"); status != http.StatusOK {
+		t.Fatalf("GET /healthz during shutdown = %d, want 200", status)
+	}
+}
+
+func TestLivenessIgnoresDependencies(t *testing.T) {
+	h := &HealthHandler{}
+	h.AddCheck("postgres", failing)
+
+	if status, _ := probe(t, h, "/healthz"); status != http.StatusOK {
+		t.Fatalf("GET /healthz = %d, want 200 while a dependency is down", status)
+	}
+}
+
+func TestDatabaseCheckFailsWithoutADatabase(t *testing.T) {
+	// Nothing listens on port 1, so the ping is refused straight away.
+	sqlDB, err := sql.Open("pgx", "postgres://127.0.0.1:1/postgres?connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, body := probe(t, NewHealthHandler(db, false), "/readyz")
+	if status != http.StatusServiceUnavailable || body.Checks["postgres"].Status != "fail" {
+		t.Fatalf("GET /readyz = %d %+v, want the postgres check to fail", status, body)
+	}
+}
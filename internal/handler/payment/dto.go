@@ -0,0 +1,12 @@
+package paymentHandler
+
+// paymentCallbackRequest is the subset of the Midtrans notification the
+// handler reads.
+type paymentCallbackRequest struct {
+	OrderID string `json:"order_id" validate:"required,uuid"`
+	Status  string `json:"transaction_status" validate:"required"`
+}
+
+type createPaymentResponse struct {
+	RedirectURL string `json:"redirect_url"`
+}
@@ -1,8 +1,8 @@
 package paymentHandler
 
 import (
-	"net/http"
-
+	auditHandler "fiber-crud/internal/handler/audit"
+	"fiber-crud/internal/handler/binder"
 	paymentUsecase "fiber-crud/internal/usecase/payment"
 
 	"github.com/gofiber/fiber/v2"
@@ -20,58 +20,30 @@ func NewPaymentHandler(usecase paymentUsecase.PaymentUsecase) *PaymentHandler {
 }
 
 func (h *PaymentHandler) CreatePayment(c *fiber.Ctx) error {
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User not authenticated"})
-	}
-
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	redirectURL, err := h.usecase.CreatePaymentMidtrans(userID)
+	redirectURL, err := h.usecase.CreatePaymentMidtrans(c.UserContext(), auditHandler.Actor(c), userID)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"redirect_url": redirectURL,
-	})
+	return c.JSON(createPaymentResponse{RedirectURL: redirectURL})
 }
 
 func (h *PaymentHandler) UpdatePaymentStatus(c *fiber.Ctx) error {
-	var callbackData struct {
-		OrderID string `json:"order_id"`
-		Status  string `json:"transaction_status"`
-	}
-
-	if err := c.BodyParser(&callbackData); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request payload",
-		})
-	}
-
-	if len(callbackData.OrderID) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Order ID cannot be empty",
-		})
+	var callbackData paymentCallbackRequest
+	if err := binder.Body(c, &callbackData); err != nil {
+		return err
 	}
 
-	orderID, err := uuid.Parse(callbackData.OrderID)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid order ID format",
-		})
-	}
+	// The binder already checked the format.
+	orderID := uuid.MustParse(callbackData.OrderID)
 
-	err = h.usecase.UpdatePaymentstatus(orderID, callbackData.Status)
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.usecase.UpdatePaymentstatus(c.UserContext(), auditHandler.Actor(c), orderID, callbackData.Status); err != nil {
+		return err
 	}
 
 	return c.JSON(fiber.Map{
@@ -0,0 +1,27 @@
+package privacyHandler
+
+import (
+	"time"
+
+	privacyModels "fiber-crud/internal/domain/privacy"
+
+	"github.com/google/uuid"
+)
+
+type erasureResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	RequestedAt  time.Time  `json:"requested_at"`
+	ScheduledFor time.Time  `json:"scheduled_for"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+func toErasureResponse(request *privacyModels.ErasureRequest) erasureResponse {
+	return erasureResponse{
+		ID:           request.ID,
+		RequestedAt:  request.RequestedAt,
+		ScheduledFor: request.ScheduledFor,
+		CancelledAt:  request.CancelledAt,
+		CompletedAt:  request.CompletedAt,
+	}
+}
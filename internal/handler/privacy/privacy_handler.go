@@ -0,0 +1,136 @@
+package privacyHandler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	auditHandler "fiber-crud/internal/handler/audit"
+	"fiber-crud/internal/handler/binder"
+	privacyUsecase "fiber-crud/internal/usecase/privacy"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PrivacyHandler struct {
+	privacyUsecase privacyUsecase.PrivacyUsecase
+}
+
+func NewPrivacyHandler(usecase privacyUsecase.PrivacyUsecase) *PrivacyHandler {
+	return &PrivacyHandler{privacyUsecase: usecase}
+}
+
+type exportQuery struct {
+	Format string `query:"format" validate:"oneof=json zip"`
+}
+
+// Export serves GET /auth/me/export. The bundle is a single JSON document by
+// default, or a ZIP with one JSON file per section when format=zip.
+func (h *PrivacyHandler) Export(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	query := exportQuery{Format: "json"}
+	if err := binder.Query(c, &query); err != nil {
+		return err
+	}
+
+	export, err := h.privacyUsecase.Export(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	filename := "export-" + export.ExportedAt.Format("20060102T150405Z")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	if query.Format == "json" {
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		return c.JSON(export)
+	}
+
+	archive, err := zipExport(export)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	return c.Send(archive)
+}
+
+func zipExport(export *privacyUsecase.Export) ([]byte, error) {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"linked_identities.json", export.Identities},
+		{"comments.json", export.Comments},
+		{"cart_items.json", export.CartItems},
+		{"payments.json", export.Payments},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (h *PrivacyHandler) GetErasure(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	request, err := h.privacyUsecase.GetErasure(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(toErasureResponse(request))
+}
+
+// RequestErasure schedules the caller's account for anonymization after the
+// cooling-off period. Payments are kept for accounting.
+func (h *PrivacyHandler) RequestErasure(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	request, err := h.privacyUsecase.RequestErasure(c.UserContext(), auditHandler.Actor(c), userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(toErasureResponse(request))
+}
+
+func (h *PrivacyHandler) CancelErasure(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.privacyUsecase.CancelErasure(c.UserContext(), auditHandler.Actor(c), userID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
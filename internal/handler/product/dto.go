@@ -0,0 +1,83 @@
+package ProductHandler
+
+import (
+	"time"
+
+	CommentModels "fiber-crud/internal/domain/comment"
+	ProductModels "fiber-crud/internal/domain/product"
+
+	"github.com/google/uuid"
+)
+
+// productRequest is bound from JSON or from the multipart form that carries
+// the image. The owner, ID and image URL are set by the handler.
+type productRequest struct {
+	Name        string  `json:"name" form:"name" validate:"required,max=255"`
+	Description string  `json:"description" form:"description" validate:"max=5000"`
+	Price       float64 `json:"price" form:"price" validate:"min=0"`
+	Stock       int     `json:"stock" form:"stock" validate:"min=0"`
+}
+
+func (r productRequest) apply(product *ProductModels.Product) {
+	product.Name = r.Name
+	product.Description = r.Description
+	product.Price = r.Price
+	product.Stock = r.Stock
+}
+
+type productCommentResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type productResponse struct {
+	ID          uuid.UUID                `json:"id"`
+	UserID      uuid.UUID                `json:"user_id"`
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	Price       float64                  `json:"price"`
+	Stock       int                      `json:"stock"`
+	ImageURL    string                   `json:"image_url"`
+	Comments    []productCommentResponse `json:"comments,omitempty"`
+	CreatedAt   time.Time                `json:"created_at"`
+}
+
+func toProductResponse(product ProductModels.Product) productResponse {
+	return productResponse{
+		ID:          product.ID,
+		UserID:      product.UserID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Stock:       product.Stock,
+		ImageURL:    product.ImageURL,
+		Comments:    toProductCommentResponses(product.Comments),
+		CreatedAt:   product.CreatedAt,
+	}
+}
+
+func toProductResponses(products []ProductModels.Product) []productResponse {
+	response := make([]productResponse, len(products))
+	for i, product := range products {
+		response[i] = toProductResponse(product)
+	}
+	return response
+}
+
+func toProductCommentResponses(comments []CommentModels.Comment) []productCommentResponse {
+	if len(comments) == 0 {
+		return nil
+	}
+	response := make([]productCommentResponse, len(comments))
+	for i, comment := range comments {
+		response[i] = productCommentResponse{
+			ID:        comment.ID,
+			UserID:    comment.UserID,
+			Content:   comment.Content,
+			CreatedAt: comment.CreatedAt,
+		}
+	}
+	return response
+}
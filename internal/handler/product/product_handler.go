@@ -1,14 +1,18 @@
 package ProductHandler
 
 import (
+	"fiber-crud/internal/domain/apperror"
 	ProductModels "fiber-crud/internal/domain/product"
+	auditHandler "fiber-crud/internal/handler/audit"
+	"fiber-crud/internal/handler/binder"
 	productUsecase "fiber-crud/internal/usecase/product"
 	"fiber-crud/utils"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
+var errImageUpload = apperror.Upstream("image_upload_failed", "failed to upload image")
+
 type ProductHandler struct {
 	productUsecase productUsecase.ProductUsecase
 }
@@ -20,188 +24,160 @@ func NewProductHandler(productUsecase productUsecase.ProductUsecase) *ProductHan
 }
 
 func (h *ProductHandler) FindAll(c *fiber.Ctx) error {
-	// Ambil userID dari c.Locals dan konversi ke uuid.UUID
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
-	}
-
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	products, err := h.productUsecase.GetProducts(userID)
+	products, err := h.productUsecase.GetProducts(c.UserContext(), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
-	return c.JSON(fiber.Map{"data": fiber.Map{"products": products}})
+	return c.JSON(fiber.Map{"data": fiber.Map{"products": toProductResponses(products)}})
 }
 
 func (h *ProductHandler) FindByID(c *fiber.Ctx) error {
-	idstr := c.Params("id")
-	id, err := uuid.Parse(idstr)
+	id, err := binder.UUIDParam(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid product ID format"})
+		return err
 	}
 
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
-	}
-
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	product, err := h.productUsecase.GetProductByID(id, userID)
+	product, err := h.productUsecase.GetProductByID(c.UserContext(), id, userID)
 	if err != nil {
-		if err == productUsecase.ErrNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(product)
+	return c.JSON(toProductResponse(product))
 }
 
 func (h *ProductHandler) Create(c *fiber.Ctx) error {
-	var product ProductModels.Product
-	if err := c.BodyParser(&product); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+	var request productRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	product.UserID = userID
-
-	file, err := c.FormFile("image")
-	if err == nil {
-		fileContent, err := file.Open()
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open uploaded file"})
-		}
-		defer fileContent.Close()
-
-		imageURL, err := utils.UploadImageToCloudinary(fileContent)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to upload image"})
-		}
+	product := ProductModels.Product{UserID: userID}
+	request.apply(&product)
 
+	if imageURL, err := uploadImage(c); err != nil {
+		return err
+	} else if imageURL != "" {
 		product.ImageURL = imageURL
 	}
 
-	res, err := h.productUsecase.CreateProduct(&product)
+	res, err := h.productUsecase.CreateProduct(c.UserContext(), auditHandler.Actor(c), &product)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(res)
+	return c.Status(fiber.StatusCreated).JSON(toProductResponse(*res))
 }
 
 func (h *ProductHandler) Update(c *fiber.Ctx) error {
 
-	var product ProductModels.Product
-	if err := c.BodyParser(&product); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	var request productRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
 	}
 
-	idstr := c.Params("id")
-	id, err := uuid.Parse(idstr)
+	id, err := binder.UUIDParam(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid product ID format"})
+		return err
 	}
 
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	existingProduct, err := h.productUsecase.GetProductByID(c.UserContext(), id, userID)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	product.ID = id
+	product := existingProduct
+	request.apply(&product)
 
-	existingProduct, err := h.productUsecase.GetProductByID(id, userID)
-	if err != nil {
-		if err == productUsecase.ErrNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if imageURL, err := uploadImage(c); err != nil {
+		return err
+	} else if imageURL != "" {
+		product.ImageURL = imageURL
 	}
 
-	if file, err := c.FormFile("image"); err == nil {
-		fileContent, err := file.Open()
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open uploaded file"})
-		}
-		defer fileContent.Close()
+	if err := h.productUsecase.UpdateProduct(c.UserContext(), auditHandler.Actor(c), &product, userID); err != nil {
+		return err
+	}
 
-		imageURL, err := utils.UploadImageToCloudinary(fileContent)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to upload image"})
-		}
+	return c.Status(fiber.StatusOK).JSON(toProductResponse(product))
+}
 
-		product.ImageURL = imageURL
-	} else if existingProduct.ImageURL != "" {
-		product.ImageURL = existingProduct.ImageURL
-	} else {
-		product.ImageURL = ""
+// uploadImage stores the optional "image" form file and returns its URL, or
+// an empty string when the request has none.
+func uploadImage(c *fiber.Ctx) (string, error) {
+	file, err := c.FormFile("image")
+	if err != nil {
+		return "", nil
 	}
 
-	err = h.productUsecase.UpdateProduct(&product, userID)
+	fileContent, err := file.Open()
 	if err != nil {
-		if err == productUsecase.ErrNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return "", err
 	}
+	defer fileContent.Close()
 
-	return c.Status(fiber.StatusOK).JSON(product)
+	imageURL, err := utils.UploadImageToCloudinary(c.UserContext(), fileContent)
+	if err != nil {
+		return "", errImageUpload.Wrap(err)
+	}
+	return imageURL, nil
 }
 
 func (h *ProductHandler) Delete(c *fiber.Ctx) error {
-	idstr := c.Params("id")
-	id, err := uuid.Parse(idstr)
+	id, err := binder.UUIDParam(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid product ID format"})
-	}
-
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	err = h.productUsecase.DeleteProduct(id, userID)
-	if err == productUsecase.ErrNotFound {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.productUsecase.DeleteProduct(c.UserContext(), auditHandler.Actor(c), id, userID); err != nil {
+		return err
 	}
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
 func (h *ProductHandler) GetAllProduct(c *fiber.Ctx) error {
-	products, err := h.productUsecase.GetAllproducts()
+	products, err := h.productUsecase.GetAllproducts(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(fiber.Map{"data": fiber.Map{"products": products}})
+	return c.JSON(fiber.Map{"data": fiber.Map{"products": toProductResponses(products)}})
+}
+
+// Restore undoes a soft delete. Only admins reach it, so it is not limited
+// to the caller's own products.
+func (h *ProductHandler) Restore(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.productUsecase.RestoreProduct(c.UserContext(), auditHandler.Actor(c), id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
 }
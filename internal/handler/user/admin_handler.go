@@ -0,0 +1,117 @@
+package userHandler
+
+import (
+	auditHandler "fiber-crud/internal/handler/audit"
+	"fiber-crud/internal/handler/binder"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListUsers serves GET /admin/users with page, limit, q, role and status
+// (active, suspended or deleted) query parameters.
+func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
+	var query listUsersQuery
+	if err := binder.Query(c, &query); err != nil {
+		return err
+	}
+	filter := query.toFilter()
+
+	users, total, err := h.userUsecase.ListUsers(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  toUserResponses(users),
+		"page":  max(filter.Page, 1),
+		"total": total,
+	})
+}
+
+func (h *UserHandler) SuspendUser(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request suspendUserRequest
+	if len(c.Body()) > 0 {
+		if err := binder.Body(c, &request); err != nil {
+			return err
+		}
+	}
+
+	if err := h.userUsecase.SuspendUser(c.UserContext(), auditHandler.Actor(c), id, request.Reason); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) ReactivateUser(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.userUsecase.ReactivateUser(c.UserContext(), auditHandler.Actor(c), id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) ForcePasswordReset(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.userUsecase.ForcePasswordReset(c.UserContext(), auditHandler.Actor(c), id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) ChangeRole(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request changeRoleRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
+	}
+
+	if err := h.userUsecase.ChangeRole(c.UserContext(), auditHandler.Actor(c), id, request.Role); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) RestoreUser(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.userUsecase.RestoreUser(c.UserContext(), auditHandler.Actor(c), id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ImpersonateUser returns a short-lived access token for the target user.
+// The token names the calling admin in its "act" claim.
+func (h *UserHandler) ImpersonateUser(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	impersonation, err := h.userUsecase.Impersonate(c.UserContext(), auditHandler.Actor(c), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(impersonation)
+}
@@ -0,0 +1,174 @@
+package userHandler
+
+import (
+	"time"
+
+	userModels "fiber-crud/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+// Request bodies bind only the fields a caller may set. Role, verification,
+// 2FA and suspension state are changed through their own endpoints.
+
+type createUserRequest struct {
+	Name     string `json:"name" validate:"required,max=50"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Avatar   string `json:"avatar" validate:"omitempty,url"`
+}
+
+func (r createUserRequest) toModel() userModels.User {
+	return userModels.User{
+		Name:     r.Name,
+		Email:    r.Email,
+		Password: r.Password,
+		Avatar:   r.Avatar,
+	}
+}
+
+// updateUserRequest replaces the profile. An empty password keeps the
+// current one.
+type updateUserRequest struct {
+	Name     string `json:"name" validate:"required,max=50"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"omitempty,min=8,max=72"`
+	Avatar   string `json:"avatar" validate:"omitempty,url"`
+}
+
+func (r updateUserRequest) toModel(id uuid.UUID) userModels.User {
+	return userModels.User{
+		ID:       id,
+		Name:     r.Name,
+		Email:    r.Email,
+		Password: r.Password,
+		Avatar:   r.Avatar,
+	}
+}
+
+// updateProfileRequest is the self-service edit; empty fields keep their
+// current value.
+type updateProfileRequest struct {
+	Name   string `json:"name" validate:"max=50"`
+	Email  string `json:"email" validate:"omitempty,email,max=254"`
+	Avatar string `json:"avatar" validate:"omitempty,url"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type passwordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type verifyMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type suspendUserRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+type changeRoleRequest struct {
+	Role string `json:"role" validate:"required,role"`
+}
+
+type searchUsersQuery struct {
+	Query string `query:"q" validate:"required,max=100"`
+}
+
+// listUsersQuery holds the GET /admin/users query parameters.
+type listUsersQuery struct {
+	Query  string `query:"q" validate:"max=100"`
+	Role   string `query:"role" validate:"omitempty,role"`
+	Status string `query:"status" validate:"omitempty,oneof=active suspended deleted"`
+	Page   int    `query:"page" validate:"min=0"`
+	Limit  int    `query:"limit" validate:"min=0,max=100"`
+}
+
+func (q listUsersQuery) toFilter() userModels.ListFilter {
+	return userModels.ListFilter{
+		Query:  q.Query,
+		Role:   q.Role,
+		Status: q.Status,
+		Page:   q.Page,
+		Limit:  q.Limit,
+	}
+}
+
+type userResponse struct {
+	ID                    uuid.UUID  `json:"id"`
+	Name                  string     `json:"name"`
+	Email                 string     `json:"email"`
+	EmailVerifiedAt       *time.Time `json:"email_verified_at"`
+	Avatar                string     `json:"avatar"`
+	Role                  string     `json:"role"`
+	TwoFactorEnabled      bool       `json:"two_factor_enabled"`
+	SuspendedAt           *time.Time `json:"suspended_at"`
+	SuspendedReason       string     `json:"suspended_reason,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	CreatedAt             time.Time  `json:"created_at"`
+	DeletedAt             *time.Time `json:"deleted_at,omitempty"`
+}
+
+func toUserResponse(user userModels.User) userResponse {
+	response := userResponse{
+		ID:                    user.ID,
+		Name:                  user.Name,
+		Email:                 user.Email,
+		EmailVerifiedAt:       user.EmailVerifiedAt,
+		Avatar:                user.Avatar,
+		Role:                  user.Role,
+		TwoFactorEnabled:      user.MFAEnabled(),
+		SuspendedAt:           user.SuspendedAt,
+		SuspendedReason:       user.SuspendedReason,
+		PasswordResetRequired: user.PasswordResetRequired,
+		CreatedAt:             user.CreatedAt,
+	}
+	if user.DeletedAt.Valid {
+		response.DeletedAt = &user.DeletedAt.Time
+	}
+	return response
+}
+
+func toUserResponses(users []userModels.User) []userResponse {
+	response := make([]userResponse, len(users))
+	for i, user := range users {
+		response[i] = toUserResponse(user)
+	}
+	return response
+}
+
+type identityResponse struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toIdentityResponses(identities []userModels.Identity) []identityResponse {
+	response := make([]identityResponse, len(identities))
+	for i, identity := range identities {
+		response[i] = identityResponse{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		}
+	}
+	return response
+}
@@ -0,0 +1,45 @@
+package userHandler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	userModels "fiber-crud/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+func TestUserResponseLeavesOutSecrets(t *testing.T) {
+	user := userModels.User{
+		ID:           uuid.New(),
+		Name:         "alice",
+		Email:        "alice@example.com",
+		Password:     "$2a$10$hash",
+		TOTPSecret:   "totp-secret",
+		TOTPLastStep: 42,
+	}
+
+	body, err := json.Marshal(toUserResponse(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{`"password"`, user.Password, user.TOTPSecret, "totp_last_step"} {
+		if strings.Contains(string(body), secret) {
+			t.Fatalf("user response %s contains %q", body, secret)
+		}
+	}
+}
+
+func TestCreateUserRequestIgnoresPrivilegedFields(t *testing.T) {
+	var request createUserRequest
+	body := `{"name":"mallory","email":"m@example.com","password":"secret-pass","role":"admin","email_verified_at":"2026-01-01T00:00:00Z"}`
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		t.Fatal(err)
+	}
+
+	user := request.toModel()
+	if user.Role != "" || user.EmailVerifiedAt != nil {
+		t.Fatalf("request bound role %q and verification %v, want neither", user.Role, user.EmailVerifiedAt)
+	}
+}
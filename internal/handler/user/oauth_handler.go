@@ -0,0 +1,218 @@
+package userHandler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/url"
+
+	"fiber-crud/internal/domain/apperror"
+	userModels "fiber-crud/internal/domain/user"
+	"fiber-crud/internal/handler/binder"
+	"fiber-crud/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+const oauthStateCookie = "oauth_state"
+
+var (
+	errUnknownProvider     = apperror.NotFound("unknown_provider", "unknown OAuth provider")
+	errRedirectNotAllowed  = apperror.Invalid("redirect_not_allowed", "redirect URI is not allowed")
+	errInvalidOAuthState   = apperror.Invalid("invalid_oauth_state", "invalid OAuth state")
+	errOAuthAccessDenied   = apperror.Unauthorized("access_denied", "the provider denied access")
+	errOAuthProvider       = apperror.Upstream("provider_error", "the OAuth provider request failed")
+	errOAuthInvalidRequest = apperror.Invalid("invalid_request", "invalid OAuth request")
+	errOAuthServer         = apperror.New(apperror.KindInternal, "server_error", "failed to complete OAuth sign-in")
+)
+
+// OAuthLogin starts the authorization code flow for the provider in the path.
+// A random state and PKCE verifier are kept in a signed cookie until the
+// callback.
+func (h *UserHandler) OAuthLogin(c *fiber.Ctx) error {
+	provider, ok := utils.GetOAuthProvider(c.Params("provider"))
+	if !ok {
+		return errUnknownProvider
+	}
+
+	redirect, ok := utils.ResolveOAuthRedirect(c.Query("redirect_uri"))
+	if !ok {
+		return errRedirectNotAllowed
+	}
+
+	authURL, err := startOAuth(c, provider, redirect, "")
+	if err != nil {
+		return err
+	}
+	return c.Redirect(authURL)
+}
+
+// OAuthLink starts a flow that links the provider account to the
+// authenticated user. The authorization URL is returned as JSON because the
+// request carries a bearer token and cannot be a plain browser navigation;
+// the frontend must send it with credentials so the state cookie is kept.
+func (h *UserHandler) OAuthLink(c *fiber.Ctx) error {
+	provider, ok := utils.GetOAuthProvider(c.Params("provider"))
+	if !ok {
+		return errUnknownProvider
+	}
+
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	redirect, ok := utils.ResolveOAuthRedirect(c.Query("redirect_uri"))
+	if !ok {
+		return errRedirectNotAllowed
+	}
+
+	authURL, err := startOAuth(c, provider, redirect, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"url": authURL})
+}
+
+func startOAuth(c *fiber.Ctx, provider utils.OAuthProvider, redirect, linkUser string) (string, error) {
+	verifier := oauth2.GenerateVerifier()
+	state, err := utils.NewOAuthState(verifier, redirect)
+	if err != nil {
+		return "", err
+	}
+	state.LinkUser = linkUser
+
+	cookie, err := state.Encode()
+	if err != nil {
+		return "", err
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthStateCookie,
+		Value:    cookie,
+		Path:     utils.OAuthPath(provider.Name()),
+		MaxAge:   int(utils.OAuthStateTTL.Seconds()),
+		Secure:   utils.OAuthCookieSecure(),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return provider.AuthCodeURL(state.State, verifier), nil
+}
+
+func (h *UserHandler) OAuthCallback(c *fiber.Ctx) error {
+	provider, ok := utils.GetOAuthProvider(c.Params("provider"))
+	if !ok {
+		return errUnknownProvider
+	}
+
+	state, err := utils.DecodeOAuthState(c.Cookies(oauthStateCookie))
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthStateCookie,
+		Path:     utils.OAuthPath(provider.Name()),
+		MaxAge:   -1,
+		HTTPOnly: true,
+	})
+	if err != nil || subtle.ConstantTimeCompare([]byte(state.State), []byte(c.Query("state"))) != 1 {
+		return errInvalidOAuthState
+	}
+
+	if c.Query("error") != "" {
+		return oauthFailure(c, state.Redirect, errOAuthAccessDenied)
+	}
+
+	identity, err := provider.Exchange(c.UserContext(), c.Query("code"), state.Verifier)
+	if err != nil {
+		return oauthFailure(c, state.Redirect, errOAuthProvider.Wrap(err))
+	}
+
+	if state.LinkUser != "" {
+		return h.finishLink(c, state, *identity)
+	}
+
+	user, err := h.userUsecase.LoginWithIdentity(c.UserContext(), *identity)
+	if err != nil {
+		return oauthFailure(c, state.Redirect, err)
+	}
+	if user.Suspended() {
+		return oauthFailure(c, state.Redirect, userModels.ErrSuspended)
+	}
+
+	code, err := h.authUsecase.CreateExchangeCode(c.UserContext(), user.ID)
+	if err != nil {
+		return oauthFailure(c, state.Redirect, err)
+	}
+
+	if state.Redirect == "" {
+		return c.JSON(fiber.Map{"code": code})
+	}
+	return c.Redirect(withQuery(state.Redirect, "code", code))
+}
+
+func (h *UserHandler) finishLink(c *fiber.Ctx, state *utils.OAuthState, identity utils.ExternalIdentity) error {
+	userID, err := uuid.Parse(state.LinkUser)
+	if err != nil {
+		return oauthFailure(c, state.Redirect, errOAuthInvalidRequest)
+	}
+
+	if err := h.userUsecase.LinkIdentity(c.UserContext(), userID, identity); err != nil {
+		return oauthFailure(c, state.Redirect, err)
+	}
+
+	if state.Redirect == "" {
+		return c.JSON(fiber.Map{"linked": identity.Provider})
+	}
+	return c.Redirect(withQuery(state.Redirect, "linked", identity.Provider))
+}
+
+func (h *UserHandler) GetIdentities(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	identities, err := h.userUsecase.GetIdentities(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(toIdentityResponses(identities))
+}
+
+func (h *UserHandler) UnlinkIdentity(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.userUsecase.UnlinkIdentity(c.UserContext(), userID, c.Params("provider")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// oauthFailure reports a callback error to the frontend when one is known,
+// passing the error code as the reason. Without a frontend the error goes to
+// the error handler like any other. Untyped errors are reported as
+// server_error.
+func oauthFailure(c *fiber.Ctx, redirect string, err error) error {
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) {
+		appErr = errOAuthServer.Wrap(err)
+	}
+	if redirect == "" {
+		return appErr
+	}
+	return c.Redirect(withQuery(redirect, "error", appErr.Code))
+}
+
+func withQuery(rawURL, key, value string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,234 @@
+package userHandler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	authHandler "fiber-crud/internal/handler/auth"
+	userHandler "fiber-crud/internal/handler/user"
+	memoryRepository "fiber-crud/internal/repository/memory"
+	"fiber-crud/internal/router"
+	auditUsecase "fiber-crud/internal/usecase/audit"
+	authUsecase "fiber-crud/internal/usecase/auth"
+	Userusecase "fiber-crud/internal/usecase/user"
+	"fiber-crud/middleware"
+	"fiber-crud/package/config"
+	"fiber-crud/package/oidcmock"
+	"fiber-crud/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	testFrontend    = "http://frontend.test/oauth"
+	testCallbackURL = "http://api.test"
+)
+
+type oauthFixture struct {
+	app      *fiber.App
+	provider *oidcmock.Provider
+	users    *memoryRepository.UserRepository
+}
+
+// newOAuthFixture wires the real routes, handlers and usecases over
+// in-memory repositories, with a mock OIDC issuer registered as "mock".
+func newOAuthFixture(t *testing.T, user oidcmock.User) *oauthFixture {
+	t.Helper()
+
+	provider, srv := oidcmock.NewServer("client", "client-secret", user)
+	t.Cleanup(srv.Close)
+
+	if err := utils.InitKeyRing(config.JWTConfig{KID: "test", Secret: strings.Repeat("k", 32), Audience: "fiber-crud"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.InitOAuth2(testOAuthConfig(provider.DiscoveryURL(), "mock")); err != nil {
+		t.Fatal(err)
+	}
+
+	users := memoryRepository.NewUserRepository()
+	auth := memoryRepository.NewAuthRepository()
+	authUC := authUsecase.NewAuthUsecase(auth, users)
+	userUC := Userusecase.NewUserUsecase(users, auth, authUC, nil, auditUsecase.NewAuditUsecase(memoryRepository.NewAuditRepository()), config.Default().Account)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(false)})
+	router.SetupUserRoutes(app, userHandler.NewUserHandler(userUC, authUC))
+	router.SetupAuthRoutes(app, authHandler.NewAuthHandler(authUC))
+
+	return &oauthFixture{app: app, provider: provider, users: users}
+}
+
+func testOAuthConfig(discoveryURL, name string) config.OAuthConfig {
+	return config.OAuthConfig{
+		StateSecret:      strings.Repeat("s", 32),
+		AllowedRedirects: []string{testFrontend},
+		CallbackBaseURL:  testCallbackURL,
+		OIDC: []config.OIDCProviderConfig{{
+			Name:              name,
+			DiscoveryURL:      discoveryURL,
+			OAuthClientConfig: config.OAuthClientConfig{ClientID: "client", ClientSecret: "client-secret"},
+		}},
+	}
+}
+
+func (f *oauthFixture) do(t *testing.T, req *http.Request) *http.Response {
+	t.Helper()
+	resp, err := f.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// signIn runs /auth/oauth/mock and the provider's authorize step, then
+// delivers the provider's redirect to the callback and returns the frontend
+// URL the callback redirects to.
+func (f *oauthFixture) signIn(t *testing.T) *url.URL {
+	t.Helper()
+
+	login := f.do(t, httptest.NewRequest(http.MethodGet, "/auth/oauth/mock?redirect_uri="+url.QueryEscape(testFrontend), nil))
+	if login.StatusCode != http.StatusFound {
+		t.Fatalf("GET /auth/oauth/mock = %d, want 302", login.StatusCode)
+	}
+	var stateCookie *http.Cookie
+	for _, cookie := range login.Cookies() {
+		if cookie.Name == "oauth_state" {
+			stateCookie = cookie
+		}
+	}
+	if stateCookie == nil {
+		t.Fatal("GET /auth/oauth/mock set no oauth_state cookie")
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	authorize, err := client.Get(login.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorize.Body.Close()
+	callback, err := url.Parse(authorize.Header.Get("Location"))
+	if err != nil || !strings.HasPrefix(callback.String(), testCallbackURL+"/auth/oauth/mock/callback") {
+		t.Fatalf("provider redirected to %q, want the configured callback", authorize.Header.Get("Location"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	req.AddCookie(stateCookie)
+	resp := f.do(t, req)
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("callback = %d, want 302", resp.StatusCode)
+	}
+	frontend, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || !strings.HasPrefix(frontend.String(), testFrontend) {
+		t.Fatalf("callback redirected to %q, want the frontend", resp.Header.Get("Location"))
+	}
+	return frontend
+}
+
+func (f *oauthFixture) exchange(t *testing.T, code string) (int, authUsecase.TokenPair) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/exchange", strings.NewReader(`{"code":"`+code+`"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp := f.do(t, req)
+	defer resp.Body.Close()
+
+	var pair authUsecase.TokenPair
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&pair); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode, pair
+}
+
+func TestOAuthSignInThroughExchange(t *testing.T) {
+	f := newOAuthFixture(t, oidcmock.User{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true, Name: "alice"})
+
+	code := f.signIn(t).Query().Get("code")
+	if code == "" {
+		t.Fatal("callback did not hand the frontend an exchange code")
+	}
+
+	status, pair := f.exchange(t, code)
+	if status != http.StatusOK || pair.AccessToken == "" || pair.RefreshToken == "" {
+		t.Fatalf("POST /auth/exchange = %d %+v, want a token pair", status, pair)
+	}
+	claims, err := utils.ParseTokenString(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("access token does not verify: %v", err)
+	}
+	user := f.users.Users[mustUUID(t, claims.Subject)]
+	if user.Email != "alice@example.com" || !user.EmailVerified() {
+		t.Fatalf("signed up %+v, want a verified account for alice@example.com", user)
+	}
+	if len(f.users.Identities) != 1 || f.users.Identities[0].Subject != "alice-sub" {
+		t.Fatalf("identities = %+v, want the mock identity linked", f.users.Identities)
+	}
+
+	if status, _ := f.exchange(t, code); status == http.StatusOK {
+		t.Fatal("exchange code was accepted twice")
+	}
+
+	// A second sign-in finds the account through the linked identity.
+	status, _ = f.exchange(t, f.signIn(t).Query().Get("code"))
+	if status != http.StatusOK {
+		t.Fatalf("second sign-in exchange = %d", status)
+	}
+	if len(f.users.Users) != 1 {
+		t.Fatalf("second sign-in created another account: %d users", len(f.users.Users))
+	}
+}
+
+func TestOAuthSignInWithoutEmail(t *testing.T) {
+	f := newOAuthFixture(t, oidcmock.User{Subject: "first", Name: "first"})
+	if got := f.signIn(t).Query().Get("error"); got != "identity_email_required" {
+		t.Fatalf("first sign-in error = %q, want identity_email_required", got)
+	}
+
+	f.provider.SetUser(oidcmock.User{Subject: "second", Name: "second"})
+	if got := f.signIn(t).Query().Get("error"); got != "identity_email_required" {
+		t.Fatalf("second sign-in error = %q, want identity_email_required", got)
+	}
+	if len(f.users.Users) != 0 {
+		t.Fatalf("accounts created without an email: %+v", f.users.Users)
+	}
+}
+
+func TestOAuthCallbackRejectsForgedState(t *testing.T) {
+	f := newOAuthFixture(t, oidcmock.User{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true})
+
+	resp := f.do(t, httptest.NewRequest(http.MethodGet, "/auth/oauth/mock/callback?code=x&state=forged", nil))
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("callback without a state cookie = %d, want 400", resp.StatusCode)
+	}
+}
+
+// Providers live under their own prefix, so neither an unknown provider nor
+// a provider named like another /auth route can shadow that route.
+func TestOAuthRoutesDoNotShadowAuthRoutes(t *testing.T) {
+	f := newOAuthFixture(t, oidcmock.User{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true})
+
+	if resp := f.do(t, httptest.NewRequest(http.MethodGet, "/auth/oauth/unknown", nil)); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("GET /auth/oauth/unknown = %d, want 404", resp.StatusCode)
+	}
+	if resp := f.do(t, httptest.NewRequest(http.MethodGet, "/auth/me", nil)); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GET /auth/me = %d, want 401 from the auth middleware", resp.StatusCode)
+	}
+
+	if err := utils.InitOAuth2(testOAuthConfig(f.provider.DiscoveryURL(), "../me")); err == nil {
+		t.Fatal("provider name that is not a single path segment was accepted")
+	}
+}
+
+func mustUUID(t *testing.T, s string) uuid.UUID {
+	t.Helper()
+	id, err := uuid.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
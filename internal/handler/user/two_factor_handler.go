@@ -0,0 +1,109 @@
+package userHandler
+
+import (
+	auditHandler "fiber-crud/internal/handler/audit"
+	authHandler "fiber-crud/internal/handler/auth"
+	"fiber-crud/internal/handler/binder"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *UserHandler) EnrollTOTP(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	enrollment, err := h.userUsecase.EnrollTOTP(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(enrollment)
+}
+
+func (h *UserHandler) ConfirmTOTP(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	var request mfaCodeRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
+	}
+
+	codes, err := h.userUsecase.ConfirmTOTP(c.UserContext(), userID, request.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
+
+func (h *UserHandler) DisableTOTP(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	var request mfaCodeRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
+	}
+
+	if err := h.userUsecase.DisableTOTP(c.UserContext(), userID, request.Code); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	var request mfaCodeRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
+	}
+
+	codes, err := h.userUsecase.RegenerateRecoveryCodes(c.UserContext(), userID, request.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
+
+// VerifyMFA is the second step of a login for accounts with two-factor
+// authentication. It accepts a TOTP code or a recovery code.
+func (h *UserHandler) VerifyMFA(c *fiber.Ctx) error {
+	var request verifyMFARequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
+	}
+
+	tokens, err := h.userUsecase.VerifyMFA(c.UserContext(), request.MFAToken, request.Code, authHandler.ClientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tokens)
+}
+
+// ResetTOTP lets an admin remove a user's second factor.
+func (h *UserHandler) ResetTOTP(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.userUsecase.ResetTOTP(c.UserContext(), auditHandler.Actor(c), id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
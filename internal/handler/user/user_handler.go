@@ -1,212 +1,219 @@
 package userHandler
 
 import (
-	"context"
-	"encoding/json"
-	"net/http"
-
-	userModels "fiber-crud/internal/domain/user"
+	auditHandler "fiber-crud/internal/handler/audit"
+	authHandler "fiber-crud/internal/handler/auth"
+	"fiber-crud/internal/handler/binder"
+	authUsecase "fiber-crud/internal/usecase/auth"
 	Userusecase "fiber-crud/internal/usecase/user"
-	"fiber-crud/utils"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
-	"golang.org/x/oauth2"
-	"gorm.io/gorm"
 )
 
 type UserHandler struct {
 	userUsecase Userusecase.UserUsecase
+	authUsecase authUsecase.AuthUsecase
 }
 
 // NewUserHandler creates a new UserHandler instance
-func NewUserHandler(usecase Userusecase.UserUsecase) *UserHandler {
-	return &UserHandler{userUsecase: usecase}
+func NewUserHandler(usecase Userusecase.UserUsecase, authUsecase authUsecase.AuthUsecase) *UserHandler {
+	return &UserHandler{userUsecase: usecase, authUsecase: authUsecase}
 }
 
 // GetUsers handles requests to get all users
 func (h *UserHandler) GetUsers(c *fiber.Ctx) error {
-	users, err := h.userUsecase.GetUsers()
+	users, err := h.userUsecase.GetUsers(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
-	return c.JSON(users)
+	return c.JSON(toUserResponses(users))
 }
 
 func (h *UserHandler) CurrentUser(c *fiber.Ctx) error {
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
-	}
-
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+		return err
 	}
 
-	user, err := h.userUsecase.GetCurrentUser(userID)
+	user, err := h.userUsecase.GetCurrentUser(c.UserContext(), userID)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve user"})
+		return err
 	}
 
-	return c.JSON(user)
+	return c.JSON(toUserResponse(user))
 }
 
 func (h *UserHandler) GetUserByID(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := binder.UUIDParam(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid UUID format"})
+		return err
 	}
 
-	user, err := h.userUsecase.GetUserByID(id)
-	if err == Userusecase.ErrNotFound {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	user, err := h.userUsecase.GetUserByID(c.UserContext(), id)
+	if err != nil {
+		return err
 	}
-	return c.JSON(user)
+	return c.JSON(toUserResponse(user))
 }
 
 func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
-	var user userModels.User
-	if err := c.BodyParser(&user); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	var request createUserRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
 	}
 
-	res, err := h.userUsecase.CreateUser(user)
-	if err == Userusecase.ErrUsernameTaken || err == Userusecase.ErrEmailTaken {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	res, err := h.userUsecase.CreateUser(c.UserContext(), auditHandler.Actor(c), request.toModel())
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(res)
+	return c.Status(fiber.StatusCreated).JSON(toUserResponse(*res))
 }
 
 func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := binder.UUIDParam(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid UUID format"})
+		return err
 	}
 
-	var user userModels.User
-	if err := c.BodyParser(&user); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	var request updateUserRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
 	}
-	user.ID = id
 
-	err = h.userUsecase.UpdateUser(user)
-	if err == Userusecase.ErrNotFound {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
-	} else if err == Userusecase.ErrUsernameTaken || err == Userusecase.ErrEmailTaken {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	user, err := h.userUsecase.UpdateUser(c.UserContext(), auditHandler.Actor(c), request.toModel(id))
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(user)
+	return c.JSON(toUserResponse(*user))
+}
+
+// UpdateCurrentUser lets users edit their own name, email and avatar.
+func (h *UserHandler) UpdateCurrentUser(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	var profile updateProfileRequest
+	if err := binder.Body(c, &profile); err != nil {
+		return err
+	}
+
+	user, err := h.userUsecase.UpdateProfile(c.UserContext(), auditHandler.Actor(c), userID, profile.Name, profile.Email, profile.Avatar)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toUserResponse(user))
 }
 
 func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := binder.UUIDParam(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid UUID format"})
+		return err
 	}
 
-	err = h.userUsecase.DeleteUser(id)
-	if err == Userusecase.ErrNotFound {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
-	} else if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.userUsecase.DeleteUser(c.UserContext(), auditHandler.Actor(c), id); err != nil {
+		return err
 	}
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
 func (h *UserHandler) SearchUsers(c *fiber.Ctx) error {
-	query := c.Query("q")
-	if query == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Search query cannot be empty"})
+	var query searchUsersQuery
+	if err := binder.Query(c, &query); err != nil {
+		return err
 	}
 
-	users, err := h.userUsecase.SearchUsers(query)
+	users, err := h.userUsecase.SearchUsers(c.UserContext(), query.Query)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
-	return c.JSON(users)
+	return c.JSON(toUserResponses(users))
 }
 
+// Login answers 423 with Retry-After while the account is locked and 429
+// while the client address is throttled.
 func (h *UserHandler) Login(c *fiber.Ctx) error {
-	var credentials struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+	var credentials loginRequest
+	if err := binder.Body(c, &credentials); err != nil {
+		return err
 	}
 
-	if err := c.BodyParser(&credentials); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	result, err := h.userUsecase.Login(c.UserContext(), credentials.Email, credentials.Password, authHandler.ClientInfo(c))
+	if err != nil {
+		return err
 	}
 
-	token, err := h.userUsecase.Login(credentials.Email, credentials.Password)
+	return c.JSON(result)
+}
+
+// UnlockAccount lets an admin clear a lockout caused by failed logins.
+func (h *UserHandler) UnlockAccount(c *fiber.Ctx) error {
+	id, err := binder.UUIDParam(c, "id")
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(fiber.Map{"token": token})
-}
+	if err := h.userUsecase.UnlockAccount(c.UserContext(), auditHandler.Actor(c), id); err != nil {
+		return err
+	}
 
-func (h *UserHandler) GoogleLogin(c *fiber.Ctx) error {
-	url := utils.GoogleOauthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	return c.Redirect(url)
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
-func (h *UserHandler) GoogleCallback(c *fiber.Ctx) error {
-	code := c.Query("code")
+func (h *UserHandler) RequestPasswordReset(c *fiber.Ctx) error {
+	var request passwordResetRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
+	}
 
-	token, err := utils.GoogleOauthConfig.Exchange(context.Background(), code)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.userUsecase.RequestPasswordReset(c.UserContext(), request.Email); err != nil {
+		return err
 	}
 
-	client := utils.GoogleOauthConfig.Client(context.Background(), token)
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v3/userinfo")
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "If the email is registered, a reset link has been sent",
+	})
+}
+
+func (h *UserHandler) ResetPassword(c *fiber.Ctx) error {
+	var request resetPasswordRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get user info"})
+	if err := h.userUsecase.ResetPassword(c.UserContext(), request.Token, request.Password); err != nil {
+		return err
 	}
 
-	var googleUser struct {
-		GoogleID string `json:"sub"`
-		Email    string `json:"email"`
-		Name     string `json:"name"`
-		Avatar   string `json:"picture"`
+	return c.JSON(fiber.Map{"message": "Password has been reset"})
+}
+
+func (h *UserHandler) VerifyEmail(c *fiber.Ctx) error {
+	var request verifyEmailRequest
+	if err := binder.Body(c, &request); err != nil {
+		return err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&googleUser); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+
+	if err := h.userUsecase.VerifyEmail(c.UserContext(), request.Token); err != nil {
+		return err
 	}
 
-	user, err := h.userUsecase.LoginOrSignup(
-		googleUser.GoogleID,
-		googleUser.Email,
-		googleUser.Name,
-		googleUser.Avatar,
-	)
+	return c.JSON(fiber.Map{"message": "Email verified"})
+}
+
+func (h *UserHandler) ResendVerificationEmail(c *fiber.Ctx) error {
+	userID, err := binder.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	tokenString, err := utils.GenerateJWT(user.ID.String())
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.userUsecase.SendVerificationEmail(c.UserContext(), userID); err != nil {
+		return err
 	}
 
-	return c.JSON(fiber.Map{"token": tokenString, "user": user})
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "Verification email sent"})
 }
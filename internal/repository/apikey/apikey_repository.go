@@ -0,0 +1,67 @@
+package apiKeyRepository
+
+import (
+	"context"
+	apiKeyModels "fiber-crud/internal/domain/apikey"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *apiKeyModels.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*apiKeyModels.APIKey, error)
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]apiKeyModels.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) (bool, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID, before time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *apiKeyModels.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*apiKeyModels.APIKey, error) {
+	var key apiKeyModels.APIKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]apiKeyModels.APIKey, error) {
+	var keys []apiKeyModels.APIKey
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&apiKeyModels.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// TouchLastUsed sets last_used_at unless it was already updated after
+// before, which keeps busy keys from writing on every request.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, before time.Time) error {
+	return r.db.WithContext(ctx).Model(&apiKeyModels.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, before).
+		Update("last_used_at", time.Now()).Error
+}
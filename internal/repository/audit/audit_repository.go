@@ -0,0 +1,94 @@
+package auditRepository
+
+import (
+	"context"
+	auditModels "fiber-crud/internal/domain/audit"
+
+	"gorm.io/gorm"
+)
+
+const exportBatchSize = 500
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *auditModels.AuditLog) error
+	Find(ctx context.Context, filter auditModels.Filter) ([]auditModels.AuditLog, int64, error)
+	Export(ctx context.Context, filter auditModels.Filter, fn func(entry auditModels.AuditLog) error) error
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry *auditModels.AuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *auditRepository) filtered(ctx context.Context, filter auditModels.Filter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&auditModels.AuditLog{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+// Find returns one page of entries, newest first, and the total number of
+// matching entries.
+func (r *auditRepository) Find(ctx context.Context, filter auditModels.Filter) ([]auditModels.AuditLog, int64, error) {
+	var total int64
+	if err := r.filtered(ctx, filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []auditModels.AuditLog
+	err := r.filtered(ctx, filter).
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// Export streams every matching entry, oldest first, a page at a time so the
+// whole table is never held in memory.
+func (r *auditRepository) Export(ctx context.Context, filter auditModels.Filter, fn func(entry auditModels.AuditLog) error) error {
+	for offset := 0; ; offset += exportBatchSize {
+		var batch []auditModels.AuditLog
+		err := r.filtered(ctx, filter).
+			Order("created_at ASC, id ASC").
+			Limit(exportBatchSize).
+			Offset(offset).
+			Find(&batch).Error
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range batch {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package authRepository
+
+import (
+	"context"
+	authModels "fiber-crud/internal/domain/auth"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AuthRepository interface {
+	CreateRefreshToken(ctx context.Context, token *authModels.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*authModels.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, id uuid.UUID, replacedBy uuid.UUID) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RedeemTokenID(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+	CreatePasswordResetToken(ctx context.Context, token *authModels.PasswordResetToken) error
+	GetPasswordResetTokenByHash(ctx context.Context, hash string) (*authModels.PasswordResetToken, error)
+	ConsumePasswordResetToken(ctx context.Context, id uuid.UUID) (bool, error)
+	InvalidatePasswordResetTokens(ctx context.Context, userID uuid.UUID) error
+	CreateEmailVerificationToken(ctx context.Context, token *authModels.EmailVerificationToken) error
+	GetEmailVerificationTokenByHash(ctx context.Context, hash string) (*authModels.EmailVerificationToken, error)
+	ConsumeEmailVerificationToken(ctx context.Context, id uuid.UUID) (bool, error)
+	InvalidateEmailVerificationTokens(ctx context.Context, userID uuid.UUID) error
+	CreateExchangeCode(ctx context.Context, code *authModels.ExchangeCode) error
+	GetExchangeCodeByHash(ctx context.Context, hash string) (*authModels.ExchangeCode, error)
+	ConsumeExchangeCode(ctx context.Context, id uuid.UUID) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) (bool, error)
+	DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+	GetThrottle(ctx context.Context, key string) (*authModels.LoginThrottle, error)
+	RecordFailure(ctx context.Context, key string, windowStart time.Time) (int, error)
+	LockThrottle(ctx context.Context, key string, until time.Time) error
+	ClearThrottle(ctx context.Context, key string) error
+	CreateSession(ctx context.Context, session *authModels.Session) error
+	GetSession(ctx context.Context, id uuid.UUID) (*authModels.Session, error)
+	GetActiveSessions(ctx context.Context, userID uuid.UUID) ([]authModels.Session, error)
+	TouchSession(ctx context.Context, id uuid.UUID, ip string, expiresAt *time.Time) error
+	RevokeSession(ctx context.Context, id uuid.UUID) error
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) error
+}
+
+type authRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthRepository(db *gorm.DB) AuthRepository {
+	return &authRepository{db: db}
+}
+
+func (r *authRepository) CreateRefreshToken(ctx context.Context, token *authModels.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *authRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*authModels.RefreshToken, error) {
+	var token authModels.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken marks the token as used and links it to its successor.
+// It reports false when the token had already been revoked, which happens when
+// two requests race to rotate the same token.
+func (r *authRepository) RotateRefreshToken(ctx context.Context, id uuid.UUID, replacedBy uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&authModels.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":  time.Now(),
+			"replaced_by": replacedBy,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *authRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&authModels.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *authRepository) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&authModels.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *authRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&authModels.RevokedToken{
+		JTI:       jti,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+func (r *authRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&authModels.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RedeemTokenID records the jti of a single-use token. It reports false when
+// the jti was already recorded, so the token can only ever succeed once.
+func (r *authRepository) RedeemTokenID(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&authModels.RevokedToken{
+		JTI:       jti,
+		ExpiresAt: expiresAt,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteExpired drops revocation entries, one-time tokens and sessions that
+// can no longer be presented because they expired before the given time.
+func (r *authRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&authModels.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&authModels.PasswordResetToken{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&authModels.EmailVerificationToken{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&authModels.ExchangeCode{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&authModels.Session{}).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&authModels.RefreshToken{}).Error
+}
+
+func (r *authRepository) CreatePasswordResetToken(ctx context.Context, token *authModels.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *authRepository) GetPasswordResetTokenByHash(ctx context.Context, hash string) (*authModels.PasswordResetToken, error) {
+	var token authModels.PasswordResetToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ConsumePasswordResetToken marks the token as used. It reports false when the
+// token was already used, so a token can only ever succeed once.
+func (r *authRepository) ConsumePasswordResetToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&authModels.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *authRepository) InvalidatePasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&authModels.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+func (r *authRepository) CreateEmailVerificationToken(ctx context.Context, token *authModels.EmailVerificationToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *authRepository) GetEmailVerificationTokenByHash(ctx context.Context, hash string) (*authModels.EmailVerificationToken, error) {
+	var token authModels.EmailVerificationToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *authRepository) ConsumeEmailVerificationToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&authModels.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *authRepository) InvalidateEmailVerificationTokens(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&authModels.EmailVerificationToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+func (r *authRepository) CreateExchangeCode(ctx context.Context, code *authModels.ExchangeCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+func (r *authRepository) GetExchangeCodeByHash(ctx context.Context, hash string) (*authModels.ExchangeCode, error) {
+	var code authModels.ExchangeCode
+	if err := r.db.WithContext(ctx).Where("code_hash = ?", hash).First(&code).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *authRepository) ConsumeExchangeCode(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&authModels.ExchangeCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes drops every existing code of the user and stores the
+// new set in one transaction.
+func (r *authRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&authModels.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]authModels.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = authModels.RecoveryCode{ID: uuid.New(), UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *authRepository) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&authModels.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *authRepository) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&authModels.RecoveryCode{}).Error
+}
+
+func (r *authRepository) GetThrottle(ctx context.Context, key string) (*authModels.LoginThrottle, error) {
+	var throttle authModels.LoginThrottle
+	if err := r.db.WithContext(ctx).Where("key = ?", key).First(&throttle).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// RecordFailure atomically increments the failure counter for key and returns
+// the new count. Failures older than windowStart are forgotten.
+func (r *authRepository) RecordFailure(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	var failures int
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES (?, 1, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = NOW()
+		RETURNING failures`, key, windowStart).Scan(&failures).Error
+	return failures, err
+}
+
+func (r *authRepository) LockThrottle(ctx context.Context, key string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&authModels.LoginThrottle{}).Where("key = ?", key).Update("locked_until", until).Error
+}
+
+func (r *authRepository) ClearThrottle(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&authModels.LoginThrottle{}).Error
+}
+
+func (r *authRepository) CreateSession(ctx context.Context, session *authModels.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *authRepository) GetSession(ctx context.Context, id uuid.UUID) (*authModels.Session, error) {
+	var session authModels.Session
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *authRepository) GetActiveSessions(ctx context.Context, userID uuid.UUID) ([]authModels.Session, error) {
+	var sessions []authModels.Session
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TouchSession records activity on a session. When expiresAt is set the
+// session lifetime is extended as well, which happens on refresh.
+func (r *authRepository) TouchSession(ctx context.Context, id uuid.UUID, ip string, expiresAt *time.Time) error {
+	updates := map[string]interface{}{"last_seen_at": time.Now()}
+	if ip != "" {
+		updates["ip"] = ip
+	}
+	if expiresAt != nil {
+		updates["expires_at"] = *expiresAt
+	}
+	return r.db.WithContext(ctx).Model(&authModels.Session{}).Where("id = ? AND revoked_at IS NULL", id).Updates(updates).Error
+}
+
+func (r *authRepository) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&authModels.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *authRepository) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&authModels.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -1,6 +1,7 @@
 package CartRepository
 
 import (
+	"context"
 	cartModels "fiber-crud/internal/domain/cart"
 
 	"errors"
@@ -12,11 +13,11 @@ import (
 var ErrNotFound = errors.New("cart item not found")
 
 type CartRepository interface {
-	GetCartItemByProductID(userID uuid.UUID, productID uuid.UUID) (cartModels.CartModels, error)
-	AddItemToCart(userID uuid.UUID, productID uuid.UUID, quantity int) error
-	UpdateCartItem(cartItem cartModels.CartModels) error
-	GetAllcartItems(userID uuid.UUID) ([]cartModels.CartModels, error)
-	GetTotalPrice(userID uuid.UUID) (int, error)
+	GetCartItemByProductID(ctx context.Context, userID uuid.UUID, productID uuid.UUID) (cartModels.CartModels, error)
+	AddItemToCart(ctx context.Context, userID uuid.UUID, productID uuid.UUID, quantity int) error
+	UpdateCartItem(ctx context.Context, cartItem cartModels.CartModels) error
+	GetAllcartItems(ctx context.Context, userID uuid.UUID) ([]cartModels.CartModels, error)
+	GetTotalPrice(ctx context.Context, userID uuid.UUID) (int, error)
 }
 
 type cartRepository struct {
@@ -27,9 +28,9 @@ func NewCartRepository(db *gorm.DB) CartRepository {
 	return &cartRepository{db}
 }
 
-func (r *cartRepository) GetCartItemByProductID(userID uuid.UUID, productID uuid.UUID) (cartModels.CartModels, error) {
+func (r *cartRepository) GetCartItemByProductID(ctx context.Context, userID uuid.UUID, productID uuid.UUID) (cartModels.CartModels, error) {
 	var cartItem cartModels.CartModels
-	if err := r.db.Where("user_id = ? AND product_id = ?", userID, productID).First(&cartItem).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND product_id = ?", userID, productID).First(&cartItem).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return cartItem, ErrNotFound
 		}
@@ -38,9 +39,9 @@ func (r *cartRepository) GetCartItemByProductID(userID uuid.UUID, productID uuid
 	return cartItem, nil
 }
 
-func (r *cartRepository) AddItemToCart(userID uuid.UUID, productID uuid.UUID, quantity int) error {
+func (r *cartRepository) AddItemToCart(ctx context.Context, userID uuid.UUID, productID uuid.UUID, quantity int) error {
 	var cartItem cartModels.CartModels
-	err := r.db.Where("user_id = ? AND product_id = ?", userID, productID).First(&cartItem).Error
+	err := r.db.WithContext(ctx).Where("user_id = ? AND product_id = ?", userID, productID).First(&cartItem).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
@@ -52,27 +53,30 @@ func (r *cartRepository) AddItemToCart(userID uuid.UUID, productID uuid.UUID, qu
 			ProductID: productID,
 			Quantity:  quantity,
 		}
-		return r.db.Create(&cartItem).Error
+		return r.db.WithContext(ctx).Create(&cartItem).Error
 	} else {
 		cartItem.Quantity += quantity
-		return r.db.Save(&cartItem).Error
+		return r.db.WithContext(ctx).Save(&cartItem).Error
 	}
 }
 
-func (r *cartRepository) UpdateCartItem(cartItem cartModels.CartModels) error {
-	return r.db.Save(&cartItem).Error
+func (r *cartRepository) UpdateCartItem(ctx context.Context, cartItem cartModels.CartModels) error {
+	return r.db.WithContext(ctx).Save(&cartItem).Error
 }
-func (r *cartRepository) GetAllcartItems(userID uuid.UUID) ([]cartModels.CartModels, error) {
+
+// GetAllcartItems skips items whose product was deleted so they are neither
+// shown nor charged.
+func (r *cartRepository) GetAllcartItems(ctx context.Context, userID uuid.UUID) ([]cartModels.CartModels, error) {
 	var cartItems []cartModels.CartModels
-	if err := r.db.Preload("Product").Where("user_id = ?", userID).Find(&cartItems).Error; err != nil {
+	if err := r.db.WithContext(ctx).InnerJoins("Product").Where("cart_models.user_id = ?", userID).Find(&cartItems).Error; err != nil {
 		return nil, err
 	}
 	return cartItems, nil
 }
 
-func (r *cartRepository) GetTotalPrice(userID uuid.UUID) (int, error) {
+func (r *cartRepository) GetTotalPrice(ctx context.Context, userID uuid.UUID) (int, error) {
 	var cartItems []cartModels.CartModels
-	if err := r.db.Preload("Product").Where("user_id = ?", userID).Find(&cartItems).Error; err != nil {
+	if err := r.db.WithContext(ctx).InnerJoins("Product").Where("cart_models.user_id = ?", userID).Find(&cartItems).Error; err != nil {
 		return 0, err
 	}
 	var totalPrice int
@@ -1,15 +1,20 @@
 package repository
 
 import (
+	"context"
 	CommentModels "fiber-crud/internal/domain/comment"
+	ProductModels "fiber-crud/internal/domain/product"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type CommentRepository interface {
-	CreateComment(comment *CommentModels.Comment) error
-	Getcommentproductid(ProductID uuid.UUID, UserID uuid.UUID) ([]CommentModels.Comment, error)
+	CreateComment(ctx context.Context, comment *CommentModels.Comment) error
+	Getcommentproductid(ctx context.Context, ProductID uuid.UUID, UserID uuid.UUID) ([]CommentModels.Comment, error)
+	GetCommentByID(ctx context.Context, id uuid.UUID) (CommentModels.Comment, error)
+	DeleteComment(ctx context.Context, id uuid.UUID) error
+	RestoreComment(ctx context.Context, id uuid.UUID) (bool, error)
 }
 
 type Commentrepository struct {
@@ -20,16 +25,44 @@ func NewCommentRepository(db *gorm.DB) CommentRepository {
 	return &Commentrepository{db: db}
 }
 
-func (r *Commentrepository) CreateComment(comment *CommentModels.Comment) error {
+func (r *Commentrepository) CreateComment(ctx context.Context, comment *CommentModels.Comment) error {
 
-	return r.db.Create(comment).Error
+	return r.db.WithContext(ctx).Create(comment).Error
 }
 
-func (r *Commentrepository) Getcommentproductid(ProductID uuid.UUID, UserID uuid.UUID) ([]CommentModels.Comment, error) {
+func (r *Commentrepository) Getcommentproductid(ctx context.Context, ProductID uuid.UUID, UserID uuid.UUID) ([]CommentModels.Comment, error) {
 
 	var comments []CommentModels.Comment
-	if err := r.db.Where("product_id = ? AND user_id = ?", ProductID, UserID).Find(&comments).Error; err != nil {
+	err := r.db.WithContext(ctx).Where("product_id = ? AND user_id = ?", ProductID, UserID).
+		Where("product_id IN (?)", r.db.WithContext(ctx).Model(&ProductModels.Product{}).Select("id")).
+		Find(&comments).Error
+	if err != nil {
 		return nil, err
 	}
 	return comments, nil
 }
+
+func (r *Commentrepository) GetCommentByID(ctx context.Context, id uuid.UUID) (CommentModels.Comment, error) {
+	var comment CommentModels.Comment
+	if err := r.db.WithContext(ctx).First(&comment, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return CommentModels.Comment{}, nil
+		}
+		return CommentModels.Comment{}, err
+	}
+	return comment, nil
+}
+
+func (r *Commentrepository) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&CommentModels.Comment{}, "id = ?", id).Error
+}
+
+func (r *Commentrepository) RestoreComment(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Model(&CommentModels.Comment{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,56 @@
+package memoryRepository
+
+import (
+	"context"
+	"time"
+
+	apiKeyModels "fiber-crud/internal/domain/apikey"
+	apiKeyRepository "fiber-crud/internal/repository/apikey"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyRepository struct {
+	apiKeyRepository.APIKeyRepository
+	Keys map[uuid.UUID]*apiKeyModels.APIKey
+}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{Keys: map[uuid.UUID]*apiKeyModels.APIKey{}}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *apiKeyModels.APIKey) error {
+	key.ID = uuid.New()
+	key.CreatedAt = time.Now()
+	stored := *key
+	r.Keys[key.ID] = &stored
+	return nil
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*apiKeyModels.APIKey, error) {
+	for _, key := range r.Keys {
+		if key.KeyHash == hash {
+			stored := *key
+			return &stored, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) (bool, error) {
+	key, ok := r.Keys[id]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	return true, nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, before time.Time) error {
+	if key, ok := r.Keys[id]; ok && (key.LastUsedAt == nil || key.LastUsedAt.Before(before)) {
+		now := time.Now()
+		key.LastUsedAt = &now
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package memoryRepository
+
+import (
+	"context"
+	auditModels "fiber-crud/internal/domain/audit"
+	auditRepository "fiber-crud/internal/repository/audit"
+)
+
+type AuditRepository struct {
+	auditRepository.AuditRepository
+	Entries []auditModels.AuditLog
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *auditModels.AuditLog) error {
+	r.Entries = append(r.Entries, *entry)
+	return nil
+}
@@ -0,0 +1,328 @@
+package memoryRepository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	authModels "fiber-crud/internal/domain/auth"
+	authRepository "fiber-crud/internal/repository/auth"
+
+	"github.com/google/uuid"
+)
+
+type AuthRepository struct {
+	authRepository.AuthRepository
+	RefreshTokens map[uuid.UUID]*authModels.RefreshToken
+	RevokedTokens map[string]time.Time
+	ResetTokens   map[uuid.UUID]*authModels.PasswordResetToken
+
+	VerificationTokens map[uuid.UUID]*authModels.EmailVerificationToken
+	ExchangeCodes      map[uuid.UUID]*authModels.ExchangeCode
+	// RecoveryCodes holds the unused recovery code hashes of each user.
+	RecoveryCodes map[uuid.UUID][]string
+	Throttles     map[string]*authModels.LoginThrottle
+	Sessions      map[uuid.UUID]*authModels.Session
+}
+
+func NewAuthRepository() *AuthRepository {
+	return &AuthRepository{
+		RefreshTokens: map[uuid.UUID]*authModels.RefreshToken{},
+		RevokedTokens: map[string]time.Time{},
+		ResetTokens:   map[uuid.UUID]*authModels.PasswordResetToken{},
+
+		VerificationTokens: map[uuid.UUID]*authModels.EmailVerificationToken{},
+		ExchangeCodes:      map[uuid.UUID]*authModels.ExchangeCode{},
+		RecoveryCodes:      map[uuid.UUID][]string{},
+		Throttles:          map[string]*authModels.LoginThrottle{},
+		Sessions:           map[uuid.UUID]*authModels.Session{},
+	}
+}
+
+func (r *AuthRepository) CreateRefreshToken(ctx context.Context, token *authModels.RefreshToken) error {
+	stored := *token
+	r.RefreshTokens[token.ID] = &stored
+	return nil
+}
+
+func (r *AuthRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*authModels.RefreshToken, error) {
+	for _, token := range r.RefreshTokens {
+		if token.TokenHash == hash {
+			stored := *token
+			return &stored, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *AuthRepository) RotateRefreshToken(ctx context.Context, id uuid.UUID, replacedBy uuid.UUID) (bool, error) {
+	token, ok := r.RefreshTokens[id]
+	if !ok || token.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.RevokedAt = &now
+	token.ReplacedBy = &replacedBy
+	return true, nil
+}
+
+func (r *AuthRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range r.RefreshTokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *AuthRepository) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range r.RefreshTokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *AuthRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.RevokedTokens[jti] = expiresAt
+	return nil
+}
+
+func (r *AuthRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	_, ok := r.RevokedTokens[jti]
+	return ok, nil
+}
+
+func (r *AuthRepository) CreatePasswordResetToken(ctx context.Context, token *authModels.PasswordResetToken) error {
+	stored := *token
+	r.ResetTokens[token.ID] = &stored
+	return nil
+}
+
+func (r *AuthRepository) GetPasswordResetTokenByHash(ctx context.Context, hash string) (*authModels.PasswordResetToken, error) {
+	for _, token := range r.ResetTokens {
+		if token.TokenHash == hash {
+			stored := *token
+			return &stored, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *AuthRepository) ConsumePasswordResetToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	token, ok := r.ResetTokens[id]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	return true, nil
+}
+
+func (r *AuthRepository) InvalidatePasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range r.ResetTokens {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *AuthRepository) CreateEmailVerificationToken(ctx context.Context, token *authModels.EmailVerificationToken) error {
+	stored := *token
+	r.VerificationTokens[token.ID] = &stored
+	return nil
+}
+
+func (r *AuthRepository) GetEmailVerificationTokenByHash(ctx context.Context, hash string) (*authModels.EmailVerificationToken, error) {
+	for _, token := range r.VerificationTokens {
+		if token.TokenHash == hash {
+			stored := *token
+			return &stored, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *AuthRepository) ConsumeEmailVerificationToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	token, ok := r.VerificationTokens[id]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	return true, nil
+}
+
+func (r *AuthRepository) InvalidateEmailVerificationTokens(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range r.VerificationTokens {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *AuthRepository) CreateExchangeCode(ctx context.Context, code *authModels.ExchangeCode) error {
+	stored := *code
+	r.ExchangeCodes[code.ID] = &stored
+	return nil
+}
+
+func (r *AuthRepository) GetExchangeCodeByHash(ctx context.Context, hash string) (*authModels.ExchangeCode, error) {
+	for _, code := range r.ExchangeCodes {
+		if code.CodeHash == hash {
+			stored := *code
+			return &stored, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *AuthRepository) ConsumeExchangeCode(ctx context.Context, id uuid.UUID) (bool, error) {
+	code, ok := r.ExchangeCodes[id]
+	if !ok || code.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	code.UsedAt = &now
+	return true, nil
+}
+
+func (r *AuthRepository) RedeemTokenID(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	if _, ok := r.RevokedTokens[jti]; ok {
+		return false, nil
+	}
+	r.RevokedTokens[jti] = expiresAt
+	return true, nil
+}
+
+func (r *AuthRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	r.RecoveryCodes[userID] = append([]string(nil), hashes...)
+	return nil
+}
+
+func (r *AuthRepository) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) (bool, error) {
+	codes := r.RecoveryCodes[userID]
+	for i, code := range codes {
+		if code == hash {
+			r.RecoveryCodes[userID] = append(codes[:i:i], codes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *AuthRepository) GetThrottle(ctx context.Context, key string) (*authModels.LoginThrottle, error) {
+	throttle, ok := r.Throttles[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *throttle
+	return &copied, nil
+}
+
+func (r *AuthRepository) RecordFailure(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	throttle, ok := r.Throttles[key]
+	if !ok {
+		throttle = &authModels.LoginThrottle{Key: key}
+		r.Throttles[key] = throttle
+	}
+	if throttle.LastFailureAt.Before(windowStart) {
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = time.Now()
+	return throttle.Failures, nil
+}
+
+func (r *AuthRepository) LockThrottle(ctx context.Context, key string, until time.Time) error {
+	if throttle, ok := r.Throttles[key]; ok {
+		throttle.LockedUntil = &until
+	}
+	return nil
+}
+
+func (r *AuthRepository) ClearThrottle(ctx context.Context, key string) error {
+	delete(r.Throttles, key)
+	return nil
+}
+
+func (r *AuthRepository) CreateSession(ctx context.Context, session *authModels.Session) error {
+	stored := *session
+	stored.CreatedAt = time.Now()
+	r.Sessions[session.ID] = &stored
+	return nil
+}
+
+func (r *AuthRepository) GetSession(ctx context.Context, id uuid.UUID) (*authModels.Session, error) {
+	session, ok := r.Sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	stored := *session
+	return &stored, nil
+}
+
+func (r *AuthRepository) GetActiveSessions(ctx context.Context, userID uuid.UUID) ([]authModels.Session, error) {
+	var sessions []authModels.Session
+	for _, session := range r.Sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(time.Now()) {
+			sessions = append(sessions, *session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+func (r *AuthRepository) TouchSession(ctx context.Context, id uuid.UUID, ip string, expiresAt *time.Time) error {
+	session, ok := r.Sessions[id]
+	if !ok || session.RevokedAt != nil {
+		return nil
+	}
+	session.LastSeenAt = time.Now()
+	if ip != "" {
+		session.IP = ip
+	}
+	if expiresAt != nil {
+		session.ExpiresAt = *expiresAt
+	}
+	return nil
+}
+
+func (r *AuthRepository) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	if session, ok := r.Sessions[id]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+	return nil
+}
+
+func (r *AuthRepository) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	for _, session := range r.Sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *AuthRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	for jti, expiresAt := range r.RevokedTokens {
+		if expiresAt.Before(before) {
+			delete(r.RevokedTokens, jti)
+		}
+	}
+	for id, session := range r.Sessions {
+		if session.ExpiresAt.Before(before) {
+			delete(r.Sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package memoryRepository holds in-memory repositories for tests. They
+// implement the methods the usecase tests exercise; the rest of each
+// interface is embedded and panics when called.
+package memoryRepository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	userModels "fiber-crud/internal/domain/user"
+	userRepository "fiber-crud/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrDuplicate mirrors a unique constraint violation of the users table.
+var ErrDuplicate = errors.New("duplicate key value violates unique constraint")
+
+type UserRepository struct {
+	userRepository.UserRepository
+	Users      map[uuid.UUID]userModels.User
+	Identities []userModels.Identity
+	// Erased marks users whose erasure request has completed.
+	Erased map[uuid.UUID]bool
+}
+
+func NewUserRepository(users ...userModels.User) *UserRepository {
+	r := &UserRepository{Users: map[uuid.UUID]userModels.User{}}
+	for _, user := range users {
+		r.Users[user.ID] = user
+	}
+	return r
+}
+
+// GetByID, GetByUsername and GetByEmail skip soft-deleted users like the
+// gorm default scope does.
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (userModels.User, error) {
+	user := r.Users[id]
+	if user.DeletedAt.Valid {
+		return userModels.User{}, nil
+	}
+	return user, nil
+}
+
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*userModels.User, error) {
+	for _, user := range r.Users {
+		if user.Name == username && !user.DeletedAt.Valid {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*userModels.User, error) {
+	for _, user := range r.Users {
+		if user.Email == email && !user.DeletedAt.Valid {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *UserRepository) IsUsernameTaken(ctx context.Context, username string) (bool, error) {
+	for _, user := range r.Users {
+		if user.Name == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *UserRepository) IsEmailTaken(ctx context.Context, email string) (bool, error) {
+	for _, user := range r.Users {
+		if user.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Create enforces the unique name and email constraints of the users table.
+func (r *UserRepository) Create(ctx context.Context, user userModels.User) (*userModels.User, error) {
+	for _, existing := range r.Users {
+		if existing.Name == user.Name || existing.Email == user.Email {
+			return nil, ErrDuplicate
+		}
+	}
+	user.ID = uuid.New()
+	r.Users[user.ID] = user
+	return &user, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user userModels.User) error {
+	r.Users[user.ID] = user
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if user, ok := r.Users[id]; ok && !user.DeletedAt.Valid {
+		user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		r.Users[id] = user
+	}
+	return nil
+}
+
+func (r *UserRepository) Restore(ctx context.Context, id uuid.UUID) (bool, error) {
+	user, ok := r.Users[id]
+	if !ok || !user.DeletedAt.Valid || r.Erased[id] {
+		return false, nil
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	r.Users[id] = user
+	return true, nil
+}
+
+func (r *UserRepository) IsErased(ctx context.Context, id uuid.UUID) (bool, error) {
+	return r.Erased[id], nil
+}
+
+func (r *UserRepository) FindByIdentity(ctx context.Context, provider, subject string) (*userModels.User, error) {
+	for _, identity := range r.Identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			user := r.Users[identity.UserID]
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *UserRepository) CreateIdentity(ctx context.Context, identity *userModels.Identity) error {
+	r.Identities = append(r.Identities, *identity)
+	return nil
+}
+
+func (r *UserRepository) AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	user, ok := r.Users[userID]
+	if !ok || user.TOTPLastStep >= step {
+		return false, nil
+	}
+	user.TOTPLastStep = step
+	r.Users[userID] = user
+	return true, nil
+}
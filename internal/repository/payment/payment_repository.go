@@ -1,6 +1,7 @@
 package paymentRepository
 
 import (
+	"context"
 	paymentModels "fiber-crud/internal/domain/payment"
 
 	"github.com/google/uuid"
@@ -12,9 +13,9 @@ type paymentRepository struct {
 }
 
 type PaymentRepository interface {
-	CreatePayment(payment *paymentModels.PaymentModels) error
-	UpdatePayment(payment *paymentModels.PaymentModels) error
-	GetPaymentByOrderID(orderID uuid.UUID, payment *paymentModels.PaymentModels) error
+	CreatePayment(ctx context.Context, payment *paymentModels.PaymentModels) error
+	UpdatePayment(ctx context.Context, payment *paymentModels.PaymentModels) error
+	GetPaymentByOrderID(ctx context.Context, orderID uuid.UUID, payment *paymentModels.PaymentModels) error
 }
 
 func NewPaymentRepository(db *gorm.DB) PaymentRepository {
@@ -22,14 +23,14 @@ func NewPaymentRepository(db *gorm.DB) PaymentRepository {
 }
 
 // buat payment dengan midtrans
-func (r *paymentRepository) CreatePayment(payment *paymentModels.PaymentModels) error {
-	return r.db.Create(&payment).Error
+func (r *paymentRepository) CreatePayment(ctx context.Context, payment *paymentModels.PaymentModels) error {
+	return r.db.WithContext(ctx).Create(&payment).Error
 }
 
-func (r *paymentRepository) UpdatePayment(payment *paymentModels.PaymentModels) error {
-	return r.db.Save(&payment).Error
+func (r *paymentRepository) UpdatePayment(ctx context.Context, payment *paymentModels.PaymentModels) error {
+	return r.db.WithContext(ctx).Save(&payment).Error
 }
 
-func (r *paymentRepository) GetPaymentByOrderID(orderID uuid.UUID, payment *paymentModels.PaymentModels) error {
-	return r.db.Where("order_id = ?", orderID).First(&payment).Error
+func (r *paymentRepository) GetPaymentByOrderID(ctx context.Context, orderID uuid.UUID, payment *paymentModels.PaymentModels) error {
+	return r.db.WithContext(ctx).Where("order_id = ?", orderID).First(&payment).Error
 }
@@ -0,0 +1,155 @@
+package privacyRepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cartModels "fiber-crud/internal/domain/cart"
+	CommentModels "fiber-crud/internal/domain/comment"
+	paymentModels "fiber-crud/internal/domain/payment"
+	privacyModels "fiber-crud/internal/domain/privacy"
+	userModels "fiber-crud/internal/domain/user"
+	userRepository "fiber-crud/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErasedCommentContent replaces the text of comments written by an erased
+// user.
+const ErasedCommentContent = "[removed at the author's request]"
+
+type PrivacyRepository interface {
+	GetComments(ctx context.Context, userID uuid.UUID) ([]CommentModels.Comment, error)
+	GetCartItems(ctx context.Context, userID uuid.UUID) ([]cartModels.CartModels, error)
+	GetPayments(ctx context.Context, userID uuid.UUID) ([]paymentModels.PaymentModels, error)
+
+	CreateErasureRequest(ctx context.Context, request *privacyModels.ErasureRequest) error
+	GetPendingErasureRequest(ctx context.Context, userID uuid.UUID) (*privacyModels.ErasureRequest, error)
+	CancelErasureRequest(ctx context.Context, id uuid.UUID) (bool, error)
+	GetDueErasureRequests(ctx context.Context, now time.Time) ([]privacyModels.ErasureRequest, error)
+	Anonymize(ctx context.Context, request privacyModels.ErasureRequest) error
+}
+
+type privacyRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRepository(db *gorm.DB) PrivacyRepository {
+	return &privacyRepository{db: db}
+}
+
+// GetComments includes soft-deleted comments, which are stored until the
+// purge removes them.
+func (r *privacyRepository) GetComments(ctx context.Context, userID uuid.UUID) ([]CommentModels.Comment, error) {
+	var comments []CommentModels.Comment
+	if err := r.db.WithContext(ctx).Unscoped().Where("user_id = ?", userID).Order("created_at").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// GetCartItems includes items whose product has since been deleted; the
+// export should describe everything stored about the user.
+func (r *privacyRepository) GetCartItems(ctx context.Context, userID uuid.UUID) ([]cartModels.CartModels, error) {
+	var items []cartModels.CartModels
+	err := r.db.WithContext(ctx).Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", userID).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *privacyRepository) GetPayments(ctx context.Context, userID uuid.UUID) ([]paymentModels.PaymentModels, error) {
+	var payments []paymentModels.PaymentModels
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *privacyRepository) CreateErasureRequest(ctx context.Context, request *privacyModels.ErasureRequest) error {
+	return r.db.WithContext(ctx).Create(request).Error
+}
+
+func (r *privacyRepository) GetPendingErasureRequest(ctx context.Context, userID uuid.UUID) (*privacyModels.ErasureRequest, error) {
+	var request privacyModels.ErasureRequest
+	err := r.db.WithContext(ctx).Where("user_id = ? AND cancelled_at IS NULL AND completed_at IS NULL", userID).First(&request).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *privacyRepository) CancelErasureRequest(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&privacyModels.ErasureRequest{}).
+		Where("id = ? AND cancelled_at IS NULL AND completed_at IS NULL", id).
+		Update("cancelled_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *privacyRepository) GetDueErasureRequests(ctx context.Context, now time.Time) ([]privacyModels.ErasureRequest, error) {
+	var requests []privacyModels.ErasureRequest
+	err := r.db.WithContext(ctx).Where("scheduled_for <= ? AND cancelled_at IS NULL AND completed_at IS NULL", now).
+		Order("scheduled_for").
+		Find(&requests).Error
+	if err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// Anonymize overwrites the user's personal data, redacts their comments,
+// drops their credentials and soft-deletes the account, all in one
+// transaction that also completes the request. Payments are left untouched.
+func (r *privacyRepository) Anonymize(ctx context.Context, request privacyModels.ErasureRequest) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		alias := "erased-" + request.UserID.String()
+		now := time.Now()
+
+		err := tx.Unscoped().Model(&userModels.User{}).
+			Where("id = ?", request.UserID).
+			Updates(map[string]interface{}{
+				"name":                    alias,
+				"email":                   alias + "@invalid",
+				"email_verified_at":       nil,
+				"password":                "!",
+				"avatar":                  "",
+				"totp_secret":             "",
+				"totp_enabled_at":         nil,
+				"totp_last_step":          0,
+				"suspended_reason":        "",
+				"password_reset_required": false,
+				"deleted_at":              now,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Model(&CommentModels.Comment{}).
+			Where("user_id = ?", request.UserID).
+			Update("content", ErasedCommentContent).Error
+		if err != nil {
+			return err
+		}
+
+		for _, table := range userRepository.OwnedTables {
+			if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id = ?", table), request.UserID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&privacyModels.ErasureRequest{}).
+			Where("id = ?", request.ID).
+			Update("completed_at", now).Error
+	})
+}
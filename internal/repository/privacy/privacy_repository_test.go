@@ -0,0 +1,145 @@
+package privacyRepository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	apiKeyModels "fiber-crud/internal/domain/apikey"
+	authModels "fiber-crud/internal/domain/auth"
+	cartModels "fiber-crud/internal/domain/cart"
+	CommentModels "fiber-crud/internal/domain/comment"
+	paymentModels "fiber-crud/internal/domain/payment"
+	privacyModels "fiber-crud/internal/domain/privacy"
+	ProductModels "fiber-crud/internal/domain/product"
+	userModels "fiber-crud/internal/domain/user"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// openTestDB connects to TEST_DATABASE_URL and returns a transaction that is
+// rolled back when the test ends.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(
+		&userModels.User{},
+		&userModels.Identity{},
+		&ProductModels.Product{},
+		&CommentModels.Comment{},
+		&cartModels.CartModels{},
+		&paymentModels.PaymentModels{},
+		&authModels.RefreshToken{},
+		&authModels.PasswordResetToken{},
+		&authModels.EmailVerificationToken{},
+		&authModels.ExchangeCode{},
+		&authModels.RecoveryCode{},
+		&authModels.Session{},
+		&apiKeyModels.APIKey{},
+		&privacyModels.ErasureRequest{},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+func TestAnonymizeErasesPersonalDataAndKeepsPayments(t *testing.T) {
+	db := openTestDB(t)
+	create := func(value interface{}) {
+		t.Helper()
+		if err := db.Create(value).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	id := uuid.New()
+	user := userModels.User{ID: id, Name: "alice-" + id.String(), Email: id.String() + "@example.com", Password: "hash", Avatar: "alice.png"}
+	create(&user)
+	product := ProductModels.Product{ID: uuid.New(), UserID: id, Name: "lamp"}
+	create(&product)
+	create(&CommentModels.Comment{ID: uuid.New(), UserID: id, ProductID: product.ID, Content: "my address is ..."})
+	create(&authModels.Session{ID: uuid.New(), UserID: id, ExpiresAt: time.Now().Add(time.Hour)})
+	create(&paymentModels.PaymentModels{ID: uuid.New(), UserID: id, OrderID: uuid.New().String(), Amount: 100, Status: "settlement"})
+	request := privacyModels.ErasureRequest{UserID: id, RequestedAt: time.Now(), ScheduledFor: time.Now()}
+	create(&request)
+
+	if err := NewPrivacyRepository(db).Anonymize(context.Background(), request); err != nil {
+		t.Fatalf("Anonymize: %v", err)
+	}
+
+	var erased userModels.User
+	if err := db.Unscoped().First(&erased, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if erased.Name != "erased-"+id.String() || erased.Email != "erased-"+id.String()+"@invalid" || erased.Avatar != "" || erased.Password != "!" || !erased.DeletedAt.Valid {
+		t.Fatalf("user after erasure = %+v, want placeholders and a soft delete", erased)
+	}
+
+	count := func(model interface{}, query string, args ...interface{}) int64 {
+		t.Helper()
+		var n int64
+		if err := db.Unscoped().Model(model).Where(query, args...).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := count(&CommentModels.Comment{}, "user_id = ? AND content <> ?", id, ErasedCommentContent); n != 0 {
+		t.Fatalf("%d comments keep their original text", n)
+	}
+	if n := count(&authModels.Session{}, "user_id = ?", id); n != 0 {
+		t.Fatalf("%d sessions survived the erasure", n)
+	}
+	if n := count(&paymentModels.PaymentModels{}, "user_id = ?", id); n != 1 {
+		t.Fatal("payment record was removed by the erasure")
+	}
+	if n := count(&privacyModels.ErasureRequest{}, "id = ? AND completed_at IS NOT NULL", request.ID); n != 1 {
+		t.Fatal("erasure request not marked as completed")
+	}
+}
+
+func TestGetCommentsIncludesSoftDeletedComments(t *testing.T) {
+	db := openTestDB(t)
+	id := uuid.New()
+	if err := db.Create(&userModels.User{ID: id, Name: "bob-" + id.String(), Email: id.String() + "@example.com", Password: "hash"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	product := ProductModels.Product{ID: uuid.New(), UserID: id, Name: "lamp"}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatal(err)
+	}
+	kept := CommentModels.Comment{ID: uuid.New(), UserID: id, ProductID: product.ID, Content: "still here"}
+	deleted := CommentModels.Comment{ID: uuid.New(), UserID: id, ProductID: product.ID, Content: "deleted"}
+	if err := db.Create(&kept).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	comments, err := NewPrivacyRepository(db).GetComments(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetComments: %v", err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("exported %d comments, want the soft-deleted one too", len(comments))
+	}
+}
@@ -1,21 +1,24 @@
 package ProductRepository
 
 import (
+	"context"
 	ProductModels "fiber-crud/internal/domain/product"
+	"fiber-crud/package/metrics"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type ProductRepository interface {
-	GetProducts(userID uuid.UUID) ([]ProductModels.Product, error)
-	GetProductByID(id uuid.UUID, userID uuid.UUID) (ProductModels.Product, error)
-	CreateProduct(product *ProductModels.Product) (*ProductModels.Product, error)
-	UpdateProduct(product *ProductModels.Product) error
-	DeleteProduct(id uuid.UUID, userID uuid.UUID) error
-	GetAllProducts() ([]ProductModels.Product, error)
-	GetAllProductsByid(id uuid.UUID) ([]ProductModels.Product, error)
-	DecreaseStock(productID uuid.UUID, quantity int) error
+	GetProducts(ctx context.Context, userID uuid.UUID) ([]ProductModels.Product, error)
+	GetProductByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (ProductModels.Product, error)
+	CreateProduct(ctx context.Context, product *ProductModels.Product) (*ProductModels.Product, error)
+	UpdateProduct(ctx context.Context, product *ProductModels.Product) error
+	DeleteProduct(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	RestoreProduct(ctx context.Context, id uuid.UUID) (bool, error)
+	GetAllProducts(ctx context.Context) ([]ProductModels.Product, error)
+	GetAllProductsByid(ctx context.Context, id uuid.UUID) ([]ProductModels.Product, error)
+	DecreaseStock(ctx context.Context, productID uuid.UUID, quantity int) error
 }
 
 type productRepository struct {
@@ -26,17 +29,17 @@ func NewProductRepository(db *gorm.DB) ProductRepository {
 	return &productRepository{db: db}
 }
 
-func (r *productRepository) GetProducts(userID uuid.UUID) ([]ProductModels.Product, error) {
+func (r *productRepository) GetProducts(ctx context.Context, userID uuid.UUID) ([]ProductModels.Product, error) {
 	var products []ProductModels.Product
-	if err := r.db.Preload("Comments").Where("user_id = ?", userID).Find(&products).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Comments").Where("user_id = ?", userID).Find(&products).Error; err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
-func (r *productRepository) GetProductByID(id uuid.UUID, userID uuid.UUID) (ProductModels.Product, error) {
+func (r *productRepository) GetProductByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (ProductModels.Product, error) {
 	var product ProductModels.Product
-	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&product).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&product).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return ProductModels.Product{}, nil
 		}
@@ -45,30 +48,40 @@ func (r *productRepository) GetProductByID(id uuid.UUID, userID uuid.UUID) (Prod
 	return product, nil
 }
 
-func (r *productRepository) CreateProduct(product *ProductModels.Product) (*ProductModels.Product, error) {
-	if err := r.db.Create(product).Error; err != nil {
+func (r *productRepository) CreateProduct(ctx context.Context, product *ProductModels.Product) (*ProductModels.Product, error) {
+	if err := r.db.WithContext(ctx).Create(product).Error; err != nil {
 		return nil, err
 	}
 	return product, nil
 }
 
-func (r *productRepository) UpdateProduct(product *ProductModels.Product) error {
-	if err := r.db.Save(product).Error; err != nil {
+func (r *productRepository) UpdateProduct(ctx context.Context, product *ProductModels.Product) error {
+	if err := r.db.WithContext(ctx).Save(product).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *productRepository) DeleteProduct(id uuid.UUID, userID uuid.UUID) error {
-	if err := r.db.Delete(&ProductModels.Product{}, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+func (r *productRepository) DeleteProduct(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&ProductModels.Product{}, "id = ? AND user_id = ?", id, userID).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *productRepository) DecreaseStock(productID uuid.UUID, quantity int) error {
+func (r *productRepository) RestoreProduct(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Model(&ProductModels.Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *productRepository) DecreaseStock(ctx context.Context, productID uuid.UUID, quantity int) error {
 
-	result := r.db.Model(&ProductModels.Product{}).
+	result := r.db.WithContext(ctx).Model(&ProductModels.Product{}).
 		Where("id = ? AND stock >= ?", productID, quantity).
 		Update("stock", gorm.Expr("stock - ?", quantity))
 
@@ -80,20 +93,22 @@ func (r *productRepository) DecreaseStock(productID uuid.UUID, quantity int) err
 		return gorm.ErrRecordNotFound
 	}
 
+	metrics.StockDecrements.Inc()
+	metrics.StockUnitsDecremented.Add(float64(quantity))
 	return nil
 }
 
-func (r *productRepository) GetAllProducts() ([]ProductModels.Product, error) {
+func (r *productRepository) GetAllProducts(ctx context.Context) ([]ProductModels.Product, error) {
 	var products []ProductModels.Product
-	if err := r.db.Preload("Comments").Find(&products).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Comments").Find(&products).Error; err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
-func (r *productRepository) GetAllProductsByid(id uuid.UUID) ([]ProductModels.Product, error) {
+func (r *productRepository) GetAllProductsByid(ctx context.Context, id uuid.UUID) ([]ProductModels.Product, error) {
 	var products []ProductModels.Product
-	if err := r.db.Preload("Comments").Where("id = ?", id).Find(&products).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Comments").Where("id = ?", id).Find(&products).Error; err != nil {
 		return nil, err
 	}
 	return products, nil
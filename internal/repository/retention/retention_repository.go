@@ -0,0 +1,75 @@
+package retentionRepository
+
+import (
+	"context"
+	"time"
+
+	userRepository "fiber-crud/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// PurgeResult counts the soft-deleted rows removed by one purge.
+type PurgeResult struct {
+	Users    int64
+	Products int64
+	Comments int64
+}
+
+type RetentionRepository interface {
+	PurgeDeleted(ctx context.Context, before time.Time) (PurgeResult, error)
+}
+
+type retentionRepository struct {
+	db *gorm.DB
+}
+
+func NewRetentionRepository(db *gorm.DB) RetentionRepository {
+	return &retentionRepository{db: db}
+}
+
+// PurgeDeleted hard-deletes users, products and comments soft-deleted before
+// the given time, together with the rows that reference them, in one
+// transaction. Erased accounts are soft-deleted too but are skipped: their
+// anonymized rows keep the comments they wrote attached to a placeholder.
+func (r *retentionRepository) PurgeDeleted(ctx context.Context, before time.Time) (PurgeResult, error) {
+	var result PurgeResult
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		purgedUsers := `SELECT id FROM users WHERE deleted_at < @before
+			AND id NOT IN (SELECT user_id FROM erasure_requests WHERE completed_at IS NOT NULL)`
+		purgedProducts := "SELECT id FROM products WHERE deleted_at < @before"
+		args := map[string]interface{}{"before": before}
+
+		comments := tx.Exec(`DELETE FROM comments
+			WHERE deleted_at < @before
+				OR product_id IN (`+purgedProducts+`)
+				OR user_id IN (`+purgedUsers+`)`, args)
+		if comments.Error != nil {
+			return comments.Error
+		}
+		result.Comments = comments.RowsAffected
+
+		if err := tx.Exec(`DELETE FROM cart_models WHERE product_id IN (`+purgedProducts+`)`, args).Error; err != nil {
+			return err
+		}
+		products := tx.Exec(`DELETE FROM products WHERE deleted_at < @before`, args)
+		if products.Error != nil {
+			return products.Error
+		}
+		result.Products = products.RowsAffected
+
+		for _, table := range userRepository.OwnedTables {
+			if err := tx.Exec(`DELETE FROM `+table+` WHERE user_id IN (`+purgedUsers+`)`, args).Error; err != nil {
+				return err
+			}
+		}
+		users := tx.Exec(`DELETE FROM users WHERE id IN (`+purgedUsers+`)`, args)
+		if users.Error != nil {
+			return users.Error
+		}
+		result.Users = users.RowsAffected
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,155 @@
+package retentionRepository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	apiKeyModels "fiber-crud/internal/domain/apikey"
+	authModels "fiber-crud/internal/domain/auth"
+	cartModels "fiber-crud/internal/domain/cart"
+	CommentModels "fiber-crud/internal/domain/comment"
+	privacyModels "fiber-crud/internal/domain/privacy"
+	ProductModels "fiber-crud/internal/domain/product"
+	userModels "fiber-crud/internal/domain/user"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// openTestDB connects to TEST_DATABASE_URL and returns a transaction that is
+// rolled back when the test ends.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(
+		&userModels.User{},
+		&userModels.Identity{},
+		&ProductModels.Product{},
+		&CommentModels.Comment{},
+		&cartModels.CartModels{},
+		&authModels.RefreshToken{},
+		&authModels.PasswordResetToken{},
+		&authModels.EmailVerificationToken{},
+		&authModels.ExchangeCode{},
+		&authModels.RecoveryCode{},
+		&authModels.Session{},
+		&apiKeyModels.APIKey{},
+		&privacyModels.ErasureRequest{},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+func deletedAt(ago time.Duration) gorm.DeletedAt {
+	return gorm.DeletedAt{Time: time.Now().Add(-ago), Valid: true}
+}
+
+func TestPurgeDeletedRemovesOnlyExpiredRows(t *testing.T) {
+	db := openTestDB(t)
+	day := 24 * time.Hour
+
+	create := func(value interface{}) {
+		t.Helper()
+		if err := db.Create(value).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	newUser := func(deleted gorm.DeletedAt) userModels.User {
+		id := uuid.New()
+		user := userModels.User{ID: id, Name: id.String(), Email: id.String() + "@example.com", Password: "x", DeletedAt: deleted}
+		create(&user)
+		return user
+	}
+
+	expired := newUser(deletedAt(40 * day))
+	recent := newUser(deletedAt(day))
+	live := newUser(gorm.DeletedAt{})
+
+	create(&authModels.Session{ID: uuid.New(), UserID: expired.ID, ExpiresAt: time.Now().Add(day)})
+	create(&authModels.Session{ID: uuid.New(), UserID: recent.ID, ExpiresAt: time.Now().Add(day)})
+
+	oldProduct := ProductModels.Product{ID: uuid.New(), UserID: live.ID, Name: "old", DeletedAt: deletedAt(40 * day)}
+	create(&oldProduct)
+	create(&CommentModels.Comment{ID: uuid.New(), UserID: live.ID, ProductID: oldProduct.ID, Content: "on a purged product"})
+	create(&cartModels.CartModels{ID: uuid.New(), UserID: live.ID, ProductID: oldProduct.ID, Quantity: 1})
+	liveProduct := ProductModels.Product{ID: uuid.New(), UserID: live.ID, Name: "live"}
+	create(&liveProduct)
+	create(&CommentModels.Comment{ID: uuid.New(), UserID: expired.ID, ProductID: liveProduct.ID, Content: "by a purged user"})
+	keptComment := CommentModels.Comment{ID: uuid.New(), UserID: live.ID, ProductID: liveProduct.ID, Content: "kept"}
+	create(&keptComment)
+
+	result, err := NewRetentionRepository(db).PurgeDeleted(context.Background(), time.Now().Add(-30*day))
+	if err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if result.Users != 1 || result.Products != 1 || result.Comments != 2 {
+		t.Fatalf("PurgeDeleted = %+v, want 1 user, 1 product and 2 comments", result)
+	}
+
+	count := func(model interface{}, query string, args ...interface{}) int64 {
+		t.Helper()
+		var n int64
+		if err := db.Unscoped().Model(model).Where(query, args...).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := count(&userModels.User{}, "id IN ?", []uuid.UUID{recent.ID, live.ID}); n != 2 {
+		t.Fatalf("%d of the recently deleted and live users remain, want 2", n)
+	}
+	if n := count(&authModels.Session{}, "user_id = ?", expired.ID); n != 0 {
+		t.Fatalf("%d sessions of the purged user remain", n)
+	}
+	if n := count(&authModels.Session{}, "user_id = ?", recent.ID); n != 1 {
+		t.Fatal("session of a user inside the retention period was purged")
+	}
+	if n := count(&cartModels.CartModels{}, "product_id = ?", oldProduct.ID); n != 0 {
+		t.Fatalf("%d cart rows for the purged product remain", n)
+	}
+	if n := count(&CommentModels.Comment{}, "id = ?", keptComment.ID); n != 1 {
+		t.Fatal("a live comment on a live product was purged")
+	}
+}
+
+// Erased accounts are soft-deleted too, but they are kept as the anchor of
+// the payments they made.
+func TestPurgeDeletedKeepsErasedUsers(t *testing.T) {
+	db := openTestDB(t)
+	day := 24 * time.Hour
+
+	id := uuid.New()
+	erased := userModels.User{ID: id, Name: "erased-" + id.String(), Email: "erased-" + id.String() + "@invalid", Password: "!", DeletedAt: deletedAt(40 * day)}
+	if err := db.Create(&erased).Error; err != nil {
+		t.Fatal(err)
+	}
+	completed := time.Now().Add(-40 * day)
+	request := privacyModels.ErasureRequest{UserID: id, RequestedAt: completed, ScheduledFor: completed, CompletedAt: &completed}
+	if err := db.Create(&request).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewRetentionRepository(db).PurgeDeleted(context.Background(), time.Now().Add(-30*day))
+	if err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if result.Users != 0 {
+		t.Fatalf("PurgeDeleted removed %d users, want the erased one kept", result.Users)
+	}
+}
@@ -1,22 +1,48 @@
 package userRepository
 
 import (
+	"context"
 	userModels "fiber-crud/internal/domain/user"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// OwnedTables hold rows keyed by user_id that are meaningless without their
+// user: credentials and personal data with no accounting value. Erasure
+// empties them and the purge drops them with the user. Payments are
+// deliberately absent; they are kept for accounting.
+var OwnedTables = []string{
+	"cart_models",
+	"identities",
+	"refresh_tokens",
+	"sessions",
+	"api_keys",
+	"recovery_codes",
+	"password_reset_tokens",
+	"email_verification_tokens",
+	"exchange_codes",
+}
+
 type UserRepository interface {
-	GetAll() ([]userModels.User, error)
-	GetByID(id uuid.UUID) (userModels.User, error)
-	GetByUsername(username string) (*userModels.User, error)
-	GetByEmail(email string) (*userModels.User, error)
-	Create(user userModels.User) (*userModels.User, error)
-	Update(user userModels.User) error
-	FindGoogleId(googleId string) (*userModels.User, error)
-	Delete(id uuid.UUID) error
-	Search(query string) ([]userModels.User, error)
+	GetAll(ctx context.Context) ([]userModels.User, error)
+	List(ctx context.Context, filter userModels.ListFilter) ([]userModels.User, int64, error)
+	GetByID(ctx context.Context, id uuid.UUID) (userModels.User, error)
+	GetByUsername(ctx context.Context, username string) (*userModels.User, error)
+	GetByEmail(ctx context.Context, email string) (*userModels.User, error)
+	IsUsernameTaken(ctx context.Context, username string) (bool, error)
+	IsEmailTaken(ctx context.Context, email string) (bool, error)
+	Create(ctx context.Context, user userModels.User) (*userModels.User, error)
+	Update(ctx context.Context, user userModels.User) error
+	FindByIdentity(ctx context.Context, provider, subject string) (*userModels.User, error)
+	GetIdentities(ctx context.Context, userID uuid.UUID) ([]userModels.Identity, error)
+	CreateIdentity(ctx context.Context, identity *userModels.Identity) error
+	DeleteIdentity(ctx context.Context, userID uuid.UUID, provider string) (bool, error)
+	AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID) (bool, error)
+	IsErased(ctx context.Context, id uuid.UUID) (bool, error)
+	Search(ctx context.Context, query string) ([]userModels.User, error)
 }
 
 type userRepository struct {
@@ -27,17 +53,55 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db}
 }
 
-func (r *userRepository) GetAll() ([]userModels.User, error) {
+func (r *userRepository) GetAll(ctx context.Context) ([]userModels.User, error) {
 	var users []userModels.User
-	if err := r.db.Select("id, name, email, created_at").Find(&users).Error; err != nil {
+	if err := r.db.WithContext(ctx).Select("id, name, email, created_at").Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-func (r *userRepository) GetByID(id uuid.UUID) (userModels.User, error) {
+// List returns one page of users, newest first, and the number of users
+// matching the filter.
+func (r *userRepository) List(ctx context.Context, filter userModels.ListFilter) ([]userModels.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&userModels.User{})
+	if filter.Status == userModels.StatusDeleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filter.Query != "" {
+		like := "%" + filter.Query + "%"
+		query = query.Where("name ILIKE ? OR email ILIKE ?", like, like)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	switch filter.Status {
+	case userModels.StatusActive:
+		query = query.Where("suspended_at IS NULL")
+	case userModels.StatusSuspended:
+		query = query.Where("suspended_at IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []userModels.User
+	err := query.Omit("password", "totp_secret").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset((filter.Page - 1) * filter.Limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (userModels.User, error) {
 	var u userModels.User
-	if err := r.db.First(&u, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&u, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return userModels.User{}, nil
 		}
@@ -46,17 +110,17 @@ func (r *userRepository) GetByID(id uuid.UUID) (userModels.User, error) {
 	return u, nil
 }
 
-func (r *userRepository) Search(query string) ([]userModels.User, error) {
+func (r *userRepository) Search(ctx context.Context, query string) ([]userModels.User, error) {
 	var users []userModels.User
-	if err := r.db.Where("name LIKE ?", "%"+query+"%").Find(&users).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("name LIKE ?", "%"+query+"%").Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-func (r *userRepository) GetByUsername(username string) (*userModels.User, error) {
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*userModels.User, error) {
 	var u userModels.User
-	if err := r.db.Where("name = ?", username).First(&u).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("name = ?", username).First(&u).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -65,9 +129,9 @@ func (r *userRepository) GetByUsername(username string) (*userModels.User, error
 	return &u, nil
 }
 
-func (r *userRepository) GetByEmail(email string) (*userModels.User, error) {
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*userModels.User, error) {
 	var u userModels.User
-	if err := r.db.Where("email = ?", email).First(&u).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&u).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -76,30 +140,75 @@ func (r *userRepository) GetByEmail(email string) (*userModels.User, error) {
 	return &u, nil
 }
 
-func (r *userRepository) Create(u userModels.User) (*userModels.User, error) {
-	if err := r.db.Create(&u).Error; err != nil {
+// IsUsernameTaken also counts soft-deleted users, whose names stay reserved
+// until they are purged so a restore can never collide.
+func (r *userRepository) IsUsernameTaken(ctx context.Context, username string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Unscoped().Model(&userModels.User{}).Where("name = ?", username).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// IsEmailTaken also counts soft-deleted users, see IsUsernameTaken.
+func (r *userRepository) IsEmailTaken(ctx context.Context, email string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Unscoped().Model(&userModels.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, u userModels.User) (*userModels.User, error) {
+	if err := r.db.WithContext(ctx).Create(&u).Error; err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
-func (r *userRepository) Update(u userModels.User) error {
-	if err := r.db.Save(&u).Error; err != nil {
+func (r *userRepository) Update(ctx context.Context, u userModels.User) error {
+	if err := r.db.WithContext(ctx).Save(&u).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *userRepository) Delete(id uuid.UUID) error {
-	if err := r.db.Delete(&userModels.User{}, "id = ?", id).Error; err != nil {
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&userModels.User{}, "id = ?", id).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *userRepository) FindGoogleId(googleId string) (*userModels.User, error) {
+// erasedUsers selects the users whose erasure request has been carried out.
+const erasedUsers = `SELECT user_id FROM erasure_requests WHERE completed_at IS NOT NULL`
+
+// Restore never brings back an erased user; the account only holds
+// placeholders by then.
+func (r *userRepository) Restore(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Model(&userModels.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL AND id NOT IN ("+erasedUsers+")", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *userRepository) IsErased(ctx context.Context, id uuid.UUID) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM ("+erasedUsers+") AS erased WHERE user_id = ?", id).Scan(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *userRepository) FindByIdentity(ctx context.Context, provider, subject string) (*userModels.User, error) {
 	var u userModels.User
-	if err := r.db.Where("google_id = ?", googleId).First(&u).Error; err != nil {
+	err := r.db.WithContext(ctx).Joins("JOIN identities ON identities.user_id = users.id").
+		Where("identities.provider = ? AND identities.subject = ?", provider, subject).
+		First(&u).Error
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -107,3 +216,35 @@ func (r *userRepository) FindGoogleId(googleId string) (*userModels.User, error)
 	}
 	return &u, nil
 }
+
+func (r *userRepository) GetIdentities(ctx context.Context, userID uuid.UUID) ([]userModels.Identity, error) {
+	var identities []userModels.Identity
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *userRepository) CreateIdentity(ctx context.Context, identity *userModels.Identity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *userRepository) DeleteIdentity(ctx context.Context, userID uuid.UUID, provider string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND provider = ?", userID, provider).Delete(&userModels.Identity{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// AdvanceTOTPStep records the last accepted TOTP time step. It reports false
+// when the step was already used, which blocks replay of a code.
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&userModels.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
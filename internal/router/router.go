@@ -1,72 +1,135 @@
 package router
 
 import (
+	userModels "fiber-crud/internal/domain/user"
+	apiKeyHandler "fiber-crud/internal/handler/apikey"
+	auditHandler "fiber-crud/internal/handler/audit"
+	authHandler "fiber-crud/internal/handler/auth"
 	handler "fiber-crud/internal/handler/cart"
 	CommentHandler "fiber-crud/internal/handler/comment"
+	healthHandler "fiber-crud/internal/handler/health"
 	paymentHandler "fiber-crud/internal/handler/payment"
+	privacyHandler "fiber-crud/internal/handler/privacy"
 	ProductHandler "fiber-crud/internal/handler/product"
 	userHandler "fiber-crud/internal/handler/user"
 	"fiber-crud/middleware"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// SetupHealthRoutes registers the unauthenticated probe endpoints.
+func SetupHealthRoutes(app *fiber.App, healthHandler *healthHandler.HealthHandler) {
+	app.Get("/healthz", healthHandler.Liveness)
+	app.Get("/readyz", healthHandler.Readiness)
+}
+
+// SetupMetricsRoutes exposes the Prometheus collectors for scraping. It is
+// registered on the separate metrics listener, never on the public app.
+func SetupMetricsRoutes(app *fiber.App) {
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+}
+
 func SetupUserRoutes(app *fiber.App, userHandler *userHandler.UserHandler) {
-	app.Get("/users", userHandler.GetUsers)
-	app.Get("/users/:id", userHandler.GetUserByID)
+	app.Get("/users", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersRead), userHandler.GetUsers)
+	app.Get("/users/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersRead), userHandler.GetUserByID)
 	app.Post("/users", userHandler.CreateUser)
-	app.Put("/users/:id", userHandler.UpdateUser)
-	app.Delete("/users/:id", userHandler.DeleteUser)
-	app.Get("/search", userHandler.SearchUsers)
+	app.Put("/users/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.UpdateUser)
+	app.Delete("/users/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.DeleteUser)
+	app.Post("/users/:id/unlock", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.UnlockAccount)
+	app.Delete("/users/:id/2fa", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.ResetTOTP)
+	app.Get("/search", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersRead), userHandler.SearchUsers)
 	app.Post("/login", userHandler.Login)
+	app.Post("/login/mfa", userHandler.VerifyMFA)
+	app.Post("/auth/2fa/enroll", middleware.AuthMiddleware(), middleware.RejectImpersonation(), userHandler.EnrollTOTP)
+	app.Post("/auth/2fa/confirm", middleware.AuthMiddleware(), middleware.RejectImpersonation(), userHandler.ConfirmTOTP)
+	app.Post("/auth/2fa/disable", middleware.AuthMiddleware(), middleware.RejectImpersonation(), userHandler.DisableTOTP)
+	app.Post("/auth/2fa/recovery-codes", middleware.AuthMiddleware(), middleware.RejectImpersonation(), userHandler.RegenerateRecoveryCodes)
+	app.Post("/auth/password/forgot", userHandler.RequestPasswordReset)
+	app.Post("/auth/password/reset", userHandler.ResetPassword)
+	app.Post("/auth/email/verify", userHandler.VerifyEmail)
+	app.Post("/auth/email/resend", middleware.AuthMiddleware(), middleware.RejectImpersonation(), userHandler.ResendVerificationEmail)
 	app.Get("/auth/me", middleware.AuthMiddleware(), userHandler.CurrentUser)
-	app.Get("/auth/google", userHandler.GoogleLogin)
-	app.Get("/auth/google/callback", userHandler.GoogleCallback)
+	app.Put("/auth/me", middleware.AuthMiddleware(), middleware.RejectImpersonation(), userHandler.UpdateCurrentUser)
+	app.Get("/auth/identities", middleware.AuthMiddleware(), userHandler.GetIdentities)
+	app.Delete("/auth/identities/:provider", middleware.AuthMiddleware(), middleware.RejectImpersonation(), userHandler.UnlinkIdentity)
+	app.Get("/auth/oauth/:provider", userHandler.OAuthLogin)
+	app.Get("/auth/oauth/:provider/callback", userHandler.OAuthCallback)
+	app.Get("/auth/oauth/:provider/link", middleware.AuthMiddleware(), middleware.RejectImpersonation(), userHandler.OAuthLink)
+}
+
+// SetupPrivacyRoutes registers data export and account erasure for the
+// signed-in user. Impersonating admins cannot use them.
+func SetupPrivacyRoutes(app *fiber.App, privacyHandler *privacyHandler.PrivacyHandler) {
+	app.Get("/auth/me/export", middleware.AuthMiddleware(), middleware.RejectImpersonation(), privacyHandler.Export)
+	app.Get("/auth/me/erasure", middleware.AuthMiddleware(), middleware.RejectImpersonation(), privacyHandler.GetErasure)
+	app.Post("/auth/me/erasure", middleware.AuthMiddleware(), middleware.RejectImpersonation(), privacyHandler.RequestErasure)
+	app.Delete("/auth/me/erasure", middleware.AuthMiddleware(), middleware.RejectImpersonation(), privacyHandler.CancelErasure)
+}
+
+// SetupAdminRoutes registers the /admin/users management surface.
+// Impersonation only accepts a JWT so an API key cannot be turned into a
+// user's token.
+func SetupAdminRoutes(app *fiber.App, userHandler *userHandler.UserHandler) {
+	admin := app.Group("/admin/users")
+	admin.Get("/", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersRead), userHandler.ListUsers)
+	admin.Get("/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersRead), userHandler.GetUserByID)
+	admin.Post("/:id/suspend", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.SuspendUser)
+	admin.Post("/:id/reactivate", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.ReactivateUser)
+	admin.Post("/:id/password-reset", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.ForcePasswordReset)
+	admin.Put("/:id/role", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.ChangeRole)
+	admin.Post("/:id/unlock", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.UnlockAccount)
+	admin.Delete("/:id/2fa", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.ResetTOTP)
+	admin.Post("/:id/restore", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermUsersWrite), userHandler.RestoreUser)
+	admin.Post("/:id/impersonate", middleware.AuthMiddleware(), middleware.CheckPermission(userModels.PermUsersImpersonate), userHandler.ImpersonateUser)
+}
+
+func SetupAuthRoutes(app *fiber.App, authHandler *authHandler.AuthHandler) {
+	app.Post("/auth/refresh", authHandler.Refresh)
+	app.Post("/auth/exchange", authHandler.Exchange)
+	app.Post("/auth/logout", middleware.AuthMiddleware(), middleware.RejectImpersonation(), authHandler.Logout)
+	app.Get("/auth/sessions", middleware.AuthMiddleware(), authHandler.GetSessions)
+	app.Delete("/auth/sessions/:id", middleware.AuthMiddleware(), middleware.RejectImpersonation(), authHandler.RevokeSession)
+	app.Get("/.well-known/jwks.json", authHandler.JWKS)
+}
+
+// SetupAPIKeyRoutes registers key management. It only accepts JWTs so a
+// leaked key cannot be used to mint more keys, and refuses impersonation
+// tokens.
+func SetupAPIKeyRoutes(app *fiber.App, apiKeyHandler *apiKeyHandler.APIKeyHandler) {
+	app.Get("/auth/api-keys", middleware.AuthMiddleware(), middleware.RejectImpersonation(), apiKeyHandler.List)
+	app.Post("/auth/api-keys", middleware.AuthMiddleware(), middleware.RejectImpersonation(), apiKeyHandler.Create)
+	app.Delete("/auth/api-keys/:id", middleware.AuthMiddleware(), middleware.RejectImpersonation(), apiKeyHandler.Revoke)
+}
+
+func SetupAuditRoutes(app *fiber.App, auditHandler *auditHandler.AuditHandler) {
+	app.Get("/admin/audit-logs", middleware.AuthMiddleware(), middleware.CheckPermission(userModels.PermAuditRead), auditHandler.GetAuditLogs)
 }
 
 func SetupProductRoutes(app *fiber.App, productHandler *ProductHandler.ProductHandler) {
-	app.Get("/products", middleware.AuthMiddleware(), productHandler.FindAll)
-	app.Get("/products/:id", middleware.AuthMiddleware(), productHandler.FindByID)
-	app.Post("/products", middleware.AuthMiddleware(), productHandler.Create)
-	app.Put("/products/:id", middleware.AuthMiddleware(), productHandler.Update)
-	app.Delete("/products/:id", middleware.AuthMiddleware(), productHandler.Delete)
-	app.Get("/all-products", middleware.AuthMiddleware(), productHandler.GetAllProduct)
+	app.Get("/products", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermProductsRead), productHandler.FindAll)
+	app.Get("/products/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermProductsRead), productHandler.FindByID)
+	app.Post("/products", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermProductsWrite), productHandler.Create)
+	app.Put("/products/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermProductsWrite), productHandler.Update)
+	app.Delete("/products/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermProductsWrite), productHandler.Delete)
+	app.Post("/admin/products/:id/restore", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermProductsWrite), productHandler.Restore)
+	app.Get("/all-products", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermProductsRead), productHandler.GetAllProduct)
 }
 
 func SetupComment(app *fiber.App, commentHandler *CommentHandler.CommentHandler) {
-	app.Post("/products/comments/:id", middleware.AuthMiddleware(), commentHandler.CreateCommentProductID)
-	app.Get("/products/comments/:id", middleware.AuthMiddleware(), commentHandler.GetCommentsByProductid)
+	app.Post("/products/comments/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermCommentsWrite), commentHandler.CreateCommentProductID)
+	app.Get("/products/comments/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermProductsRead), commentHandler.GetCommentsByProductid)
+	app.Delete("/comments/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermCommentsWrite), commentHandler.DeleteComment)
+	app.Post("/admin/comments/:id/restore", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermCommentsModerate), commentHandler.RestoreComment)
 }
 
 func SetupCart(app *fiber.App, cartHandler *handler.CartHandler) {
-	app.Post("/carts/:id", middleware.AuthMiddleware(), cartHandler.AddItemToCart)
-	app.Get("/carts", middleware.AuthMiddleware(), cartHandler.GetAllcartItems)
+	app.Post("/carts/:id", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermCartWrite), cartHandler.AddItemToCart)
+	app.Get("/carts", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermCartRead), cartHandler.GetAllcartItems)
 }
 
 func SetupPayment(app *fiber.App, paymentHandler *paymentHandler.PaymentHandler) {
-	app.Post("/payments", middleware.AuthMiddleware(), paymentHandler.CreatePayment)
+	app.Post("/payments", middleware.AuthMiddlewareWithAPIKey(), middleware.CheckPermission(userModels.PermPaymentsWrite), paymentHandler.CreatePayment)
 	app.Post("/payment/callback", paymentHandler.UpdatePaymentStatus)
 }
-
-// func SetupUserRoutes(app *fiber.App, userHandler *userHandler.UserHandler) {
-// 	app.Post("/login", userHandler.Login) // Rute login tidak memerlukan autentikasi
-
-// 	// Rute pengguna, hanya bisa diakses oleh admin
-// 	app.Get("/users", middleware.Authenticate, middleware.CheckRole("admin"), userHandler.GetUsers)
-// 	app.Get("/users/:id", middleware.Authenticate, middleware.CheckRole("admin"), userHandler.GetUserByID)
-// 	app.Post("/users", middleware.Authenticate, middleware.CheckRole("admin"), userHandler.CreateUser)
-// 	app.Put("/users/:id", middleware.Authenticate, middleware.CheckRole("admin"), userHandler.UpdateUser)
-// 	app.Delete("/users/:id", middleware.Authenticate, middleware.CheckRole("admin"), userHandler.DeleteUser)
-// 	app.Get("/search", middleware.Authenticate, middleware.CheckRole("admin"), userHandler.SearchUsers)
-// }
-
-// func SetupProductRoutes(app *fiber.App, productHandler *ProductHandler.ProductHandler) {
-// 	api := app.Group("/products", middleware.Authenticate) // Menambahkan middleware Authenticate ke semua rute produk
-
-// 	// Rute produk, bisa diakses oleh admin dan user
-// 	api.Get("/", middleware.CheckRole("admin", "user"), productHandler.FindAll)
-// 	api.Get("/:id", middleware.CheckRole("admin", "user"), productHandler.FindByID)
-// 	api.Post("/", middleware.CheckRole("admin"), productHandler.Create)
-// 	api.Put("/:id", middleware.CheckRole("admin"), productHandler.Update)
-// 	api.Delete("/:id", middleware.CheckRole("admin"), productHandler.Delete)
-// }
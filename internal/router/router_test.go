@@ -0,0 +1,232 @@
+package router_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	auditModels "fiber-crud/internal/domain/audit"
+	ProductModels "fiber-crud/internal/domain/product"
+	userModels "fiber-crud/internal/domain/user"
+	ProductHandler "fiber-crud/internal/handler/product"
+	userHandler "fiber-crud/internal/handler/user"
+	memoryRepository "fiber-crud/internal/repository/memory"
+	"fiber-crud/internal/router"
+	apiKeyUsecase "fiber-crud/internal/usecase/apikey"
+	productUsecase "fiber-crud/internal/usecase/product"
+	Userusecase "fiber-crud/internal/usecase/user"
+	"fiber-crud/middleware"
+	"fiber-crud/package/config"
+	"fiber-crud/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// stubUsers answers the user routes the guard tests reach. Anything else
+// panics through the nil embedded interface.
+type stubUsers struct {
+	Userusecase.UserUsecase
+	profiles map[uuid.UUID]userModels.User
+}
+
+func (s *stubUsers) GetUsers(context.Context) ([]userModels.User, error) { return nil, nil }
+
+func (s *stubUsers) ListUsers(context.Context, userModels.ListFilter) ([]userModels.User, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *stubUsers) UpdateProfile(_ context.Context, _ auditModels.Actor, userID uuid.UUID, name, email, avatar string) (userModels.User, error) {
+	user := userModels.User{ID: userID, Name: name, Email: email, Avatar: avatar}
+	s.profiles[userID] = user
+	return user, nil
+}
+
+type stubProducts struct {
+	productUsecase.ProductUsecase
+}
+
+func (stubProducts) GetProducts(context.Context, uuid.UUID) ([]ProductModels.Product, error) {
+	return nil, nil
+}
+func (stubProducts) DeleteProduct(context.Context, auditModels.Actor, uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
+func newApp(t *testing.T, users *stubUsers) *fiber.App {
+	t.Helper()
+	if err := utils.InitKeyRing(config.JWTConfig{KID: "test", Secret: strings.Repeat("k", 32), Audience: "fiber-crud"}); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(false)})
+	handler := userHandler.NewUserHandler(users, nil)
+	router.SetupUserRoutes(app, handler)
+	router.SetupAdminRoutes(app, handler)
+	router.SetupProductRoutes(app, ProductHandler.NewProductHandler(stubProducts{}))
+	return app
+}
+
+func bearer(t *testing.T, userID uuid.UUID, role string) string {
+	t.Helper()
+	token, err := utils.GenerateJWT(userID.String(), role, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+func TestRouteGuards(t *testing.T) {
+	users := &stubUsers{profiles: map[uuid.UUID]userModels.User{}}
+	app := newApp(t, users)
+	member := bearer(t, uuid.New(), userModels.RoleUser)
+	admin := bearer(t, uuid.New(), userModels.RoleAdmin)
+	impersonation, err := utils.GenerateImpersonationToken(uuid.NewString(), userModels.RoleUser, uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	impersonated := "Bearer " + impersonation
+	productPath := "/products/" + uuid.NewString()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		auth   string
+		want   int
+	}{
+		{"anonymous lists users", http.MethodGet, "/users", "", fiber.StatusUnauthorized},
+		{"user lists users", http.MethodGet, "/users", member, fiber.StatusForbidden},
+		{"admin lists users", http.MethodGet, "/users", admin, fiber.StatusOK},
+		{"user edits another user", http.MethodPut, "/users/" + uuid.NewString(), member, fiber.StatusForbidden},
+		{"anonymous edits own profile", http.MethodPut, "/auth/me", "", fiber.StatusUnauthorized},
+		{"user edits own profile", http.MethodPut, "/auth/me", member, fiber.StatusOK},
+		{"forged token", http.MethodGet, "/products", "Bearer not-a-token", fiber.StatusUnauthorized},
+		{"user lists products", http.MethodGet, "/products", member, fiber.StatusOK},
+		{"user deletes a product", http.MethodDelete, productPath, member, fiber.StatusForbidden},
+		{"admin deletes a product", http.MethodDelete, productPath, admin, fiber.StatusNoContent},
+		{"user lists admin users", http.MethodGet, "/admin/users", member, fiber.StatusForbidden},
+		{"admin lists admin users", http.MethodGet, "/admin/users", admin, fiber.StatusOK},
+		{"user impersonates", http.MethodPost, "/admin/users/" + uuid.NewString() + "/impersonate", member, fiber.StatusForbidden},
+		{"impersonator reads products", http.MethodGet, "/products", impersonated, fiber.StatusOK},
+		{"impersonator enrolls 2FA", http.MethodPost, "/auth/2fa/enroll", impersonated, fiber.StatusForbidden},
+		{"impersonator unlinks an identity", http.MethodDelete, "/auth/identities/google", impersonated, fiber.StatusForbidden},
+		{"impersonator edits the profile", http.MethodPut, "/auth/me", impersonated, fiber.StatusForbidden},
+		{"impersonator resends verification", http.MethodPost, "/auth/email/resend", impersonated, fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"name":"renamed"}`))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.auth != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.auth)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("%s %s = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateCurrentUserEditsOnlyTheCaller(t *testing.T) {
+	users := &stubUsers{profiles: map[uuid.UUID]userModels.User{}}
+	app := newApp(t, users)
+	userID := uuid.New()
+
+	req := httptest.NewRequest(http.MethodPut, "/auth/me", strings.NewReader(`{"name":"renamed","role":"admin"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderAuthorization, bearer(t, userID, userModels.RoleUser))
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("PUT /auth/me = %d, want 200", resp.StatusCode)
+	}
+	if got := users.profiles[userID]; got.Name != "renamed" || got.Role != "" {
+		t.Fatalf("profile update = %+v, want only the name of the caller changed", got)
+	}
+}
+
+func TestAPIKeyRouteGuards(t *testing.T) {
+	member := userModels.User{ID: uuid.New(), Role: userModels.RoleUser}
+	admin := userModels.User{ID: uuid.New(), Role: userModels.RoleAdmin}
+	demoted := userModels.User{ID: uuid.New(), Role: userModels.RoleAdmin}
+	userRepo := memoryRepository.NewUserRepository(member, admin, demoted)
+	keys := apiKeyUsecase.NewAPIKeyUsecase(memoryRepository.NewAPIKeyRepository(), userRepo)
+	middleware.SetAPIKeyAuthenticator(keys)
+	t.Cleanup(func() { middleware.SetAPIKeyAuthenticator(nil) })
+
+	app := newApp(t, &stubUsers{profiles: map[uuid.UUID]userModels.User{}})
+	newKey := func(user userModels.User, scopes ...string) string {
+		t.Helper()
+		created, err := keys.CreateAPIKey(context.Background(), user.ID, apiKeyUsecase.CreateAPIKeyInput{Name: "test", Scopes: scopes})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return created.Key
+	}
+	memberRead := newKey(member, userModels.PermProductsRead)
+	adminRead := newKey(admin, userModels.PermProductsRead)
+	adminUsers := newKey(admin, userModels.PermUsersRead)
+	demotedUsers := newKey(demoted, userModels.PermUsersRead)
+	revoked := newKey(admin, userModels.PermUsersRead)
+
+	demoted.Role = userModels.RoleUser
+	userRepo.Users[demoted.ID] = demoted
+	if _, err := keys.CreateAPIKey(context.Background(), member.ID, apiKeyUsecase.CreateAPIKeyInput{Name: "escalate", Scopes: []string{userModels.PermUsersRead}}); err != apiKeyUsecase.ErrInvalidScope {
+		t.Fatalf("member created a key with users:read: %v", err)
+	}
+	revokeKey(t, keys, admin.ID, revoked)
+
+	productPath := "/products/" + uuid.NewString()
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		key    string
+		want   int
+	}{
+		{"scoped key reads products", http.MethodGet, "/products", "X-API-Key", memberRead, fiber.StatusOK},
+		{"key as bearer token", http.MethodGet, "/products", fiber.HeaderAuthorization, "Bearer " + memberRead, fiber.StatusOK},
+		{"admin key outside its scopes", http.MethodDelete, productPath, "X-API-Key", adminRead, fiber.StatusForbidden},
+		{"admin key lists users", http.MethodGet, "/users", "X-API-Key", adminUsers, fiber.StatusOK},
+		{"key of a demoted admin", http.MethodGet, "/users", "X-API-Key", demotedUsers, fiber.StatusForbidden},
+		{"revoked key", http.MethodGet, "/users", "X-API-Key", revoked, fiber.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/products", "X-API-Key", "fc_unknown", fiber.StatusUnauthorized},
+		{"key on a login-only route", http.MethodPut, "/auth/me", fiber.HeaderAuthorization, "Bearer " + memberRead, fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"name":"renamed"}`))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			req.Header.Set(tt.header, tt.key)
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("%s %s = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+// revokeKey revokes the API key whose plaintext is key.
+func revokeKey(t *testing.T, keys apiKeyUsecase.APIKeyUsecase, userID uuid.UUID, key string) {
+	t.Helper()
+	principal, err := keys.AuthenticateAPIKey(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.RevokeAPIKey(context.Background(), userID, principal.KeyID); err != nil {
+		t.Fatal(err)
+	}
+}
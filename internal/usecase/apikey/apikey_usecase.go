@@ -0,0 +1,190 @@
+package apiKeyUsecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	apiKeyModels "fiber-crud/internal/domain/apikey"
+	"fiber-crud/internal/domain/apperror"
+	userModels "fiber-crud/internal/domain/user"
+	userRepository "fiber-crud/internal/repository"
+	apiKeyRepository "fiber-crud/internal/repository/apikey"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultExpiryDays = 90
+	MaxExpiryDays     = 365
+
+	// lastUsedInterval limits how often request traffic writes last_used_at.
+	lastUsedInterval = time.Minute
+	// displayPrefixLen is how much of the key is kept in clear so users can
+	// recognise it in listings.
+	displayPrefixLen = 8
+)
+
+var (
+	ErrNameRequired  = apperror.Invalid("api_key_name_required", "API key name cannot be empty")
+	ErrInvalidScope  = apperror.Invalid("invalid_scope", "invalid or unavailable scope")
+	ErrScopeRequired = apperror.Invalid("scope_required", "at least one scope is required")
+	ErrInvalidExpiry = apperror.Invalid("invalid_expiry", "expiry must be between 1 and 365 days")
+	ErrInvalidAPIKey = apiKeyModels.ErrInvalidAPIKey
+	ErrNotFound      = apperror.NotFound("api_key_not_found", "API key not found")
+)
+
+type CreateAPIKeyInput struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// CreatedAPIKey carries the plaintext key. It is only ever returned from
+// CreateAPIKey; afterwards only the hash is known.
+type CreatedAPIKey struct {
+	APIKey apiKeyModels.APIKey `json:"api_key"`
+	Key    string              `json:"key"`
+}
+
+type APIKeyUsecase interface {
+	CreateAPIKey(ctx context.Context, userID uuid.UUID, input CreateAPIKeyInput) (*CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]apiKeyModels.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*apiKeyModels.Principal, error)
+}
+
+type apiKeyUsecase struct {
+	apiKeyRepo apiKeyRepository.APIKeyRepository
+	userRepo   userRepository.UserRepository
+}
+
+func NewAPIKeyUsecase(apiKeyRepo apiKeyRepository.APIKeyRepository, userRepo userRepository.UserRepository) APIKeyUsecase {
+	return &apiKeyUsecase{apiKeyRepo: apiKeyRepo, userRepo: userRepo}
+}
+
+// CreateAPIKey issues a key limited to the requested scopes, each of which
+// must be a permission the user's role grants.
+func (u *apiKeyUsecase) CreateAPIKey(ctx context.Context, userID uuid.UUID, input CreateAPIKeyInput) (*CreatedAPIKey, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, ErrNameRequired
+	}
+	if len(input.Scopes) == 0 {
+		return nil, ErrScopeRequired
+	}
+
+	days := input.ExpiresInDays
+	if days == 0 {
+		days = DefaultExpiryDays
+	}
+	if days < 1 || days > MaxExpiryDays {
+		return nil, ErrInvalidExpiry
+	}
+
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == uuid.Nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	seen := make(map[string]bool, len(input.Scopes))
+	scopes := make([]string, 0, len(input.Scopes))
+	for _, scope := range input.Scopes {
+		if !userModels.HasPermission(user.Role, scope) {
+			return nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	secret, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	key := apiKeyModels.KeyPrefix + secret
+
+	expiresAt := time.Now().AddDate(0, 0, days)
+	record := apiKeyModels.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:len(apiKeyModels.KeyPrefix)+displayPrefixLen],
+		KeyHash:   utils.HashToken(key),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: &expiresAt,
+	}
+	if err := u.apiKeyRepo.Create(ctx, &record); err != nil {
+		return nil, err
+	}
+
+	return &CreatedAPIKey{APIKey: record, Key: key}, nil
+}
+
+func (u *apiKeyUsecase) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]apiKeyModels.APIKey, error) {
+	return u.apiKeyRepo.GetByUser(ctx, userID)
+}
+
+func (u *apiKeyUsecase) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	revoked, err := u.apiKeyRepo.Revoke(ctx, keyID, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey resolves a presented key to its owner. The owner's role
+// is read fresh on every call so a demotion takes effect immediately.
+func (u *apiKeyUsecase) AuthenticateAPIKey(ctx context.Context, key string) (*apiKeyModels.Principal, error) {
+	if !strings.HasPrefix(key, apiKeyModels.KeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	record, err := u.apiKeyRepo.GetByHash(ctx, utils.HashToken(key))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if record == nil || !record.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := u.userRepo.GetByID(ctx, record.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == uuid.Nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if user.Suspended() {
+		return nil, userModels.ErrSuspended
+	}
+
+	var scopes []string
+	for _, scope := range record.ScopeList() {
+		if userModels.HasPermission(user.Role, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) > lastUsedInterval {
+		if err := u.apiKeyRepo.TouchLastUsed(ctx, record.ID, now.Add(-lastUsedInterval)); err != nil {
+			log.Warn().Err(err).Msg("usecase::AuthenticateAPIKey - Error while updating key activity")
+		}
+	}
+
+	return &apiKeyModels.Principal{
+		KeyID:  record.ID,
+		UserID: user.ID,
+		Role:   user.Role,
+		Scopes: scopes,
+	}, nil
+}
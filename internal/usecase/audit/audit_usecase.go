@@ -0,0 +1,153 @@
+package auditUsecase
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"fiber-crud/internal/domain/apperror"
+	auditModels "fiber-crud/internal/domain/audit"
+	auditRepository "fiber-crud/internal/repository/audit"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+
+	redacted = "[REDACTED]"
+)
+
+var ErrInvalidFilter = apperror.Invalid("invalid_audit_filter", "invalid audit log filter")
+
+// redactedFields are recorded as changed without their values.
+var redactedFields = map[string]bool{
+	"password": true,
+}
+
+// personalFields are redacted like redactedFields, but only for the entity
+// types they identify a person in. The audit log is append-only, so an
+// erasure could never remove them once written.
+var personalFields = map[string]map[string]bool{
+	auditModels.EntityUser:    {"name": true, "email": true, "avatar": true},
+	auditModels.EntityComment: {"content": true},
+}
+
+type AuditUsecase interface {
+	// Record appends an entry describing the change from before to after.
+	// Either side may be nil for creations and deletions. Failures are
+	// logged rather than returned so an audit outage never undoes a change
+	// that was already committed.
+	Record(ctx context.Context, actor auditModels.Actor, action, entityType, entityID string, before, after interface{})
+	Find(ctx context.Context, filter auditModels.Filter) ([]auditModels.AuditLog, int64, error)
+}
+
+type auditUsecase struct {
+	auditRepo auditRepository.AuditRepository
+}
+
+func NewAuditUsecase(auditRepo auditRepository.AuditRepository) AuditUsecase {
+	return &auditUsecase{auditRepo: auditRepo}
+}
+
+func (u *auditUsecase) Record(ctx context.Context, actor auditModels.Actor, action, entityType, entityID string, before, after interface{}) {
+	changes, err := Diff(entityType, before, after)
+	if err != nil {
+		log.Error().Err(err).Str("entity", entityType).Str("entityID", entityID).Msg("usecase::Record - Error while computing audit diff")
+		changes = auditModels.Changes{}
+	}
+
+	entry := auditModels.AuditLog{
+		ActorID:        actor.UserID,
+		ImpersonatorID: actor.ImpersonatorID,
+		Action:         action,
+		EntityType:     entityType,
+		EntityID:       entityID,
+		Changes:        changes,
+		IP:             actor.IP,
+		RequestID:      actor.RequestID,
+	}
+	if err := u.auditRepo.Create(ctx, &entry); err != nil {
+		log.Error().Err(err).Str("action", action).Str("entity", entityType).Str("entityID", entityID).Msg("usecase::Record - Error while writing audit log")
+	}
+}
+
+func (u *auditUsecase) Find(ctx context.Context, filter auditModels.Filter) ([]auditModels.AuditLog, int64, error) {
+	if filter.Limit == 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit < 0 || filter.Limit > MaxPageSize || filter.Offset < 0 {
+		return nil, 0, ErrInvalidFilter
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, 0, ErrInvalidFilter
+	}
+	return u.auditRepo.Find(ctx, filter)
+}
+
+// Diff compares the JSON representation of two values field by field, so
+// anything hidden with json:"-" never reaches the audit log. Secrets and the
+// personal fields of entityType keep only the fact that they changed.
+func Diff(entityType string, before, after interface{}) (auditModels.Changes, error) {
+	beforeFields, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := auditModels.Changes{}
+	for field, value := range beforeFields {
+		next, ok := afterFields[field]
+		if ok && reflect.DeepEqual(value, next) {
+			continue
+		}
+		change := auditModels.Change{Before: value}
+		if ok {
+			change.After = next
+		}
+		changes[field] = change
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = auditModels.Change{After: value}
+		}
+	}
+
+	for field, change := range changes {
+		name := strings.ToLower(field)
+		if redactedFields[name] || personalFields[entityType][name] {
+			if change.Before != nil {
+				change.Before = redacted
+			}
+			if change.After != nil {
+				change.After = redacted
+			}
+			changes[field] = change
+		}
+	}
+	return changes, nil
+}
+
+func toFields(value interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
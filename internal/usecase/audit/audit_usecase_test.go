@@ -0,0 +1,92 @@
+package auditUsecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	auditModels "fiber-crud/internal/domain/audit"
+	memoryRepository "fiber-crud/internal/repository/memory"
+)
+
+type account struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Secret   string `json:"-"`
+}
+
+func TestDiffRecordsOnlyChangedFields(t *testing.T) {
+	before := account{Name: "alice", Email: "alice@example.com", Password: "old-hash", Secret: "a"}
+	after := account{Name: "alice", Email: "alice@example.org", Password: "new-hash", Secret: "b"}
+
+	changes, err := Diff(auditModels.EntityProduct, before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("changes = %+v, want email and password only", changes)
+	}
+	if got := changes["email"]; got.Before != "alice@example.com" || got.After != "alice@example.org" {
+		t.Fatalf("email change = %+v", got)
+	}
+	if got := changes["password"]; got.Before != redacted || got.After != redacted {
+		t.Fatalf("password change = %+v, want both sides redacted", got)
+	}
+}
+
+func TestDiffOfCreation(t *testing.T) {
+	changes, err := Diff(auditModels.EntityProduct, nil, &account{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := changes["name"]; got.Before != nil || got.After != "alice" {
+		t.Fatalf("name change = %+v, want only an after value", got)
+	}
+}
+
+func TestDiffRedactsPersonalFields(t *testing.T) {
+	before := map[string]interface{}{"name": "alice", "email": "alice@example.com", "role": "user"}
+	after := map[string]interface{}{"name": "erased", "email": "erased@invalid", "role": "admin"}
+
+	changes, err := Diff(auditModels.EntityUser, before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"name", "email"} {
+		if got := changes[field]; got.Before != redacted || got.After != redacted {
+			t.Errorf("%s = %+v, want both sides redacted", field, got)
+		}
+	}
+	if got := changes["role"]; got.Before != "user" || got.After != "admin" {
+		t.Errorf("role = %+v, want the values kept", got)
+	}
+}
+
+func TestRecordKeepsActor(t *testing.T) {
+	repo := memoryRepository.NewAuditRepository()
+	NewAuditUsecase(repo).Record(context.Background(), auditModels.Actor{IP: "203.0.113.7", RequestID: "req-1"}, auditModels.ActionCreate, auditModels.EntityProduct, "p-1", nil, account{Name: "x"})
+
+	if len(repo.Entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(repo.Entries))
+	}
+	entry := repo.Entries[0]
+	if entry.IP != "203.0.113.7" || entry.RequestID != "req-1" || entry.EntityID != "p-1" || entry.ActorID != nil {
+		t.Fatalf("entry = %+v", entry)
+	}
+}
+
+func TestFindRejectsInvalidFilter(t *testing.T) {
+	usecase := NewAuditUsecase(memoryRepository.NewAuditRepository())
+	now := time.Now()
+	for name, filter := range map[string]auditModels.Filter{
+		"negative limit":  {Limit: -1},
+		"limit too large": {Limit: MaxPageSize + 1},
+		"negative offset": {Offset: -1},
+		"empty range":     {From: &now, To: &now},
+	} {
+		if _, _, err := usecase.Find(context.Background(), filter); err != ErrInvalidFilter {
+			t.Errorf("%s: Find = %v, want ErrInvalidFilter", name, err)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package authUsecase
+
+import (
+	"context"
+	"time"
+
+	"fiber-crud/internal/domain/apperror"
+	authModels "fiber-crud/internal/domain/auth"
+	userModels "fiber-crud/internal/domain/user"
+	userRepository "fiber-crud/internal/repository"
+	authRepository "fiber-crud/internal/repository/auth"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+const exchangeCodeTTL = time.Minute
+
+var (
+	ErrInvalidRefreshToken = apperror.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	ErrRefreshTokenReused  = apperror.Unauthorized("refresh_token_reused", "refresh token reuse detected")
+	ErrInvalidExchangeCode = apperror.Unauthorized("invalid_exchange_code", "invalid or expired exchange code")
+	ErrSessionNotFound     = apperror.NotFound("session_not_found", "session not found")
+)
+
+// ClientInfo describes the device a login comes from and is recorded on the
+// session.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// LoginResult carries either a token pair or, when the account has a second
+// factor enrolled, the challenge token for the MFA step.
+type LoginResult struct {
+	*TokenPair
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+type AuthUsecase interface {
+	IssueTokens(ctx context.Context, user *userModels.User, client ClientInfo) (*TokenPair, error)
+	CompleteLogin(ctx context.Context, user *userModels.User, client ClientInfo) (*LoginResult, error)
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error)
+	Logout(ctx context.Context, claims *utils.Claims, refreshToken string) error
+	IsRevoked(ctx context.Context, claims *utils.Claims) (bool, error)
+	CreateExchangeCode(ctx context.Context, userID uuid.UUID) (string, error)
+	RedeemExchangeCode(ctx context.Context, code string, client ClientInfo) (*LoginResult, error)
+	GetSessions(ctx context.Context, userID uuid.UUID) ([]authModels.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
+}
+
+type authUsecase struct {
+	authRepo authRepository.AuthRepository
+	userRepo userRepository.UserRepository
+}
+
+func NewAuthUsecase(authRepo authRepository.AuthRepository, userRepo userRepository.UserRepository) AuthUsecase {
+	return &authUsecase{
+		authRepo: authRepo,
+		userRepo: userRepo,
+	}
+}
+
+// IssueTokens opens a new session for the user. The session ID is also the
+// family ID of the refresh tokens rotated from it.
+func (u *authUsecase) IssueTokens(ctx context.Context, user *userModels.User, client ClientInfo) (*TokenPair, error) {
+	if user.Suspended() {
+		return nil, userModels.ErrSuspended
+	}
+
+	session := &authModels.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(RefreshTokenTTL),
+	}
+	if err := u.authRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	refreshToken, _, err := u.newRefreshToken(ctx, user.ID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return u.pair(user, session.ID, refreshToken)
+}
+
+// CompleteLogin finishes the first login step: users with MFA enabled get a
+// challenge token instead of tokens.
+func (u *authUsecase) CompleteLogin(ctx context.Context, user *userModels.User, client ClientInfo) (*LoginResult, error) {
+	if user.Suspended() {
+		return nil, userModels.ErrSuspended
+	}
+
+	if user.MFAEnabled() {
+		challenge, err := utils.GenerateMFAChallenge(user.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFARequired: true, MFAToken: challenge}, nil
+	}
+
+	tokens, err := u.IssueTokens(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{TokenPair: tokens}, nil
+}
+
+func (u *authUsecase) newRefreshToken(ctx context.Context, userID, familyID uuid.UUID) (string, uuid.UUID, error) {
+	raw, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", uuid.Nil, err
+	}
+
+	token := &authModels.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}
+	if err := u.authRepo.CreateRefreshToken(ctx, token); err != nil {
+		return "", uuid.Nil, err
+	}
+	return raw, token.ID, nil
+}
+
+func (u *authUsecase) pair(user *userModels.User, sessionID uuid.UUID, refreshToken string) (*TokenPair, error) {
+	accessToken, err := utils.GenerateJWT(user.ID.String(), user.Role, sessionID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(utils.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new pair. Presenting a token that
+// was already rotated revokes every token in its family.
+func (u *authUsecase) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	stored, err := u.authRepo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, u.revokeReusedFamily(ctx, stored)
+	}
+
+	user, err := u.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == uuid.Nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if user.Suspended() {
+		return nil, userModels.ErrSuspended
+	}
+
+	raw, newID, err := u.newRefreshToken(ctx, stored.UserID, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := u.authRepo.RotateRefreshToken(ctx, stored.ID, newID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, u.revokeReusedFamily(ctx, stored)
+	}
+
+	expiresAt := time.Now().Add(RefreshTokenTTL)
+	if err := u.authRepo.TouchSession(ctx, stored.FamilyID, client.IP, &expiresAt); err != nil {
+		return nil, err
+	}
+
+	return u.pair(&user, stored.FamilyID, raw)
+}
+
+func (u *authUsecase) revokeReusedFamily(ctx context.Context, token *authModels.RefreshToken) error {
+	log.Warn().
+		Str("userID", token.UserID.String()).
+		Str("familyID", token.FamilyID.String()).
+		Msg("usecase::Refresh - refresh token reuse detected, revoking family")
+
+	if err := u.revokeFamily(ctx, token.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// revokeFamily ends a session: its refresh tokens stop rotating and access
+// tokens carrying its sid are rejected by AuthMiddleware.
+func (u *authUsecase) revokeFamily(ctx context.Context, sessionID uuid.UUID) error {
+	if err := u.authRepo.RevokeRefreshTokenFamily(ctx, sessionID); err != nil {
+		return err
+	}
+	return u.authRepo.RevokeSession(ctx, sessionID)
+}
+
+// Logout revokes the presented access token and ends its session. A refresh
+// token, when given, has its own session ended as well.
+func (u *authUsecase) Logout(ctx context.Context, claims *utils.Claims, refreshToken string) error {
+	if claims != nil && claims.ID != "" && claims.ExpiresAt != nil {
+		if err := u.authRepo.RevokeAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			return err
+		}
+	}
+
+	if claims != nil && claims.SessionID != "" {
+		if sessionID, err := uuid.Parse(claims.SessionID); err == nil {
+			if err := u.revokeFamily(ctx, sessionID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := u.authRepo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if stored == nil || (claims != nil && stored.UserID.String() != claims.Subject) {
+		return ErrInvalidRefreshToken
+	}
+	return u.revokeFamily(ctx, stored.FamilyID)
+}
+
+// sessionTouchInterval limits how often request traffic writes last_seen_at.
+const sessionTouchInterval = time.Minute
+
+// IsRevoked reports whether the access token itself or the session it
+// belongs to was revoked. Tokens without a sid predate sessions and are only
+// checked against the jti revocation list. Impersonation tokens have no
+// session either and stop working once the acting admin loses the right to
+// impersonate. Tokens of suspended users fail with userModels.ErrSuspended.
+func (u *authUsecase) IsRevoked(ctx context.Context, claims *utils.Claims) (bool, error) {
+	if claims.ID != "" {
+		revoked, err := u.authRepo.IsAccessTokenRevoked(ctx, claims.ID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return true, nil
+	}
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if user.ID == uuid.Nil {
+		return true, nil
+	}
+	if user.Suspended() {
+		return true, userModels.ErrSuspended
+	}
+
+	if claims.Actor != nil {
+		return u.impersonatorRevoked(ctx, claims.Actor.Subject)
+	}
+
+	if claims.SessionID == "" {
+		return false, nil
+	}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return true, nil
+	}
+
+	session, err := u.authRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	if session == nil || session.RevokedAt != nil {
+		return true, nil
+	}
+
+	if time.Since(session.LastSeenAt) > sessionTouchInterval {
+		if err := u.authRepo.TouchSession(ctx, session.ID, "", nil); err != nil {
+			log.Warn().Err(err).Msg("usecase::IsRevoked - Error while updating session activity")
+		}
+	}
+	return false, nil
+}
+
+// impersonatorRevoked reports whether the admin named in an impersonation
+// token was deleted, suspended or lost the users:impersonate permission.
+func (u *authUsecase) impersonatorRevoked(ctx context.Context, adminID string) (bool, error) {
+	id, err := uuid.Parse(adminID)
+	if err != nil {
+		return true, nil
+	}
+	admin, err := u.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if admin.ID == uuid.Nil || admin.Suspended() {
+		return true, nil
+	}
+	return !userModels.HasPermission(admin.Role, userModels.PermUsersImpersonate), nil
+}
+
+func (u *authUsecase) GetSessions(ctx context.Context, userID uuid.UUID) ([]authModels.Session, error) {
+	return u.authRepo.GetActiveSessions(ctx, userID)
+}
+
+// RevokeSession ends one of the user's own sessions.
+func (u *authUsecase) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session, err := u.authRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	return u.revokeFamily(ctx, sessionID)
+}
+
+// RevokeAllSessions signs the user out everywhere, e.g. after a password
+// reset.
+func (u *authUsecase) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := u.authRepo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+	return u.authRepo.RevokeUserSessions(ctx, userID)
+}
+
+// CreateExchangeCode returns a short-lived single-use code that the frontend
+// trades for tokens, so tokens never appear in a redirect URL.
+func (u *authUsecase) CreateExchangeCode(ctx context.Context, userID uuid.UUID) (string, error) {
+	raw, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	code := &authModels.ExchangeCode{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CodeHash:  utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(exchangeCodeTTL),
+	}
+	if err := u.authRepo.CreateExchangeCode(ctx, code); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func (u *authUsecase) RedeemExchangeCode(ctx context.Context, code string, client ClientInfo) (*LoginResult, error) {
+	if code == "" {
+		return nil, ErrInvalidExchangeCode
+	}
+
+	stored, err := u.authRepo.GetExchangeCodeByHash(ctx, utils.HashToken(code))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidExchangeCode
+	}
+
+	consumed, err := u.authRepo.ConsumeExchangeCode(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidExchangeCode
+	}
+
+	user, err := u.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == uuid.Nil {
+		return nil, ErrInvalidExchangeCode
+	}
+
+	return u.CompleteLogin(ctx, &user, client)
+}
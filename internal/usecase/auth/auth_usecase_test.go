@@ -0,0 +1,218 @@
+package authUsecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	userModels "fiber-crud/internal/domain/user"
+	memoryRepository "fiber-crud/internal/repository/memory"
+	"fiber-crud/package/config"
+	"fiber-crud/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func newTestUsecase(t *testing.T) (AuthUsecase, userModels.User) {
+	t.Helper()
+	if err := utils.InitKeyRing(config.JWTConfig{KID: "test", Secret: strings.Repeat("k", 32), Audience: "fiber-crud"}); err != nil {
+		t.Fatal(err)
+	}
+	user := userModels.User{ID: uuid.New(), Name: "alice", Email: "alice@example.com", Role: userModels.RoleUser}
+	return NewAuthUsecase(memoryRepository.NewAuthRepository(), memoryRepository.NewUserRepository(user)), user
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	usecase, user := newTestUsecase(t)
+
+	first, err := usecase.IssueTokens(context.Background(), &user, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := usecase.Refresh(context.Background(), first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("Refresh returned the presented refresh token")
+	}
+
+	if _, err := usecase.Refresh(context.Background(), first.RefreshToken, ClientInfo{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("replaying a rotated token = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := usecase.Refresh(context.Background(), second.RefreshToken, ClientInfo{}); err == nil {
+		t.Fatal("the family survived a replayed refresh token")
+	}
+}
+
+func TestLogoutRevokesAccessTokenAndFamily(t *testing.T) {
+	usecase, user := newTestUsecase(t)
+
+	pair, err := usecase.IssueTokens(context.Background(), &user, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := utils.ParseTokenString(pair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := usecase.Logout(context.Background(), claims, pair.RefreshToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if revoked, err := usecase.IsRevoked(context.Background(), claims); err != nil || !revoked {
+		t.Fatalf("IsRevoked after logout = %v, %v; want true", revoked, err)
+	}
+	if _, err := usecase.Refresh(context.Background(), pair.RefreshToken, ClientInfo{}); err == nil {
+		t.Fatal("refresh token still works after logout")
+	}
+}
+
+func TestLogoutRejectsAnotherUsersRefreshToken(t *testing.T) {
+	usecase, user := newTestUsecase(t)
+
+	pair, err := usecase.IssueTokens(context.Background(), &user, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := &utils.Claims{}
+	other.Subject = uuid.NewString()
+
+	if err := usecase.Logout(context.Background(), other, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("Logout with someone else's refresh token = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := usecase.Refresh(context.Background(), pair.RefreshToken, ClientInfo{}); err != nil {
+		t.Fatalf("the owner's refresh token was revoked: %v", err)
+	}
+}
+
+func TestExchangeCodeIsSingleUse(t *testing.T) {
+	usecase, user := newTestUsecase(t)
+
+	code, err := usecase.CreateExchangeCode(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := usecase.RedeemExchangeCode(context.Background(), code, ClientInfo{})
+	if err != nil {
+		t.Fatalf("RedeemExchangeCode: %v", err)
+	}
+	if pair.AccessToken == "" || pair.RefreshToken == "" {
+		t.Fatalf("token pair = %+v, want both tokens", pair)
+	}
+	if _, err := usecase.RedeemExchangeCode(context.Background(), code, ClientInfo{}); !errors.Is(err, ErrInvalidExchangeCode) {
+		t.Fatalf("second RedeemExchangeCode = %v, want ErrInvalidExchangeCode", err)
+	}
+}
+
+func TestRevokeSessionEndsOnlyThatSession(t *testing.T) {
+	usecase, user := newTestUsecase(t)
+
+	laptop, err := usecase.IssueTokens(context.Background(), &user, ClientInfo{IP: "203.0.113.1", UserAgent: "laptop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone, err := usecase.IssueTokens(context.Background(), &user, ClientInfo{IP: "203.0.113.2", UserAgent: "phone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	laptopClaims, err := utils.ParseTokenString(laptop.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phoneClaims, err := utils.ParseTokenString(phone.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	laptopSession := uuid.MustParse(laptopClaims.SessionID)
+
+	if err := usecase.RevokeSession(context.Background(), uuid.New(), laptopSession); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("revoking another user's session = %v, want ErrSessionNotFound", err)
+	}
+	if err := usecase.RevokeSession(context.Background(), user.ID, laptopSession); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+
+	if revoked, err := usecase.IsRevoked(context.Background(), laptopClaims); err != nil || !revoked {
+		t.Fatalf("IsRevoked for the revoked session = %v, %v; want true", revoked, err)
+	}
+	if _, err := usecase.Refresh(context.Background(), laptop.RefreshToken, ClientInfo{}); err == nil {
+		t.Fatal("refresh token of the revoked session still works")
+	}
+	if revoked, err := usecase.IsRevoked(context.Background(), phoneClaims); err != nil || revoked {
+		t.Fatalf("IsRevoked for the other session = %v, %v; want false", revoked, err)
+	}
+
+	sessions, err := usecase.GetSessions(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].UserAgent != "phone" {
+		t.Fatalf("active sessions = %+v, want only the phone", sessions)
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	usecase, user := newTestUsecase(t)
+
+	pair, err := usecase.IssueTokens(context.Background(), &user, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := utils.ParseTokenString(pair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := usecase.RevokeAllSessions(context.Background(), user.ID); err != nil {
+		t.Fatalf("RevokeAllSessions: %v", err)
+	}
+	if revoked, err := usecase.IsRevoked(context.Background(), claims); err != nil || !revoked {
+		t.Fatalf("IsRevoked after RevokeAllSessions = %v, %v; want true", revoked, err)
+	}
+	if sessions, _ := usecase.GetSessions(context.Background(), user.ID); len(sessions) != 0 {
+		t.Fatalf("active sessions = %+v, want none", sessions)
+	}
+}
+
+func TestIsRevokedFollowsImpersonatorPermission(t *testing.T) {
+	suspended := time.Now()
+	tests := []struct {
+		name    string
+		admin   func(userModels.User) userModels.User
+		revoked bool
+	}{
+		{"admin keeps the permission", func(u userModels.User) userModels.User { return u }, false},
+		{"admin demoted", func(u userModels.User) userModels.User { u.Role = userModels.RoleUser; return u }, true},
+		{"admin suspended", func(u userModels.User) userModels.User { u.SuspendedAt = &suspended; return u }, true},
+		{"admin deleted", func(userModels.User) userModels.User { return userModels.User{} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			admin := userModels.User{ID: uuid.New(), Name: "admin", Role: userModels.RoleAdmin}
+			target := userModels.User{ID: uuid.New(), Name: "target", Role: userModels.RoleUser}
+			users := memoryRepository.NewUserRepository(target)
+			if changed := tt.admin(admin); changed.ID != uuid.Nil {
+				users.Users[changed.ID] = changed
+			}
+			usecase := NewAuthUsecase(memoryRepository.NewAuthRepository(), users)
+
+			claims := &utils.Claims{
+				Role:             target.Role,
+				Actor:            &utils.ActorClaim{Subject: admin.ID.String()},
+				RegisteredClaims: jwt.RegisteredClaims{ID: uuid.NewString(), Subject: target.ID.String()},
+			}
+			revoked, err := usecase.IsRevoked(context.Background(), claims)
+			if err != nil {
+				t.Fatalf("IsRevoked: %v", err)
+			}
+			if revoked != tt.revoked {
+				t.Fatalf("IsRevoked = %v, want %v", revoked, tt.revoked)
+			}
+		})
+	}
+}
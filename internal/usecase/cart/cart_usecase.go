@@ -1,15 +1,17 @@
 package usecase
 
 import (
-	"errors"
+	"context"
+	"fiber-crud/internal/domain/apperror"
 	cartModels "fiber-crud/internal/domain/cart"
 	CartRepository "fiber-crud/internal/repository/cart"
 	ProductRepository "fiber-crud/internal/repository/product"
+	"fiber-crud/package/metrics"
 
 	"github.com/google/uuid"
 )
 
-var ErrNotFound = errors.New("product not found")
+var ErrNotFound = apperror.NotFound("product_not_found", "product not found")
 
 type cartUsecase struct {
 	cartRepository    CartRepository.CartRepository
@@ -17,17 +19,17 @@ type cartUsecase struct {
 }
 
 type CartUsecase interface {
-	AddItemToCart(userID uuid.UUID, productID uuid.UUID, quantity int) error
-	GetAllcartItems(userID uuid.UUID) ([]cartModels.CartModels, error)
+	AddItemToCart(ctx context.Context, userID uuid.UUID, productID uuid.UUID, quantity int) error
+	GetAllcartItems(ctx context.Context, userID uuid.UUID) ([]cartModels.CartModels, error)
 }
 
 func NewCartUsecase(cartRepo CartRepository.CartRepository, productRepo ProductRepository.ProductRepository) CartUsecase {
 	return &cartUsecase{cartRepo, productRepo}
 }
 
-func (u *cartUsecase) AddItemToCart(userID uuid.UUID, productID uuid.UUID, quantity int) error {
+func (u *cartUsecase) AddItemToCart(ctx context.Context, userID uuid.UUID, productID uuid.UUID, quantity int) error {
 	// Fetch product to check if it exists
-	product, err := u.productRepository.GetAllProductsByid(productID)
+	product, err := u.productRepository.GetAllProductsByid(ctx, productID)
 	if err != nil {
 		return err
 	}
@@ -35,7 +37,7 @@ func (u *cartUsecase) AddItemToCart(userID uuid.UUID, productID uuid.UUID, quant
 		return ErrNotFound
 	}
 
-	cartItem, err := u.cartRepository.GetCartItemByProductID(userID, productID)
+	cartItem, err := u.cartRepository.GetCartItemByProductID(ctx, userID, productID)
 	if err != nil && err != CartRepository.ErrNotFound {
 		return err
 	}
@@ -43,7 +45,7 @@ func (u *cartUsecase) AddItemToCart(userID uuid.UUID, productID uuid.UUID, quant
 	// If cart item exists, update its quantity
 	if cartItem.ID != uuid.Nil {
 		cartItem.Quantity += quantity
-		if err := u.cartRepository.UpdateCartItem(cartItem); err != nil {
+		if err := u.cartRepository.UpdateCartItem(ctx, cartItem); err != nil {
 			return err
 		}
 	} else {
@@ -53,19 +55,20 @@ func (u *cartUsecase) AddItemToCart(userID uuid.UUID, productID uuid.UUID, quant
 			ProductID: productID,
 			Quantity:  quantity,
 		}
-		if err := u.cartRepository.AddItemToCart(userID, productID, quantity); err != nil {
+		if err := u.cartRepository.AddItemToCart(ctx, userID, productID, quantity); err != nil {
 			return err
 		}
+		metrics.CartsCreated.Inc()
 	}
 
 	// Decrease stock after updating or adding the cart item
-	if err := u.productRepository.DecreaseStock(productID, quantity); err != nil {
+	if err := u.productRepository.DecreaseStock(ctx, productID, quantity); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (u *cartUsecase) GetAllcartItems(userID uuid.UUID) ([]cartModels.CartModels, error) {
-	return u.cartRepository.GetAllcartItems(userID)
+func (u *cartUsecase) GetAllcartItems(ctx context.Context, userID uuid.UUID) ([]cartModels.CartModels, error) {
+	return u.cartRepository.GetAllcartItems(ctx, userID)
 }
@@ -1,28 +1,73 @@
 package commentUsecase
 
 import (
+	"context"
+	"fiber-crud/internal/domain/apperror"
+	auditModels "fiber-crud/internal/domain/audit"
 	CommentModels "fiber-crud/internal/domain/comment"
 	repository "fiber-crud/internal/repository/comment"
+	auditUsecase "fiber-crud/internal/usecase/audit"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrNotFound  = apperror.NotFound("comment_not_found", "comment not found")
+	ErrForbidden = apperror.Forbidden("comment_forbidden", "cannot delete another user's comment")
+)
+
 type CommentUsecase interface {
-	CreateComment(comment *CommentModels.Comment) error
-	Getcommentproductid(ProductID uuid.UUID, UserID uuid.UUID) ([]CommentModels.Comment, error)
+	CreateComment(ctx context.Context, comment *CommentModels.Comment) error
+	Getcommentproductid(ctx context.Context, ProductID uuid.UUID, UserID uuid.UUID) ([]CommentModels.Comment, error)
+	DeleteComment(ctx context.Context, actor auditModels.Actor, id uuid.UUID, userID uuid.UUID, canModerate bool) error
+	RestoreComment(ctx context.Context, actor auditModels.Actor, id uuid.UUID) error
 }
 
 type commentUsecase struct {
 	commentRepository repository.CommentRepository
+	audit             auditUsecase.AuditUsecase
 }
 
-func NewCommentUsecase(repo repository.CommentRepository) CommentUsecase {
-	return &commentUsecase{commentRepository: repo}
+func NewCommentUsecase(repo repository.CommentRepository, audit auditUsecase.AuditUsecase) CommentUsecase {
+	return &commentUsecase{commentRepository: repo, audit: audit}
 }
 
-func (r *commentUsecase) CreateComment(comment *CommentModels.Comment) error {
-	return r.commentRepository.CreateComment(comment)
+func (r *commentUsecase) CreateComment(ctx context.Context, comment *CommentModels.Comment) error {
+	return r.commentRepository.CreateComment(ctx, comment)
 }
-func (r *commentUsecase) Getcommentproductid(ProductID uuid.UUID, UserID uuid.UUID) ([]CommentModels.Comment, error) {
-	return r.commentRepository.Getcommentproductid(ProductID, UserID)
+func (r *commentUsecase) Getcommentproductid(ctx context.Context, ProductID uuid.UUID, UserID uuid.UUID) ([]CommentModels.Comment, error) {
+	return r.commentRepository.Getcommentproductid(ctx, ProductID, UserID)
+}
+
+// DeleteComment soft-deletes a comment. Authors may delete their own;
+// moderators may delete any.
+func (r *commentUsecase) DeleteComment(ctx context.Context, actor auditModels.Actor, id uuid.UUID, userID uuid.UUID, canModerate bool) error {
+	comment, err := r.commentRepository.GetCommentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if comment.ID == uuid.Nil {
+		return ErrNotFound
+	}
+	if comment.UserID != userID && !canModerate {
+		return ErrForbidden
+	}
+
+	if err := r.commentRepository.DeleteComment(ctx, id); err != nil {
+		return err
+	}
+	r.audit.Record(ctx, actor, auditModels.ActionDelete, auditModels.EntityComment, id.String(), comment, nil)
+	return nil
+}
+
+func (r *commentUsecase) RestoreComment(ctx context.Context, actor auditModels.Actor, id uuid.UUID) error {
+	restored, err := r.commentRepository.RestoreComment(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrNotFound
+	}
+	r.audit.Record(ctx, actor, auditModels.ActionRestore, auditModels.EntityComment, id.String(), nil, nil)
+	return nil
 }
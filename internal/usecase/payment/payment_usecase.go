@@ -1,54 +1,101 @@
 package paymentUsecase
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fiber-crud/internal/domain/apperror"
+	auditModels "fiber-crud/internal/domain/audit"
 	paymentModels "fiber-crud/internal/domain/payment"
+	userRepository "fiber-crud/internal/repository"
 	cartRepository "fiber-crud/internal/repository/cart"
 	paymentRepository "fiber-crud/internal/repository/payment"
+	auditUsecase "fiber-crud/internal/usecase/audit"
+	"fiber-crud/package/config"
+	"fiber-crud/package/metrics"
+	"fiber-crud/package/tracing"
 	"fmt"
-	"os"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/veritrans/go-midtrans"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmailNotVerified = apperror.Forbidden("email_not_verified", "email address must be verified before checkout")
+	ErrEmptyCart        = apperror.Conflict("cart_empty", "no items in cart")
+	ErrInvalidOrderID   = apperror.Invalid("invalid_order_id", "orderID cannot be empty")
+	ErrPaymentNotFound  = apperror.NotFound("payment_not_found", "payment not found")
+	ErrPaymentGateway   = apperror.Upstream("payment_gateway_error", "payment gateway request failed")
 )
 
 type PaymentUsecase interface {
-	UpdatePaymentstatus(orderID uuid.UUID, status string) error
-	CreatePaymentMidtrans(userID uuid.UUID) (string, error)
+	UpdatePaymentstatus(ctx context.Context, actor auditModels.Actor, orderID uuid.UUID, status string) error
+	CreatePaymentMidtrans(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) (string, error)
 }
 
+// gatewayTimeout bounds one Midtrans request, matching the timeout of the
+// client the Midtrans library would use.
+const gatewayTimeout = 80 * time.Second
+
 type paymentUsecase struct {
 	paymentRepo paymentRepository.PaymentRepository
 	cartRepo    cartRepository.CartRepository
+	userRepo    userRepository.UserRepository
 	midtrans    midtrans.Client
-}
+	httpClient  *http.Client
+	snapURL     string
+	audit       auditUsecase.AuditUsecase
 
-func NewPaymentUsecase(paymentRepo paymentRepository.PaymentRepository, cartRepo cartRepository.CartRepository) PaymentUsecase {
-	midtransServerKey := os.Getenv("MIDTRANS_SERVER_KEY")
-	if midtransServerKey == "" {
-		panic("Midtrans server key not set in environment variables")
-	}
+	requireVerification bool
+}
 
+func NewPaymentUsecase(cfg config.MidtransConfig, requireVerification bool, paymentRepo paymentRepository.PaymentRepository, cartRepo cartRepository.CartRepository, userRepo userRepository.UserRepository, audit auditUsecase.AuditUsecase) PaymentUsecase {
 	midtransClient := midtrans.NewClient()
-	midtransClient.ServerKey = midtransServerKey
-	midtransClient.ClientKey = os.Getenv("MIDTRANS_CLIENT_KEY")
+	midtransClient.ServerKey = cfg.ServerKey
+	midtransClient.ClientKey = cfg.ClientKey
 	midtransClient.APIEnvType = midtrans.Sandbox
+	if cfg.Environment == "production" {
+		midtransClient.APIEnvType = midtrans.Production
+	}
 
 	return &paymentUsecase{
 		paymentRepo: paymentRepo,
 		cartRepo:    cartRepo,
+		userRepo:    userRepo,
 		midtrans:    midtransClient,
+		httpClient:  tracing.NewHTTPClient(gatewayTimeout),
+		snapURL:     midtransClient.APIEnvType.SnapURL(),
+		audit:       audit,
+
+		requireVerification: requireVerification,
 	}
 }
 
-func (p *paymentUsecase) CreatePaymentMidtrans(userID uuid.UUID) (string, error) {
-	carts, err := p.cartRepo.GetAllcartItems(userID)
+func (p *paymentUsecase) CreatePaymentMidtrans(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) (string, error) {
+	if p.requireVerification {
+		user, err := p.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		if !user.EmailVerified() {
+			return "", ErrEmailNotVerified
+		}
+	}
+
+	carts, err := p.cartRepo.GetAllcartItems(ctx, userID)
 	if err != nil {
 		return "", err
 	}
 
 	if len(carts) == 0 {
-		return "", errors.New("no items in cart")
+		return "", ErrEmptyCart
 	}
 
 	var total int
@@ -66,10 +113,11 @@ func (p *paymentUsecase) CreatePaymentMidtrans(userID uuid.UUID) (string, error)
 		Amount:  total,
 	}
 
-	err = p.paymentRepo.CreatePayment(payment)
+	err = p.paymentRepo.CreatePayment(ctx, payment)
 	if err != nil {
 		return "", err
 	}
+	p.audit.Record(ctx, actor, auditModels.ActionCreate, auditModels.EntityPayment, payment.ID.String(), nil, payment)
 
 	params := midtrans.SnapReq{
 		TransactionDetails: midtrans.TransactionDetails{
@@ -78,32 +126,87 @@ func (p *paymentUsecase) CreatePaymentMidtrans(userID uuid.UUID) (string, error)
 		},
 	}
 
-	snapGateway := midtrans.SnapGateway{Client: p.midtrans}
-	snapResp, err := snapGateway.GetToken(&params)
+	snapResp, err := p.getSnapToken(ctx, &params)
 	if err != nil {
-		return "", err
+		return "", ErrPaymentGateway.Wrap(err)
 	}
+	metrics.PaymentsCreated.Inc()
 
 	return snapResp.RedirectURL, nil
 }
 
-func (p *paymentUsecase) UpdatePaymentstatus(orderID uuid.UUID, status string) error {
+// getSnapToken does what SnapGateway.GetToken does, but sends the request
+// with ctx through a traced client, so the call is traced and cancelled
+// along with the checkout.
+func (p *paymentUsecase) getSnapToken(ctx context.Context, params *midtrans.SnapReq) (resp midtrans.SnapResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "midtrans.snap.GetToken",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("midtrans.order_id", params.TransactionDetails.OrderID)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	body, err := json.Marshal(params)
+	if err != nil {
+		return resp, err
+	}
+	req, err := p.midtrans.NewRequest(http.MethodPost, p.snapURL+"/snap/v1/transactions", bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+	res, err := p.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return resp, err
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return resp, fmt.Errorf("error decoding snap response (%s): %v", res.Status, err)
+	}
+	if len(resp.ErrorMessages) > 0 {
+		return resp, errors.New(strings.Join(resp.ErrorMessages, ", "))
+	}
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return resp, fmt.Errorf("snap returned %s", res.Status)
+	}
+	return resp, nil
+}
+
+func (p *paymentUsecase) UpdatePaymentstatus(ctx context.Context, actor auditModels.Actor, orderID uuid.UUID, status string) error {
 	if orderID == uuid.Nil {
-		return errors.New("orderID cannot be empty")
+		return ErrInvalidOrderID
 	}
 
 	payment := &paymentModels.PaymentModels{}
-	err := p.paymentRepo.GetPaymentByOrderID(orderID, payment)
-	if err != nil {
+	err := p.paymentRepo.GetPaymentByOrderID(ctx, orderID, payment)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPaymentNotFound
+	} else if err != nil {
 		return fmt.Errorf("failed to fetch payment: %v", err)
 	}
 
+	before := *payment
 	payment.Status = status
 
-	err = p.paymentRepo.UpdatePayment(payment)
+	err = p.paymentRepo.UpdatePayment(ctx, payment)
 	if err != nil {
 		return fmt.Errorf("failed to update payment status: %v", err)
 	}
+	p.audit.Record(ctx, actor, auditModels.ActionStatusChange, auditModels.EntityPayment, payment.ID.String(), before, payment)
+	if before.Status != status {
+		countStatusChange(status)
+	}
 
 	return nil
 }
+
+// countStatusChange counts payments reaching a final Midtrans transaction
+// status. It is only called when the status changed, so a repeated
+// notification from the gateway is not counted twice.
+func countStatusChange(status string) {
+	switch status {
+	case "settlement", "capture":
+		metrics.PaymentsSettled.Inc()
+	case "deny", "cancel", "expire", "failure":
+		metrics.PaymentsFailed.Inc()
+	}
+}
@@ -0,0 +1,75 @@
+package paymentUsecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/veritrans/go-midtrans"
+)
+
+// newSnapServer answers snap transaction requests with the given status and
+// body, and fails the test when a request lacks the server key or order.
+func newSnapServer(t *testing.T, status int, body string) *paymentUsecase {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/snap/v1/transactions" {
+			t.Errorf("request = %s %s, want POST /snap/v1/transactions", r.Method, r.URL.Path)
+		}
+		if user, _, ok := r.BasicAuth(); !ok || user != "server-key" {
+			t.Errorf("basic auth user = %q, want the server key", user)
+		}
+		var req midtrans.SnapReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TransactionDetails.OrderID != "order-1" {
+			t.Errorf("request body = %+v, %v; want order-1", req, err)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	client := midtrans.NewClient()
+	client.ServerKey = "server-key"
+	return &paymentUsecase{midtrans: client, httpClient: server.Client(), snapURL: server.URL}
+}
+
+func snapRequest() *midtrans.SnapReq {
+	return &midtrans.SnapReq{TransactionDetails: midtrans.TransactionDetails{OrderID: "order-1", GrossAmt: 100}}
+}
+
+func TestGetSnapTokenReturnsTheRedirect(t *testing.T) {
+	p := newSnapServer(t, http.StatusCreated, `{"token":"abc","redirect_url":"https://pay.example/abc"}`)
+
+	resp, err := p.getSnapToken(context.Background(), snapRequest())
+	if err != nil {
+		t.Fatalf("getSnapToken: %v", err)
+	}
+	if resp.Token != "abc" || resp.RedirectURL != "https://pay.example/abc" {
+		t.Fatalf("response = %+v, want the token and redirect", resp)
+	}
+}
+
+func TestGetSnapTokenReportsErrorMessages(t *testing.T) {
+	p := newSnapServer(t, http.StatusBadRequest, `{"error_messages":["gross_amount is required","order_id has already been taken"]}`)
+
+	_, err := p.getSnapToken(context.Background(), snapRequest())
+	if err == nil || !strings.Contains(err.Error(), "gross_amount is required, order_id has already been taken") {
+		t.Fatalf("error = %v, want the gateway's messages", err)
+	}
+}
+
+func TestGetSnapTokenFailsOnErrorStatus(t *testing.T) {
+	p := newSnapServer(t, http.StatusInternalServerError, `{}`)
+
+	if _, err := p.getSnapToken(context.Background(), snapRequest()); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("error = %v, want the 500 status", err)
+	}
+
+	p = newSnapServer(t, http.StatusBadGateway, `<html>bad gateway</html>`)
+	if _, err := p.getSnapToken(context.Background(), snapRequest()); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("error = %v, want the 502 status for a body that is not JSON", err)
+	}
+}
@@ -0,0 +1,118 @@
+package privacyUsecase
+
+import (
+	"context"
+	"time"
+
+	userModels "fiber-crud/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+// Export is everything stored about a user that they are entitled to
+// receive. Secrets such as the password hash and TOTP seed are omitted.
+type Export struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	Profile    ExportProfile   `json:"profile"`
+	Identities []ExportLink    `json:"linked_identities"`
+	Comments   []ExportComment `json:"comments"`
+	CartItems  []ExportCart    `json:"cart_items"`
+	Payments   []ExportPayment `json:"payments"`
+}
+
+type ExportProfile struct {
+	ID              uuid.UUID  `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Avatar          string     `json:"avatar"`
+	Role            string     `json:"role"`
+	TOTPEnabled     bool       `json:"two_factor_enabled"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type ExportLink struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"linked_at"`
+}
+
+type ExportComment struct {
+	ID        uuid.UUID `json:"id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportCart struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Price       float64   `json:"price"`
+	Quantity    int       `json:"quantity"`
+}
+
+type ExportPayment struct {
+	OrderID   string    `json:"order_id"`
+	Amount    int       `json:"amount"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (u *privacyUsecase) Export(ctx context.Context, userID uuid.UUID) (*Export, error) {
+	user, err := u.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := u.userRepo.GetIdentities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := u.privacyRepo.GetComments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cartItems, err := u.privacyRepo.GetCartItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := u.privacyRepo.GetPayments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &Export{
+		ExportedAt: time.Now().UTC(),
+		Profile:    exportProfile(user),
+		Identities: make([]ExportLink, len(identities)),
+		Comments:   make([]ExportComment, len(comments)),
+		CartItems:  make([]ExportCart, len(cartItems)),
+		Payments:   make([]ExportPayment, len(payments)),
+	}
+	for i, identity := range identities {
+		export.Identities[i] = ExportLink{Provider: identity.Provider, Email: identity.Email, CreatedAt: identity.CreatedAt}
+	}
+	for i, comment := range comments {
+		export.Comments[i] = ExportComment{ID: comment.ID, ProductID: comment.ProductID, Content: comment.Content, CreatedAt: comment.CreatedAt}
+	}
+	for i, item := range cartItems {
+		export.CartItems[i] = ExportCart{ProductID: item.ProductID, ProductName: item.Product.Name, Price: item.Product.Price, Quantity: item.Quantity}
+	}
+	for i, payment := range payments {
+		export.Payments[i] = ExportPayment{OrderID: payment.OrderID, Amount: payment.Amount, Status: payment.Status, CreatedAt: payment.CreatedAt}
+	}
+	return export, nil
+}
+
+func exportProfile(user userModels.User) ExportProfile {
+	return ExportProfile{
+		ID:              user.ID,
+		Name:            user.Name,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		Avatar:          user.Avatar,
+		Role:            user.Role,
+		TOTPEnabled:     user.MFAEnabled(),
+		CreatedAt:       user.CreatedAt,
+	}
+}
@@ -0,0 +1,186 @@
+package privacyUsecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fiber-crud/internal/domain/apperror"
+	auditModels "fiber-crud/internal/domain/audit"
+	privacyModels "fiber-crud/internal/domain/privacy"
+	userModels "fiber-crud/internal/domain/user"
+	userRepository "fiber-crud/internal/repository"
+	privacyRepository "fiber-crud/internal/repository/privacy"
+	auditUsecase "fiber-crud/internal/usecase/audit"
+	"fiber-crud/package/config"
+	"fiber-crud/package/mailer"
+	"fiber-crud/package/tracing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrNotFound         = apperror.NotFound("user_not_found", "user not found")
+	ErrErasurePending   = apperror.Conflict("erasure_pending", "an erasure request is already pending")
+	ErrNoPendingErasure = apperror.NotFound("no_pending_erasure", "no pending erasure request")
+)
+
+type PrivacyUsecase interface {
+	Export(ctx context.Context, userID uuid.UUID) (*Export, error)
+	GetErasure(ctx context.Context, userID uuid.UUID) (*privacyModels.ErasureRequest, error)
+	RequestErasure(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) (*privacyModels.ErasureRequest, error)
+	CancelErasure(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) error
+	// ExecuteDueErasures anonymizes every account whose cooling-off period
+	// has ended.
+	ExecuteDueErasures(ctx context.Context) error
+	// Run executes due erasures once immediately and then every interval
+	// until ctx is cancelled.
+	Run(ctx context.Context)
+}
+
+type privacyUsecase struct {
+	privacyRepo privacyRepository.PrivacyRepository
+	userRepo    userRepository.UserRepository
+	mailer      mailer.Mailer
+	audit       auditUsecase.AuditUsecase
+	coolingOff  time.Duration
+	interval    time.Duration
+}
+
+func NewPrivacyUsecase(cfg config.ErasureConfig, privacyRepo privacyRepository.PrivacyRepository, userRepo userRepository.UserRepository, mailer mailer.Mailer, audit auditUsecase.AuditUsecase) PrivacyUsecase {
+	return &privacyUsecase{
+		privacyRepo: privacyRepo,
+		userRepo:    userRepo,
+		mailer:      mailer,
+		audit:       audit,
+		coolingOff:  cfg.CoolingOff,
+		interval:    cfg.Interval,
+	}
+}
+
+func (u *privacyUsecase) getUser(ctx context.Context, userID uuid.UUID) (userModels.User, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return userModels.User{}, err
+	}
+	if user.ID == uuid.Nil {
+		return userModels.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (u *privacyUsecase) GetErasure(ctx context.Context, userID uuid.UUID) (*privacyModels.ErasureRequest, error) {
+	request, err := u.privacyRepo.GetPendingErasureRequest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, ErrNoPendingErasure
+	}
+	return request, nil
+}
+
+// RequestErasure schedules the account for anonymization after the
+// cooling-off period and tells the user how to cancel.
+func (u *privacyUsecase) RequestErasure(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) (*privacyModels.ErasureRequest, error) {
+	user, err := u.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := u.privacyRepo.GetPendingErasureRequest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return nil, ErrErasurePending
+	}
+
+	now := time.Now()
+	request := &privacyModels.ErasureRequest{
+		UserID:       userID,
+		RequestedAt:  now,
+		ScheduledFor: now.Add(u.coolingOff),
+	}
+	if err := u.privacyRepo.CreateErasureRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionRequestErasure, auditModels.EntityUser, userID.String(), nil, request)
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Your account is scheduled for deletion",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to delete your account. Your personal data will be erased on %s.\n\nIf you did not ask for this, sign in and cancel the request before then.\n",
+			user.Name, request.ScheduledFor.UTC().Format(time.RFC1123)),
+	}
+	if err := u.mailer.Send(msg); err != nil {
+		log.Warn().Err(err).Str("userID", userID.String()).Msg("usecase::RequestErasure - Confirmation email not sent")
+	}
+
+	return request, nil
+}
+
+func (u *privacyUsecase) CancelErasure(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) error {
+	pending, err := u.privacyRepo.GetPendingErasureRequest(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if pending == nil {
+		return ErrNoPendingErasure
+	}
+
+	cancelled, err := u.privacyRepo.CancelErasureRequest(ctx, pending.ID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return ErrNoPendingErasure
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionCancelErasure, auditModels.EntityUser, userID.String(), nil, nil)
+	return nil
+}
+
+// ExecuteDueErasures keeps going after a failed request so one bad row does
+// not block everyone else's erasure; the first error is returned.
+func (u *privacyUsecase) ExecuteDueErasures(ctx context.Context) error {
+	requests, err := u.privacyRepo.GetDueErasureRequests(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, request := range requests {
+		if err := u.privacyRepo.Anonymize(ctx, request); err != nil {
+			log.Error().Err(err).Str("requestID", request.ID.String()).Msg("usecase::ExecuteDueErasures - Error while anonymizing user")
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		// The diff is left empty so the audit log does not keep the data
+		// that was just erased.
+		u.audit.Record(ctx, auditModels.Actor{}, auditModels.ActionErase, auditModels.EntityUser, request.UserID.String(), nil, nil)
+	}
+	return firstErr
+}
+
+func (u *privacyUsecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+
+	for {
+		passCtx, span := tracing.Tracer().Start(ctx, "privacy.ExecuteDueErasures")
+		err := u.ExecuteDueErasures(passCtx)
+		tracing.End(span, err)
+		if err != nil {
+			log.Error().Err(err).Msg("usecase::Run - Error while executing erasures")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
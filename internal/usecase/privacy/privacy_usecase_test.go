@@ -0,0 +1,153 @@
+package privacyUsecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	auditModels "fiber-crud/internal/domain/audit"
+	privacyModels "fiber-crud/internal/domain/privacy"
+	userModels "fiber-crud/internal/domain/user"
+	memoryRepository "fiber-crud/internal/repository/memory"
+	privacyRepository "fiber-crud/internal/repository/privacy"
+	auditUsecase "fiber-crud/internal/usecase/audit"
+	"fiber-crud/package/config"
+	"fiber-crud/package/mailer"
+
+	"github.com/google/uuid"
+)
+
+// erasureRepository keeps erasure requests in memory. Anonymize fails for
+// the users in failFor.
+type erasureRepository struct {
+	privacyRepository.PrivacyRepository
+	requests   []*privacyModels.ErasureRequest
+	failFor    map[uuid.UUID]bool
+	anonymized []uuid.UUID
+}
+
+func (r *erasureRepository) CreateErasureRequest(_ context.Context, request *privacyModels.ErasureRequest) error {
+	request.ID = uuid.New()
+	r.requests = append(r.requests, request)
+	return nil
+}
+
+func (r *erasureRepository) GetPendingErasureRequest(_ context.Context, userID uuid.UUID) (*privacyModels.ErasureRequest, error) {
+	for _, request := range r.requests {
+		if request.UserID == userID && request.Pending() {
+			return request, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *erasureRepository) CancelErasureRequest(_ context.Context, id uuid.UUID) (bool, error) {
+	for _, request := range r.requests {
+		if request.ID == id && request.Pending() {
+			now := time.Now()
+			request.CancelledAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *erasureRepository) GetDueErasureRequests(_ context.Context, now time.Time) ([]privacyModels.ErasureRequest, error) {
+	var due []privacyModels.ErasureRequest
+	for _, request := range r.requests {
+		if request.Pending() && !request.ScheduledFor.After(now) {
+			due = append(due, *request)
+		}
+	}
+	return due, nil
+}
+
+func (r *erasureRepository) Anonymize(_ context.Context, request privacyModels.ErasureRequest) error {
+	if r.failFor[request.UserID] {
+		return errors.New("deadlock detected")
+	}
+	r.anonymized = append(r.anonymized, request.UserID)
+	return nil
+}
+
+type privacyFixture struct {
+	usecase PrivacyUsecase
+	repo    *erasureRepository
+	audit   *memoryRepository.AuditRepository
+	outbox  *mailer.OutboxMailer
+	user    userModels.User
+}
+
+func newPrivacyFixture(t *testing.T) *privacyFixture {
+	t.Helper()
+
+	outbox, err := mailer.NewOutboxMailer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := userModels.User{ID: uuid.New(), Name: "alice", Email: "alice@example.com"}
+	repo := &erasureRepository{failFor: map[uuid.UUID]bool{}}
+	audit := memoryRepository.NewAuditRepository()
+	return &privacyFixture{
+		usecase: NewPrivacyUsecase(config.ErasureConfig{CoolingOff: 72 * time.Hour, Interval: time.Hour}, repo, memoryRepository.NewUserRepository(user), outbox, auditUsecase.NewAuditUsecase(audit)),
+		repo:    repo,
+		audit:   audit,
+		outbox:  outbox,
+		user:    user,
+	}
+}
+
+func TestRequestAndCancelErasure(t *testing.T) {
+	f := newPrivacyFixture(t)
+	actor := auditModels.Actor{UserID: &f.user.ID}
+
+	request, err := f.usecase.RequestErasure(context.Background(), actor, f.user.ID)
+	if err != nil {
+		t.Fatalf("RequestErasure: %v", err)
+	}
+	if wait := request.ScheduledFor.Sub(request.RequestedAt); wait != 72*time.Hour {
+		t.Fatalf("erasure scheduled %v after the request, want the 72h cooling-off", wait)
+	}
+	if messages, _ := f.outbox.Messages(); len(messages) != 1 || messages[0].To != f.user.Email {
+		t.Fatalf("outbox = %+v, want one confirmation to the user", messages)
+	}
+	if _, err := f.usecase.RequestErasure(context.Background(), actor, f.user.ID); !errors.Is(err, ErrErasurePending) {
+		t.Fatalf("second RequestErasure = %v, want ErrErasurePending", err)
+	}
+
+	if err := f.usecase.CancelErasure(context.Background(), actor, f.user.ID); err != nil {
+		t.Fatalf("CancelErasure: %v", err)
+	}
+	if err := f.usecase.CancelErasure(context.Background(), actor, f.user.ID); !errors.Is(err, ErrNoPendingErasure) {
+		t.Fatalf("second CancelErasure = %v, want ErrNoPendingErasure", err)
+	}
+	if _, err := f.usecase.RequestErasure(context.Background(), actor, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("RequestErasure for an unknown user = %v, want ErrNotFound", err)
+	}
+}
+
+func TestExecuteDueErasuresContinuesPastFailures(t *testing.T) {
+	f := newPrivacyFixture(t)
+	past := time.Now().Add(-time.Minute)
+	failing, due, later := uuid.New(), uuid.New(), uuid.New()
+	f.repo.requests = []*privacyModels.ErasureRequest{
+		{ID: uuid.New(), UserID: failing, ScheduledFor: past},
+		{ID: uuid.New(), UserID: due, ScheduledFor: past},
+		{ID: uuid.New(), UserID: later, ScheduledFor: time.Now().Add(time.Hour)},
+	}
+	f.repo.failFor[failing] = true
+
+	if err := f.usecase.ExecuteDueErasures(context.Background()); err == nil {
+		t.Fatal("ExecuteDueErasures hid the failed erasure")
+	}
+	if len(f.repo.anonymized) != 1 || f.repo.anonymized[0] != due {
+		t.Fatalf("anonymized %v, want only the due request after the failing one", f.repo.anonymized)
+	}
+	if n := len(f.audit.Entries); n != 1 || f.audit.Entries[0].Action != auditModels.ActionErase || f.audit.Entries[0].EntityID != due.String() {
+		t.Fatalf("audit log = %+v, want one erase entry for the completed request", f.audit.Entries)
+	}
+	if len(f.audit.Entries[0].Changes) != 0 {
+		t.Fatalf("erase entry keeps changes %+v, want none", f.audit.Entries[0].Changes)
+	}
+}
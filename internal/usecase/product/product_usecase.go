@@ -1,50 +1,60 @@
 package productUsecase
 
 import (
-	"errors"
+	"context"
+	"fiber-crud/internal/domain/apperror"
+	auditModels "fiber-crud/internal/domain/audit"
 	ProductModels "fiber-crud/internal/domain/product"
 	ProductRepository "fiber-crud/internal/repository/product"
+	auditUsecase "fiber-crud/internal/usecase/audit"
 
 	"github.com/google/uuid"
 )
 
-var ErrNotFound = errors.New("product not found")
+var ErrNotFound = apperror.NotFound("product_not_found", "product not found")
 
 type ProductUsecase interface {
-	GetProducts(userID uuid.UUID) ([]ProductModels.Product, error)
-	GetProductByID(id uuid.UUID, userID uuid.UUID) (ProductModels.Product, error)
-	CreateProduct(product *ProductModels.Product) (*ProductModels.Product, error)
-	UpdateProduct(product *ProductModels.Product, userID uuid.UUID) error
-	DeleteProduct(id uuid.UUID, userID uuid.UUID) error
-	GetAllproducts() ([]ProductModels.Product, error)
+	GetProducts(ctx context.Context, userID uuid.UUID) ([]ProductModels.Product, error)
+	GetProductByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (ProductModels.Product, error)
+	CreateProduct(ctx context.Context, actor auditModels.Actor, product *ProductModels.Product) (*ProductModels.Product, error)
+	UpdateProduct(ctx context.Context, actor auditModels.Actor, product *ProductModels.Product, userID uuid.UUID) error
+	DeleteProduct(ctx context.Context, actor auditModels.Actor, id uuid.UUID, userID uuid.UUID) error
+	RestoreProduct(ctx context.Context, actor auditModels.Actor, id uuid.UUID) error
+	GetAllproducts(ctx context.Context) ([]ProductModels.Product, error)
 }
 
 type productUsecase struct {
 	productRepo ProductRepository.ProductRepository
+	audit       auditUsecase.AuditUsecase
 }
 
-func NewProductUsecase(repo ProductRepository.ProductRepository) ProductUsecase {
-	return &productUsecase{productRepo: repo}
+func NewProductUsecase(repo ProductRepository.ProductRepository, audit auditUsecase.AuditUsecase) ProductUsecase {
+	return &productUsecase{productRepo: repo, audit: audit}
 }
 
-func (u *productUsecase) GetProducts(userID uuid.UUID) ([]ProductModels.Product, error) {
-	return u.productRepo.GetProducts(userID)
+func (u *productUsecase) GetProducts(ctx context.Context, userID uuid.UUID) ([]ProductModels.Product, error) {
+	return u.productRepo.GetProducts(ctx, userID)
 }
 
-func (u *productUsecase) GetProductByID(id uuid.UUID, userID uuid.UUID) (ProductModels.Product, error) {
-	product, err := u.productRepo.GetProductByID(id, userID)
+func (u *productUsecase) GetProductByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (ProductModels.Product, error) {
+	product, err := u.productRepo.GetProductByID(ctx, id, userID)
 	if err != nil || product.ID == uuid.Nil {
 		return ProductModels.Product{}, ErrNotFound
 	}
 	return product, nil
 }
 
-func (u *productUsecase) CreateProduct(product *ProductModels.Product) (*ProductModels.Product, error) {
-	return u.productRepo.CreateProduct(product)
+func (u *productUsecase) CreateProduct(ctx context.Context, actor auditModels.Actor, product *ProductModels.Product) (*ProductModels.Product, error) {
+	res, err := u.productRepo.CreateProduct(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionCreate, auditModels.EntityProduct, res.ID.String(), nil, res)
+	return res, nil
 }
 
-func (u *productUsecase) UpdateProduct(product *ProductModels.Product, userID uuid.UUID) error {
-	existingProduct, err := u.productRepo.GetProductByID(product.ID, userID)
+func (u *productUsecase) UpdateProduct(ctx context.Context, actor auditModels.Actor, product *ProductModels.Product, userID uuid.UUID) error {
+	existingProduct, err := u.productRepo.GetProductByID(ctx, product.ID, userID)
 	if err != nil {
 		return err
 	}
@@ -52,20 +62,40 @@ func (u *productUsecase) UpdateProduct(product *ProductModels.Product, userID uu
 		return ErrNotFound
 	}
 	product.UserID = userID
-	return u.productRepo.UpdateProduct(product)
+	if err := u.productRepo.UpdateProduct(ctx, product); err != nil {
+		return err
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionUpdate, auditModels.EntityProduct, product.ID.String(), existingProduct, product)
+	return nil
 }
 
-func (u *productUsecase) DeleteProduct(id uuid.UUID, userID uuid.UUID) error {
-	existingProduct, err := u.productRepo.GetProductByID(id, userID)
+func (u *productUsecase) DeleteProduct(ctx context.Context, actor auditModels.Actor, id uuid.UUID, userID uuid.UUID) error {
+	existingProduct, err := u.productRepo.GetProductByID(ctx, id, userID)
 	if err != nil {
 		return err
 	}
 	if existingProduct.ID == uuid.Nil {
 		return ErrNotFound
 	}
-	return u.productRepo.DeleteProduct(id, userID)
+	if err := u.productRepo.DeleteProduct(ctx, id, userID); err != nil {
+		return err
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionDelete, auditModels.EntityProduct, id.String(), existingProduct, nil)
+	return nil
+}
+
+func (u *productUsecase) RestoreProduct(ctx context.Context, actor auditModels.Actor, id uuid.UUID) error {
+	restored, err := u.productRepo.RestoreProduct(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrNotFound
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionRestore, auditModels.EntityProduct, id.String(), nil, nil)
+	return nil
 }
 
-func (u *productUsecase) GetAllproducts() ([]ProductModels.Product, error) {
-	return u.productRepo.GetAllProducts()
+func (u *productUsecase) GetAllproducts(ctx context.Context) ([]ProductModels.Product, error) {
+	return u.productRepo.GetAllProducts(ctx)
 }
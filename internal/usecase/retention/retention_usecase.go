@@ -0,0 +1,78 @@
+package retentionUsecase
+
+import (
+	"context"
+	"time"
+
+	authRepository "fiber-crud/internal/repository/auth"
+	retentionRepository "fiber-crud/internal/repository/retention"
+	"fiber-crud/package/config"
+	"fiber-crud/package/tracing"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Purger removes soft-deleted rows once their retention period has passed,
+// and expired tokens and sessions along the way.
+type Purger interface {
+	Purge(ctx context.Context) error
+	// Run purges once immediately and then every interval until ctx is
+	// cancelled.
+	Run(ctx context.Context)
+}
+
+type purger struct {
+	retentionRepo retentionRepository.RetentionRepository
+	authRepo      authRepository.AuthRepository
+	retention     time.Duration
+	interval      time.Duration
+}
+
+func NewPurger(cfg config.RetentionConfig, retentionRepo retentionRepository.RetentionRepository, authRepo authRepository.AuthRepository) Purger {
+	return &purger{
+		retentionRepo: retentionRepo,
+		authRepo:      authRepo,
+		retention:     cfg.SoftDelete,
+		interval:      cfg.PurgeInterval,
+	}
+}
+
+func (p *purger) Purge(ctx context.Context) error {
+	now := time.Now()
+
+	result, err := p.retentionRepo.PurgeDeleted(ctx, now.Add(-p.retention))
+	if err != nil {
+		return err
+	}
+	if result.Users+result.Products+result.Comments > 0 {
+		log.Info().
+			Int64("users", result.Users).
+			Int64("products", result.Products).
+			Int64("comments", result.Comments).
+			Msg("usecase::Purge - Purged soft-deleted rows")
+	}
+
+	return p.authRepo.DeleteExpired(ctx, now)
+}
+
+func (p *purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		// Each pass gets its own trace so its queries are grouped under
+		// one span.
+		passCtx, span := tracing.Tracer().Start(ctx, "retention.Purge")
+		err := p.Purge(passCtx)
+		tracing.End(span, err)
+		if err != nil {
+			log.Error().Err(err).Msg("usecase::Run - Error while purging")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
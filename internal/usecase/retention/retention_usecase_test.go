@@ -0,0 +1,44 @@
+package retentionUsecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	memoryRepository "fiber-crud/internal/repository/memory"
+	retentionRepository "fiber-crud/internal/repository/retention"
+	"fiber-crud/package/config"
+)
+
+type recordingRetention struct {
+	before []time.Time
+}
+
+func (r *recordingRetention) PurgeDeleted(_ context.Context, before time.Time) (retentionRepository.PurgeResult, error) {
+	r.before = append(r.before, before)
+	return retentionRepository.PurgeResult{}, nil
+}
+
+func TestPurgeKeepsRowsInsideTheRetentionPeriod(t *testing.T) {
+	retention := &recordingRetention{}
+	auth := memoryRepository.NewAuthRepository()
+	auth.RevokedTokens["expired"] = time.Now().Add(-time.Minute)
+	auth.RevokedTokens["live"] = time.Now().Add(time.Minute)
+
+	if err := NewPurger(config.RetentionConfig{SoftDelete: 48 * time.Hour, PurgeInterval: time.Hour}, retention, auth).Purge(context.Background()); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+
+	if len(retention.before) != 1 {
+		t.Fatalf("PurgeDeleted called %d times, want 1", len(retention.before))
+	}
+	if age := time.Since(retention.before[0]); age < 48*time.Hour || age > 48*time.Hour+time.Minute {
+		t.Fatalf("purged rows deleted before %v ago, want 48h", age)
+	}
+	if _, ok := auth.RevokedTokens["expired"]; ok {
+		t.Fatal("expired revocation entry was kept")
+	}
+	if _, ok := auth.RevokedTokens["live"]; !ok {
+		t.Fatal("revocation entry of an unexpired token was purged")
+	}
+}
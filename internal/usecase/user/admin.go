@@ -0,0 +1,190 @@
+package Userusecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"fiber-crud/internal/domain/apperror"
+	auditModels "fiber-crud/internal/domain/audit"
+	userModels "fiber-crud/internal/domain/user"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var (
+	ErrInvalidRole        = apperror.Invalid("invalid_role", "unknown role")
+	ErrInvalidUserFilter  = apperror.Invalid("invalid_user_filter", "invalid user filter")
+	ErrSelfAdministration = apperror.Forbidden("self_administration", "admins cannot apply this action to their own account")
+	ErrAlreadySuspended   = apperror.Conflict("already_suspended", "account is already suspended")
+	ErrNotSuspended       = apperror.Conflict("not_suspended", "account is not suspended")
+	ErrCannotImpersonate  = apperror.Forbidden("cannot_impersonate", "this account cannot be impersonated")
+	ErrUserErased         = apperror.Conflict("user_erased", "account was erased and cannot be restored")
+)
+
+// Impersonation is an access token for the target user that carries the
+// acting admin in its "act" claim.
+type Impersonation struct {
+	AccessToken string `json:"token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (u *userUsecase) ListUsers(ctx context.Context, filter userModels.ListFilter) ([]userModels.User, int64, error) {
+	if filter.Page == 0 {
+		filter.Page = 1
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Page < 1 || filter.Limit < 1 || filter.Limit > maxPageSize {
+		return nil, 0, ErrInvalidUserFilter
+	}
+	if filter.Role != "" && !userModels.ValidRole(filter.Role) {
+		return nil, 0, ErrInvalidUserFilter
+	}
+	switch filter.Status {
+	case "", userModels.StatusActive, userModels.StatusSuspended, userModels.StatusDeleted:
+	default:
+		return nil, 0, ErrInvalidUserFilter
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+	return u.userRepo.List(ctx, filter)
+}
+
+// SuspendUser blocks the account from signing in and signs it out
+// everywhere. AuthMiddleware rejects its remaining access tokens and API
+// keys.
+func (u *userUsecase) SuspendUser(ctx context.Context, actor auditModels.Actor, userID uuid.UUID, reason string) error {
+	if isSelf(actor, userID) {
+		return ErrSelfAdministration
+	}
+	user, err := u.getExisting(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Suspended() {
+		return ErrAlreadySuspended
+	}
+
+	before := user
+	now := time.Now()
+	user.SuspendedAt = &now
+	user.SuspendedReason = strings.TrimSpace(reason)
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	if err := u.authUsecase.RevokeAllSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	u.audit.Record(ctx, actor, auditModels.ActionSuspend, auditModels.EntityUser, user.ID.String(), before, user)
+	return nil
+}
+
+func (u *userUsecase) ReactivateUser(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) error {
+	user, err := u.getExisting(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.Suspended() {
+		return ErrNotSuspended
+	}
+
+	before := user
+	user.SuspendedAt = nil
+	user.SuspendedReason = ""
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	u.audit.Record(ctx, actor, auditModels.ActionReactivate, auditModels.EntityUser, user.ID.String(), before, user)
+	return nil
+}
+
+// ForcePasswordReset signs the user out, refuses password logins until a new
+// password is chosen and emails a reset link.
+func (u *userUsecase) ForcePasswordReset(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) error {
+	user, err := u.getExisting(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	before := user
+	user.PasswordResetRequired = true
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	if err := u.authUsecase.RevokeAllSessions(ctx, user.ID); err != nil {
+		return err
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionForceReset, auditModels.EntityUser, user.ID.String(), before, user)
+
+	return u.sendPasswordReset(ctx, &user)
+}
+
+// ChangeRole assigns a new role and ends the user's sessions so tokens
+// carrying the old role stop working.
+func (u *userUsecase) ChangeRole(ctx context.Context, actor auditModels.Actor, userID uuid.UUID, role string) error {
+	if !userModels.ValidRole(role) {
+		return ErrInvalidRole
+	}
+	if isSelf(actor, userID) {
+		return ErrSelfAdministration
+	}
+	user, err := u.getExisting(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Role == role {
+		return nil
+	}
+
+	before := user
+	user.Role = role
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	if err := u.authUsecase.RevokeAllSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	u.audit.Record(ctx, actor, auditModels.ActionChangeRole, auditModels.EntityUser, user.ID.String(), before, user)
+	return nil
+}
+
+// Impersonate lets an admin act as another user for support. Admins and
+// suspended accounts cannot be impersonated, and every token issued is
+// audited.
+func (u *userUsecase) Impersonate(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) (*Impersonation, error) {
+	if actor.UserID == nil || isSelf(actor, userID) {
+		return nil, ErrCannotImpersonate
+	}
+	user, err := u.getExisting(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == userModels.RoleAdmin || user.Suspended() {
+		return nil, ErrCannotImpersonate
+	}
+
+	token, err := utils.GenerateImpersonationToken(user.ID.String(), user.Role, actor.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	u.audit.Record(ctx, actor, auditModels.ActionImpersonate, auditModels.EntityUser, user.ID.String(), nil, nil)
+	return &Impersonation{
+		AccessToken: token,
+		ExpiresIn:   int(utils.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func isSelf(actor auditModels.Actor, userID uuid.UUID) bool {
+	return actor.UserID != nil && *actor.UserID == userID
+}
@@ -0,0 +1,103 @@
+package Userusecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	auditModels "fiber-crud/internal/domain/audit"
+	userModels "fiber-crud/internal/domain/user"
+	authUsecase "fiber-crud/internal/usecase/auth"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+)
+
+func adminActor() auditModels.Actor {
+	id := uuid.New()
+	return auditModels.Actor{UserID: &id}
+}
+
+func TestSuspendUserEndsSessions(t *testing.T) {
+	f := newResetFixture(t, nil)
+	auth := authUsecase.NewAuthUsecase(f.auth, f.users)
+	pair, err := auth.IssueTokens(context.Background(), &f.user, authUsecase.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := utils.ParseTokenString(pair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actor := adminActor()
+	if err := f.usecase.SuspendUser(context.Background(), actor, f.user.ID, "spam"); err != nil {
+		t.Fatalf("SuspendUser: %v", err)
+	}
+	if _, err := auth.IsRevoked(context.Background(), claims); !errors.Is(err, userModels.ErrSuspended) {
+		t.Fatalf("IsRevoked for a suspended user = %v, want ErrSuspended", err)
+	}
+	if _, err := auth.Refresh(context.Background(), pair.RefreshToken, authUsecase.ClientInfo{}); err == nil {
+		t.Fatal("refresh token survived the suspension")
+	}
+	if err := f.usecase.SuspendUser(context.Background(), actor, f.user.ID, "again"); !errors.Is(err, ErrAlreadySuspended) {
+		t.Fatalf("second SuspendUser = %v, want ErrAlreadySuspended", err)
+	}
+	if err := f.usecase.SuspendUser(context.Background(), auditModels.Actor{UserID: &f.user.ID}, f.user.ID, ""); !errors.Is(err, ErrSelfAdministration) {
+		t.Fatalf("suspending oneself = %v, want ErrSelfAdministration", err)
+	}
+
+	if err := f.usecase.ReactivateUser(context.Background(), actor, f.user.ID); err != nil {
+		t.Fatalf("ReactivateUser: %v", err)
+	}
+	if f.users.Users[f.user.ID].Suspended() {
+		t.Fatal("user still suspended after ReactivateUser")
+	}
+	if got := len(f.audit.Entries); got != 2 || f.audit.Entries[0].Action != auditModels.ActionSuspend || *f.audit.Entries[0].ActorID != *actor.UserID {
+		t.Fatalf("audit log = %+v, want suspend and reactivate by the admin", f.audit.Entries)
+	}
+}
+
+func TestChangeRole(t *testing.T) {
+	f := newResetFixture(t, nil)
+
+	if err := f.usecase.ChangeRole(context.Background(), adminActor(), f.user.ID, "superuser"); !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("ChangeRole to an unknown role = %v, want ErrInvalidRole", err)
+	}
+	if err := f.usecase.ChangeRole(context.Background(), auditModels.Actor{UserID: &f.user.ID}, f.user.ID, userModels.RoleAdmin); !errors.Is(err, ErrSelfAdministration) {
+		t.Fatalf("changing one's own role = %v, want ErrSelfAdministration", err)
+	}
+	if err := f.usecase.ChangeRole(context.Background(), adminActor(), f.user.ID, userModels.RoleAdmin); err != nil {
+		t.Fatalf("ChangeRole: %v", err)
+	}
+	if got := f.users.Users[f.user.ID].Role; got != userModels.RoleAdmin {
+		t.Fatalf("role = %q, want admin", got)
+	}
+}
+
+func TestImpersonate(t *testing.T) {
+	f := newResetFixture(t, nil)
+	actor := adminActor()
+
+	impersonation, err := f.usecase.Impersonate(context.Background(), actor, f.user.ID)
+	if err != nil {
+		t.Fatalf("Impersonate: %v", err)
+	}
+	claims, err := utils.ParseTokenString(impersonation.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != f.user.ID.String() || claims.Actor == nil || claims.Actor.Subject != actor.UserID.String() || claims.SessionID != "" {
+		t.Fatalf("impersonation claims = %+v, want the user as subject and the admin as actor without a session", claims)
+	}
+	if len(f.audit.Entries) != 1 || f.audit.Entries[0].Action != auditModels.ActionImpersonate {
+		t.Fatalf("audit log = %+v, want the impersonation recorded", f.audit.Entries)
+	}
+
+	admin := f.users.Users[f.user.ID]
+	admin.Role = userModels.RoleAdmin
+	f.users.Users[f.user.ID] = admin
+	if _, err := f.usecase.Impersonate(context.Background(), actor, f.user.ID); !errors.Is(err, ErrCannotImpersonate) {
+		t.Fatalf("impersonating an admin = %v, want ErrCannotImpersonate", err)
+	}
+}
@@ -0,0 +1,101 @@
+package Userusecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	authModels "fiber-crud/internal/domain/auth"
+	userModels "fiber-crud/internal/domain/user"
+	"fiber-crud/package/mailer"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+// SendVerificationEmail issues a fresh verification link for the user's
+// current address, invalidating any earlier link.
+func (u *userUsecase) SendVerificationEmail(ctx context.Context, userID uuid.UUID) error {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.ID == uuid.Nil {
+		return ErrNotFound
+	}
+	if user.EmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+	return u.sendVerificationEmail(ctx, &user)
+}
+
+func (u *userUsecase) sendVerificationEmail(ctx context.Context, user *userModels.User) error {
+	raw, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	if err := u.authRepo.InvalidateEmailVerificationTokens(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token := &authModels.EmailVerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	}
+	if err := u.authRepo.CreateEmailVerificationToken(ctx, token); err != nil {
+		return err
+	}
+
+	link := u.account.EmailVerifyURL + "?token=" + url.QueryEscape(raw)
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm this email address by opening the link below. It expires in %d hours.\n\n%s\n",
+			user.Name, int(emailVerificationTTL.Hours()), link),
+	}
+	if err := u.mailer.Send(msg); err != nil {
+		log.Error().Err(err).Str("userID", user.ID.String()).Msg("usecase::sendVerificationEmail - Error while sending verification email")
+		return err
+	}
+	return nil
+}
+
+// VerifyEmail consumes a verification token and marks the address it was
+// issued for as verified, provided the user still uses that address.
+func (u *userUsecase) VerifyEmail(ctx context.Context, token string) error {
+	stored, err := u.authRepo.GetEmailVerificationTokenByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	user, err := u.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return err
+	}
+	if user.ID == uuid.Nil || user.Email != stored.Email {
+		return ErrInvalidVerificationToken
+	}
+
+	consumed, err := u.authRepo.ConsumeEmailVerificationToken(ctx, stored.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidVerificationToken
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	return u.userRepo.Update(ctx, user)
+}
@@ -0,0 +1,67 @@
+package Userusecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+// verificationToken returns the token from the last email in the outbox.
+func (f *resetFixture) verificationToken(t *testing.T) string {
+	t.Helper()
+
+	messages, err := f.outbox.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) == 0 {
+		t.Fatal("no verification email in the outbox")
+	}
+	match := resetLink.FindStringSubmatch(messages[len(messages)-1].Body)
+	if match == nil {
+		t.Fatal("no verification link in the last email")
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifyEmail(t *testing.T) {
+	f := newResetFixture(t, nil)
+	if err := f.usecase.SendVerificationEmail(context.Background(), f.user.ID); err != nil {
+		t.Fatalf("SendVerificationEmail: %v", err)
+	}
+	token := f.verificationToken(t)
+
+	if err := f.usecase.VerifyEmail(context.Background(), token); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if !f.users.Users[f.user.ID].EmailVerified() {
+		t.Fatal("address not marked as verified")
+	}
+	if err := f.usecase.VerifyEmail(context.Background(), token); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Fatalf("second VerifyEmail = %v, want ErrInvalidVerificationToken", err)
+	}
+}
+
+func TestVerifyEmailRejectsTokenForPreviousAddress(t *testing.T) {
+	f := newResetFixture(t, nil)
+	if err := f.usecase.SendVerificationEmail(context.Background(), f.user.ID); err != nil {
+		t.Fatalf("SendVerificationEmail: %v", err)
+	}
+	token := f.verificationToken(t)
+
+	changed := f.users.Users[f.user.ID]
+	changed.Email = "alice@example.org"
+	f.users.Users[f.user.ID] = changed
+
+	if err := f.usecase.VerifyEmail(context.Background(), token); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Fatalf("VerifyEmail after an address change = %v, want ErrInvalidVerificationToken", err)
+	}
+	if f.users.Users[f.user.ID].EmailVerified() {
+		t.Fatal("new address verified with a token sent to the old one")
+	}
+}
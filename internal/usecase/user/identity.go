@@ -0,0 +1,164 @@
+package Userusecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	userModels "fiber-crud/internal/domain/user"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+)
+
+// LoginWithIdentity resolves an external identity to a local account. An
+// existing account is only linked by email when the provider vouches for the
+// address and the local account has verified it too; otherwise anyone able
+// to register the address with a provider could take the account over. Every
+// account needs a unique email, so an unlinked identity without one is
+// refused; it can still be linked to a signed-in account.
+func (u *userUsecase) LoginWithIdentity(ctx context.Context, identity utils.ExternalIdentity) (*userModels.User, error) {
+	user, err := u.userRepo.FindByIdentity(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil && user.ID != uuid.Nil {
+		return user, nil
+	}
+
+	if identity.Email == "" {
+		return nil, ErrIdentityEmailRequired
+	}
+
+	user, err = u.userRepo.GetByEmail(ctx, identity.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if user != nil && user.ID != uuid.Nil {
+		if !identity.EmailVerified || !user.EmailVerified() {
+			return nil, ErrEmailNotVerified
+		}
+	} else {
+		user, err = u.createFromIdentity(ctx, identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := u.userRepo.CreateIdentity(ctx, &userModels.Identity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *userUsecase) createFromIdentity(ctx context.Context, identity utils.ExternalIdentity) (*userModels.User, error) {
+	// The address may belong to a soft-deleted account awaiting purge.
+	emailTaken, err := u.userRepo.IsEmailTaken(ctx, identity.Email)
+	if err != nil {
+		return nil, err
+	}
+	if emailTaken {
+		return nil, ErrEmailTaken
+	}
+
+	name, err := u.availableName(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	user := userModels.User{
+		Name:   name,
+		Email:  identity.Email,
+		Avatar: identity.Avatar,
+		Role:   userModels.RoleUser,
+	}
+	if identity.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	return u.userRepo.Create(ctx, user)
+}
+
+// availableName picks a unique username for an account created from an
+// external identity, suffixing the provider name when it is already taken.
+func (u *userUsecase) availableName(ctx context.Context, identity utils.ExternalIdentity) (string, error) {
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	if name == "" {
+		name = identity.Provider + "-user"
+	}
+
+	candidate := name
+	for i := 0; i < 5; i++ {
+		taken, err := u.userRepo.IsUsernameTaken(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = name + "-" + uuid.NewString()[:8]
+	}
+	return candidate, nil
+}
+
+func (u *userUsecase) LinkIdentity(ctx context.Context, userID uuid.UUID, identity utils.ExternalIdentity) error {
+	owner, err := u.userRepo.FindByIdentity(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return err
+	}
+	if owner != nil && owner.ID != uuid.Nil {
+		if owner.ID == userID {
+			return nil
+		}
+		return ErrIdentityLinked
+	}
+
+	return u.userRepo.CreateIdentity(ctx, &userModels.Identity{
+		UserID:   userID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+}
+
+// UnlinkIdentity removes a provider link unless it is the only remaining way
+// for the user to sign in.
+func (u *userUsecase) UnlinkIdentity(ctx context.Context, userID uuid.UUID, provider string) error {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.ID == uuid.Nil {
+		return ErrNotFound
+	}
+
+	identities, err := u.userRepo.GetIdentities(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Password == "" && len(identities) <= 1 {
+		return ErrLastLoginMethod
+	}
+
+	deleted, err := u.userRepo.DeleteIdentity(ctx, userID, provider)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
+
+func (u *userUsecase) GetIdentities(ctx context.Context, userID uuid.UUID) ([]userModels.Identity, error) {
+	return u.userRepo.GetIdentities(ctx, userID)
+}
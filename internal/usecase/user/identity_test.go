@@ -0,0 +1,89 @@
+package Userusecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	userModels "fiber-crud/internal/domain/user"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+)
+
+// An existing account is only linked to an identity when both the provider
+// and the account have verified the address, so registering the address
+// with a provider is not enough to take the account over.
+func TestLoginWithIdentityLinksOnlyVerifiedAddresses(t *testing.T) {
+	verifiedAt := time.Now()
+	tests := []struct {
+		name             string
+		localVerified    bool
+		providerVerified bool
+		wantErr          error
+	}{
+		{"provider does not vouch", true, false, ErrEmailNotVerified},
+		{"account not verified", false, true, ErrEmailNotVerified},
+		{"both verified", true, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newResetFixture(t, nil)
+			existing := f.users.Users[f.user.ID]
+			if tt.localVerified {
+				existing.EmailVerifiedAt = &verifiedAt
+			}
+			f.users.Users[existing.ID] = existing
+
+			user, err := f.usecase.LoginWithIdentity(context.Background(), utils.ExternalIdentity{
+				Provider:      "google",
+				Subject:       "google-sub",
+				Email:         existing.Email,
+				EmailVerified: tt.providerVerified,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoginWithIdentity = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && len(f.users.Identities) != 0 {
+				t.Fatalf("identity linked to the existing account: %+v", f.users.Identities)
+			}
+			if tt.wantErr == nil && (user.ID != existing.ID || len(f.users.Identities) != 1 || f.users.Identities[0].UserID != existing.ID) {
+				t.Fatalf("signed in as %+v with identities %+v, want the existing account linked", user, f.users.Identities)
+			}
+		})
+	}
+}
+
+func TestLoginWithIdentityCreatesAccountForNewAddress(t *testing.T) {
+	f := newResetFixture(t, nil)
+
+	user, err := f.usecase.LoginWithIdentity(context.Background(), utils.ExternalIdentity{
+		Provider:      "github",
+		Subject:       "42",
+		Email:         "bob@example.com",
+		EmailVerified: true,
+		Name:          "alice",
+	})
+	if err != nil {
+		t.Fatalf("LoginWithIdentity: %v", err)
+	}
+	if user.ID == uuid.Nil || user.ID == f.user.ID || !user.EmailVerified() || user.Role != userModels.RoleUser {
+		t.Fatalf("new account = %+v, want a verified user account", user)
+	}
+	if user.Name == f.user.Name {
+		t.Fatalf("new account reused the taken username %q", user.Name)
+	}
+}
+
+func TestLoginWithIdentityRequiresEmail(t *testing.T) {
+	f := newResetFixture(t, nil)
+
+	_, err := f.usecase.LoginWithIdentity(context.Background(), utils.ExternalIdentity{Provider: "github", Subject: "42", Name: "bob"})
+	if !errors.Is(err, ErrIdentityEmailRequired) {
+		t.Fatalf("LoginWithIdentity = %v, want ErrIdentityEmailRequired", err)
+	}
+	if len(f.users.Users) != 1 {
+		t.Fatalf("account created without an email: %+v", f.users.Users)
+	}
+}
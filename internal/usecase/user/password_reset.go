@@ -0,0 +1,116 @@
+package Userusecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	authModels "fiber-crud/internal/domain/auth"
+	userModels "fiber-crud/internal/domain/user"
+	"fiber-crud/package/mailer"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const passwordResetTTL = time.Hour
+
+const minPasswordLength = 8
+
+// RequestPasswordReset emails a reset link when the address belongs to an
+// account. It succeeds silently for unknown addresses, and for known ones
+// whose email could not be sent, so the endpoint cannot be used to discover
+// registered emails.
+func (u *userUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := u.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.ID == uuid.Nil {
+		return nil
+	}
+
+	if err := u.sendPasswordReset(ctx, user); err != nil {
+		log.Error().Err(err).Str("userID", user.ID.String()).Msg("usecase::RequestPasswordReset - Error while sending reset link")
+	}
+	return nil
+}
+
+// sendPasswordReset replaces any outstanding reset tokens with a new one and
+// emails the link.
+func (u *userUsecase) sendPasswordReset(ctx context.Context, user *userModels.User) error {
+	raw, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	if err := u.authRepo.InvalidatePasswordResetTokens(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token := &authModels.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+	if err := u.authRepo.CreatePasswordResetToken(ctx, token); err != nil {
+		return err
+	}
+
+	link := u.account.PasswordResetURL + "?token=" + url.QueryEscape(raw)
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %d minutes and can only be used once.\n\n%s\n\nIf you did not request this, you can ignore this email.\n",
+			user.Name, int(passwordResetTTL.Minutes()), link),
+	}
+	return u.mailer.Send(msg)
+}
+
+// ResetPassword consumes a reset token, stores the new password and signs the
+// user out of every existing session.
+func (u *userUsecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if len(newPassword) < minPasswordLength {
+		return ErrWeakPassword
+	}
+
+	stored, err := u.authRepo.GetPasswordResetTokenByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	consumed, err := u.authRepo.ConsumePasswordResetToken(ctx, stored.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+
+	user, err := u.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return err
+	}
+	if user.ID == uuid.Nil {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+	user.PasswordResetRequired = false
+
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return u.authUsecase.RevokeAllSessions(ctx, user.ID)
+}
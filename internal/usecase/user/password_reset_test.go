@@ -0,0 +1,170 @@
+package Userusecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	userModels "fiber-crud/internal/domain/user"
+	memoryRepository "fiber-crud/internal/repository/memory"
+	auditUsecase "fiber-crud/internal/usecase/audit"
+	authUsecase "fiber-crud/internal/usecase/auth"
+	"fiber-crud/package/config"
+	"fiber-crud/package/mailer"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+)
+
+var resetLink = regexp.MustCompile(`token=(\S+)`)
+
+type failingMailer struct{}
+
+func (failingMailer) Send(mailer.Message) error {
+	return errors.New("smtp: connection refused")
+}
+
+type resetFixture struct {
+	usecase UserUsecase
+	users   *memoryRepository.UserRepository
+	auth    *memoryRepository.AuthRepository
+	audit   *memoryRepository.AuditRepository
+	outbox  *mailer.OutboxMailer
+	user    userModels.User
+}
+
+func newResetFixture(t *testing.T, mail mailer.Mailer) *resetFixture {
+	t.Helper()
+	if err := utils.InitKeyRing(config.JWTConfig{KID: "test", Secret: strings.Repeat("k", 32), Audience: "fiber-crud"}); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := HashPassword("old-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := userModels.User{ID: uuid.New(), Name: "alice", Email: "alice@example.com", Password: hash}
+
+	outbox, err := mailer.NewOutboxMailer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mail == nil {
+		mail = outbox
+	}
+
+	users := memoryRepository.NewUserRepository(user)
+	auth := memoryRepository.NewAuthRepository()
+	audit := memoryRepository.NewAuditRepository()
+	return &resetFixture{
+		usecase: NewUserUsecase(users, auth, authUsecase.NewAuthUsecase(auth, users), mail, auditUsecase.NewAuditUsecase(audit), config.Default().Account),
+		users:   users,
+		auth:    auth,
+		audit:   audit,
+		outbox:  outbox,
+		user:    user,
+	}
+}
+
+// requestToken asks for a reset link and returns the token from the last
+// email in the outbox.
+func (f *resetFixture) requestToken(t *testing.T) string {
+	t.Helper()
+
+	if err := f.usecase.RequestPasswordReset(context.Background(), f.user.Email); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	messages, err := f.outbox.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) == 0 {
+		t.Fatal("no reset email in the outbox")
+	}
+	last := messages[len(messages)-1]
+	if last.To != f.user.Email {
+		t.Fatalf("reset email sent to %q, want %q", last.To, f.user.Email)
+	}
+	match := resetLink.FindStringSubmatch(last.Body)
+	if match == nil {
+		t.Fatalf("no reset link in %q", last.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestResetPasswordTokenIsSingleUse(t *testing.T) {
+	f := newResetFixture(t, nil)
+	token := f.requestToken(t)
+
+	if err := f.usecase.ResetPassword(context.Background(), token, "new-password-1"); err != nil {
+		t.Fatalf("first ResetPassword: %v", err)
+	}
+	if err := f.usecase.ResetPassword(context.Background(), token, "new-password-2"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("second ResetPassword = %v, want ErrInvalidResetToken", err)
+	}
+	if !ComparePassword(f.users.Users[f.user.ID].Password, "new-password-1") {
+		t.Fatal("password is not the one set with the first use of the token")
+	}
+}
+
+func TestResetPasswordNewRequestInvalidatesOlderToken(t *testing.T) {
+	f := newResetFixture(t, nil)
+	older := f.requestToken(t)
+	newer := f.requestToken(t)
+
+	if err := f.usecase.ResetPassword(context.Background(), older, "new-password"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("ResetPassword with replaced token = %v, want ErrInvalidResetToken", err)
+	}
+	if err := f.usecase.ResetPassword(context.Background(), newer, "new-password"); err != nil {
+		t.Fatalf("ResetPassword with latest token: %v", err)
+	}
+}
+
+func TestResetPasswordRejectsExpiredToken(t *testing.T) {
+	f := newResetFixture(t, nil)
+	token := f.requestToken(t)
+	for _, stored := range f.auth.ResetTokens {
+		stored.ExpiresAt = time.Now().Add(-time.Second)
+	}
+
+	if err := f.usecase.ResetPassword(context.Background(), token, "new-password"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("ResetPassword = %v, want ErrInvalidResetToken", err)
+	}
+	if f.users.Users[f.user.ID].Password != f.user.Password {
+		t.Fatal("password changed with an expired token")
+	}
+}
+
+func TestResetPasswordSignsOutEverywhere(t *testing.T) {
+	f := newResetFixture(t, nil)
+	pair, err := authUsecase.NewAuthUsecase(f.auth, f.users).IssueTokens(context.Background(), &f.user, authUsecase.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := f.requestToken(t)
+	if err := f.usecase.ResetPassword(context.Background(), token, "new-password"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if _, err := authUsecase.NewAuthUsecase(f.auth, f.users).Refresh(context.Background(), pair.RefreshToken, authUsecase.ClientInfo{}); err == nil {
+		t.Fatal("refresh token issued before the reset still works")
+	}
+}
+
+func TestRequestPasswordResetHidesDeliveryFailure(t *testing.T) {
+	f := newResetFixture(t, failingMailer{})
+
+	known := f.usecase.RequestPasswordReset(context.Background(), f.user.Email)
+	unknown := f.usecase.RequestPasswordReset(context.Background(), "nobody@example.com")
+	if known != nil || unknown != nil {
+		t.Fatalf("RequestPasswordReset = %v (registered), %v (unknown); want nil for both", known, unknown)
+	}
+}
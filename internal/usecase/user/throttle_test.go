@@ -0,0 +1,100 @@
+package Userusecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"fiber-crud/internal/domain/apperror"
+	auditModels "fiber-crud/internal/domain/audit"
+	authUsecase "fiber-crud/internal/usecase/auth"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testClient = authUsecase.ClientInfo{IP: "203.0.113.7"}
+
+func TestDummyPasswordHashCostsLikeARealOne(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Fatalf("dummy hash cost = %d, want %d as used by HashPassword", cost, bcrypt.DefaultCost)
+	}
+}
+
+func TestLoginLocksAccountAfterRepeatedFailures(t *testing.T) {
+	f := newResetFixture(t, nil)
+
+	for i := 0; i < freeAccountFailures; i++ {
+		if _, err := f.usecase.Login(context.Background(), f.user.Email, "wrong", testClient); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failure %d = %v, want ErrInvalidCredentials", i+1, err)
+		}
+	}
+	if _, err := f.usecase.Login(context.Background(), f.user.Email, "wrong", testClient); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("failure over the limit = %v, want ErrInvalidCredentials", err)
+	}
+
+	_, err := f.usecase.Login(context.Background(), f.user.Email, "old-password", testClient)
+	var locked *apperror.Error
+	if !errors.As(err, &locked) || !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("login while locked = %v, want ErrAccountLocked", err)
+	}
+	if locked.RetryAfter <= 0 || locked.RetryAfter > baseLockout {
+		t.Fatalf("RetryAfter = %v, want at most %v for the first lockout", locked.RetryAfter, baseLockout)
+	}
+
+	if err := f.usecase.UnlockAccount(context.Background(), auditModels.Actor{}, f.user.ID); err != nil {
+		t.Fatalf("UnlockAccount: %v", err)
+	}
+	if n := len(f.audit.Entries); n != 1 || f.audit.Entries[0].Action != auditModels.ActionUnlock {
+		t.Fatalf("audit log = %+v, want one unlock entry", f.audit.Entries)
+	}
+	if _, err := f.usecase.Login(context.Background(), f.user.Email, "old-password", testClient); err != nil {
+		t.Fatalf("login after unlock: %v", err)
+	}
+}
+
+func TestLoginSuccessClearsAccountFailures(t *testing.T) {
+	f := newResetFixture(t, nil)
+
+	for i := 0; i < freeAccountFailures; i++ {
+		f.usecase.Login(context.Background(), f.user.Email, "wrong", testClient)
+	}
+	if _, err := f.usecase.Login(context.Background(), f.user.Email, "old-password", testClient); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if throttle, _ := f.auth.GetThrottle(context.Background(), accountThrottleKey(f.user.ID, "")); throttle != nil {
+		t.Fatalf("account throttle after a successful login = %+v, want none", throttle)
+	}
+}
+
+func TestLoginUnknownEmailIsThrottledLikeAnAccount(t *testing.T) {
+	f := newResetFixture(t, nil)
+
+	for i := 0; i <= freeAccountFailures; i++ {
+		if _, err := f.usecase.Login(context.Background(), "Nobody@Example.com", "wrong", testClient); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failure %d = %v, want ErrInvalidCredentials", i+1, err)
+		}
+	}
+	if _, err := f.usecase.Login(context.Background(), "nobody@example.com", "wrong", testClient); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("unknown address over the limit = %v, want ErrAccountLocked like a registered one", err)
+	}
+}
+
+func TestLoginThrottlesClientAddress(t *testing.T) {
+	f := newResetFixture(t, nil)
+
+	// Spread the failures over many addresses so only the client limit trips.
+	for i := 0; i <= freeIPFailures; i++ {
+		f.usecase.Login(context.Background(), "guess"+string(rune('a'+i))+"@example.com", "wrong", testClient)
+	}
+
+	if _, err := f.usecase.Login(context.Background(), f.user.Email, "old-password", testClient); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("login from throttled address = %v, want ErrTooManyAttempts", err)
+	}
+	if _, err := f.usecase.Login(context.Background(), f.user.Email, "old-password", authUsecase.ClientInfo{IP: "198.51.100.1"}); err != nil {
+		t.Fatalf("login from another address: %v", err)
+	}
+}
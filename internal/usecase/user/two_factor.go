@@ -0,0 +1,259 @@
+package Userusecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	auditModels "fiber-crud/internal/domain/audit"
+	userModels "fiber-crud/internal/domain/user"
+	authUsecase "fiber-crud/internal/usecase/auth"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+)
+
+const recoveryCodeCount = 10
+
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// EnrollTOTP generates a new secret for the user. Two-factor authentication
+// stays disabled until ConfirmTOTP proves the authenticator was set up.
+func (u *userUsecase) EnrollTOTP(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error) {
+	user, err := u.getExisting(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := utils.SealTOTPSecret(secret, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = sealed
+	user.TOTPLastStep = 0
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(u.account.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user enters a valid
+// code and returns the recovery codes, which are only shown this once.
+func (u *userUsecase) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := u.getExisting(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	ok, err := u.checkTOTP(ctx, &user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, err := u.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (u *userUsecase) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := u.getExisting(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled() {
+		return ErrMFANotEnrolled
+	}
+
+	ok, err := u.verifySecondFactor(ctx, &user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	return u.clearTOTP(ctx, user)
+}
+
+func (u *userUsecase) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := u.getExisting(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled() {
+		return nil, ErrMFANotEnrolled
+	}
+
+	ok, err := u.checkTOTP(ctx, &user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	return u.replaceRecoveryCodes(ctx, user.ID)
+}
+
+// VerifyMFA completes a two-step login with either a TOTP code or a recovery
+// code. The challenge is redeemed before the code is checked, so it allows a
+// single attempt and a replayed challenge can never consume a recovery code
+// or TOTP step; after a wrong code the user signs in with the password again.
+func (u *userUsecase) VerifyMFA(ctx context.Context, challenge, code string, client authUsecase.ClientInfo) (*authUsecase.TokenPair, error) {
+	claims, err := utils.ParseMFAChallenge(challenge)
+	if err != nil {
+		return nil, ErrInvalidMFAChallenge
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	first, err := u.authRepo.RedeemTokenID(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == uuid.Nil || !user.MFAEnabled() {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	accountKey := accountThrottleKey(user.ID, "")
+	if err := u.checkLocked(ctx, accountKey, ErrAccountLocked); err != nil {
+		return nil, err
+	}
+
+	ok, err := u.verifySecondFactor(ctx, &user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := u.recordFailure(ctx, accountKey, freeAccountFailures); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidMFACode
+	}
+
+	if err := u.authRepo.ClearThrottle(ctx, accountKey); err != nil {
+		return nil, err
+	}
+
+	return u.authUsecase.IssueTokens(ctx, &user, client)
+}
+
+// ResetTOTP is the administrative escape hatch for users who lost both their
+// authenticator and their recovery codes. Existing sessions are revoked.
+func (u *userUsecase) ResetTOTP(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) error {
+	user, err := u.getExisting(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := u.clearTOTP(ctx, user); err != nil {
+		return err
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionReset2FA, auditModels.EntityUser, user.ID.String(), nil, nil)
+	return u.authUsecase.RevokeAllSessions(ctx, user.ID)
+}
+
+func (u *userUsecase) clearTOTP(ctx context.Context, user userModels.User) error {
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	user.TOTPLastStep = 0
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	return u.authRepo.DeleteRecoveryCodes(ctx, user.ID)
+}
+
+func (u *userUsecase) verifySecondFactor(ctx context.Context, user *userModels.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == 6 {
+		return u.checkTOTP(ctx, user, code)
+	}
+	return u.authRepo.ConsumeRecoveryCode(ctx, user.ID, utils.HashToken(strings.ToLower(code)))
+}
+
+// checkTOTP accepts each time step at most once so an observed code cannot be
+// replayed within its validity window.
+func (u *userUsecase) checkTOTP(ctx context.Context, user *userModels.User, code string) (bool, error) {
+	secret, err := utils.OpenTOTPSecret(user.TOTPSecret, user.ID.String())
+	if err != nil {
+		return false, err
+	}
+	step, ok := utils.ValidateTOTP(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	advanced, err := u.userRepo.AdvanceTOTPStep(ctx, user.ID, step)
+	if err != nil || !advanced {
+		return false, err
+	}
+	user.TOTPLastStep = step
+	return true, nil
+}
+
+func (u *userUsecase) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(code)
+	}
+	if err := u.authRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (u *userUsecase) getExisting(ctx context.Context, userID uuid.UUID) (userModels.User, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return userModels.User{}, err
+	}
+	if user.ID == uuid.Nil {
+		return userModels.User{}, ErrNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,113 @@
+package Userusecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	userModels "fiber-crud/internal/domain/user"
+	memoryRepository "fiber-crud/internal/repository/memory"
+	auditUsecase "fiber-crud/internal/usecase/audit"
+	authUsecase "fiber-crud/internal/usecase/auth"
+	"fiber-crud/package/config"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+)
+
+type mfaFixture struct {
+	usecase UserUsecase
+	users   *memoryRepository.UserRepository
+	auth    *memoryRepository.AuthRepository
+	user    userModels.User
+}
+
+func newMFAFixture(t *testing.T) *mfaFixture {
+	t.Helper()
+	if err := utils.InitKeyRing(config.JWTConfig{KID: "test", Secret: strings.Repeat("k", 32), Audience: "fiber-crud"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.InitTOTPEncryption(base64.StdEncoding.EncodeToString(make([]byte, 32))); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	user := userModels.User{ID: uuid.New(), Name: "alice", Email: "alice@example.com", TOTPEnabledAt: &now}
+	users := memoryRepository.NewUserRepository(user)
+	auth := memoryRepository.NewAuthRepository()
+	return &mfaFixture{
+		usecase: NewUserUsecase(users, auth, authUsecase.NewAuthUsecase(auth, users), nil, auditUsecase.NewAuditUsecase(memoryRepository.NewAuditRepository()), config.Default().Account),
+		users:   users,
+		auth:    auth,
+		user:    user,
+	}
+}
+
+func (f *mfaFixture) challenge(t *testing.T) string {
+	t.Helper()
+	challenge, err := utils.GenerateMFAChallenge(f.user.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return challenge
+}
+
+func TestVerifyMFAChallengeIsSingleUse(t *testing.T) {
+	f := newMFAFixture(t)
+	f.auth.RecoveryCodes[f.user.ID] = []string{utils.HashToken("code-one"), utils.HashToken("code-two")}
+	challenge := f.challenge(t)
+
+	if _, err := f.usecase.VerifyMFA(context.Background(), challenge, "code-one", authUsecase.ClientInfo{}); err != nil {
+		t.Fatalf("first VerifyMFA: %v", err)
+	}
+	if _, err := f.usecase.VerifyMFA(context.Background(), challenge, "code-two", authUsecase.ClientInfo{}); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Fatalf("second VerifyMFA = %v, want ErrInvalidMFAChallenge", err)
+	}
+	if codes := f.auth.RecoveryCodes[f.user.ID]; len(codes) != 1 {
+		t.Fatalf("replayed challenge consumed a recovery code: %d left, want 1", len(codes))
+	}
+}
+
+// The challenge is spent by the first attempt, so guessing codes needs a
+// correct password for every guess.
+func TestVerifyMFAWrongCodeSpendsChallenge(t *testing.T) {
+	f := newMFAFixture(t)
+	f.auth.RecoveryCodes[f.user.ID] = []string{utils.HashToken("code-one")}
+	challenge := f.challenge(t)
+
+	if _, err := f.usecase.VerifyMFA(context.Background(), challenge, "wrong", authUsecase.ClientInfo{}); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("VerifyMFA with a wrong code = %v, want ErrInvalidMFACode", err)
+	}
+	if _, err := f.usecase.VerifyMFA(context.Background(), challenge, "code-one", authUsecase.ClientInfo{}); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Fatalf("VerifyMFA retrying the challenge = %v, want ErrInvalidMFAChallenge", err)
+	}
+	if codes := f.auth.RecoveryCodes[f.user.ID]; len(codes) != 1 {
+		t.Fatalf("spent challenge consumed a recovery code: %d left, want 1", len(codes))
+	}
+	if _, err := f.usecase.VerifyMFA(context.Background(), f.challenge(t), "code-one", authUsecase.ClientInfo{}); err != nil {
+		t.Fatalf("VerifyMFA with a fresh challenge: %v", err)
+	}
+}
+
+func TestEnrollTOTPStoresSealedSecret(t *testing.T) {
+	f := newMFAFixture(t)
+	f.user.TOTPEnabledAt = nil
+	f.users.Users[f.user.ID] = f.user
+
+	enrollment, err := f.usecase.EnrollTOTP(context.Background(), f.user.ID)
+	if err != nil {
+		t.Fatalf("EnrollTOTP: %v", err)
+	}
+
+	stored := f.users.Users[f.user.ID].TOTPSecret
+	if stored == enrollment.Secret || strings.Contains(stored, enrollment.Secret) {
+		t.Fatal("TOTP secret stored in plain text")
+	}
+	opened, err := utils.OpenTOTPSecret(stored, f.user.ID.String())
+	if err != nil || opened != enrollment.Secret {
+		t.Fatalf("stored secret opens to %q, %v; want the enrolled secret", opened, err)
+	}
+}
@@ -1,10 +1,17 @@
 package Userusecase
 
 import (
-	"errors"
+	"context"
 
+	"fiber-crud/internal/domain/apperror"
+	auditModels "fiber-crud/internal/domain/audit"
 	userModels "fiber-crud/internal/domain/user"
 	userRepository "fiber-crud/internal/repository"
+	authRepository "fiber-crud/internal/repository/auth"
+	auditUsecase "fiber-crud/internal/usecase/audit"
+	authUsecase "fiber-crud/internal/usecase/auth"
+	"fiber-crud/package/config"
+	"fiber-crud/package/mailer"
 	"fiber-crud/utils"
 
 	"github.com/google/uuid"
@@ -13,32 +20,83 @@ import (
 )
 
 var (
-	ErrNotFound           = errors.New("entity not found")
-	ErrUsernameTaken      = errors.New("username already taken")
-	ErrEmailTaken         = errors.New("email already taken")
-	ErrUsernameValidate   = errors.New("username cannot be empty")
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrNotFound           = apperror.NotFound("user_not_found", "user not found")
+	ErrUsernameTaken      = apperror.Conflict("username_taken", "username already taken")
+	ErrEmailTaken         = apperror.Conflict("email_taken", "email already taken")
+	ErrUsernameValidate   = apperror.Invalid("username_required", "username cannot be empty")
+	ErrInvalidCredentials = apperror.Unauthorized("invalid_credentials", "invalid credentials")
+	ErrInvalidResetToken  = apperror.Invalid("invalid_reset_token", "invalid or expired reset token")
+	ErrWeakPassword       = apperror.Invalid("weak_password", "password must be at least 8 characters")
+	ErrEmptySearchQuery   = apperror.Invalid("empty_search_query", "search query cannot be empty")
+
+	ErrPasswordResetRequired = apperror.Forbidden("password_reset_required", "password reset required before signing in")
+
+	ErrEmailNotVerified         = apperror.Forbidden("email_not_verified", "email address is not verified")
+	ErrEmailAlreadyVerified     = apperror.Conflict("email_already_verified", "email address is already verified")
+	ErrInvalidVerificationToken = apperror.Invalid("invalid_verification_token", "invalid or expired verification token")
+
+	ErrIdentityLinked        = apperror.Conflict("identity_already_linked", "identity is already linked to another account")
+	ErrIdentityNotFound      = apperror.NotFound("identity_not_found", "identity not linked")
+	ErrIdentityEmailRequired = apperror.Invalid("identity_email_required", "the provider did not share an email address; sign in another way and link the provider instead")
+	ErrLastLoginMethod       = apperror.Conflict("last_login_method", "cannot unlink the only way to sign in")
+
+	ErrMFAAlreadyEnabled   = apperror.Conflict("mfa_already_enabled", "two-factor authentication is already enabled")
+	ErrMFANotEnrolled      = apperror.Conflict("mfa_not_enrolled", "two-factor authentication is not enrolled")
+	ErrInvalidMFACode      = apperror.Unauthorized("invalid_mfa_code", "invalid authentication code")
+	ErrInvalidMFAChallenge = apperror.Unauthorized("invalid_mfa_challenge", "invalid or expired MFA challenge")
 )
 
 type UserUsecase interface {
-	GetUsers() ([]userModels.User, error)
-	GetUserByID(id uuid.UUID) (userModels.User, error)
-	CreateUser(user userModels.User) (*userModels.User, error)
-	UpdateUser(user userModels.User) error
-	DeleteUser(id uuid.UUID) error
-	GetCurrentUser(userID uuid.UUID) (userModels.User, error)
-	SearchUsers(query string) ([]userModels.User, error)
-	LoginOrSignup(googleID, email, name, avatar string) (*userModels.User, error)
-	Login(email, password string) (string, error)
+	GetUsers(ctx context.Context) ([]userModels.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (userModels.User, error)
+	CreateUser(ctx context.Context, actor auditModels.Actor, user userModels.User) (*userModels.User, error)
+	UpdateUser(ctx context.Context, actor auditModels.Actor, user userModels.User) (*userModels.User, error)
+	UpdateProfile(ctx context.Context, actor auditModels.Actor, userID uuid.UUID, name, email, avatar string) (userModels.User, error)
+	DeleteUser(ctx context.Context, actor auditModels.Actor, id uuid.UUID) error
+	RestoreUser(ctx context.Context, actor auditModels.Actor, id uuid.UUID) error
+	GetCurrentUser(ctx context.Context, userID uuid.UUID) (userModels.User, error)
+	SearchUsers(ctx context.Context, query string) ([]userModels.User, error)
+	LoginWithIdentity(ctx context.Context, identity utils.ExternalIdentity) (*userModels.User, error)
+	LinkIdentity(ctx context.Context, userID uuid.UUID, identity utils.ExternalIdentity) error
+	UnlinkIdentity(ctx context.Context, userID uuid.UUID, provider string) error
+	GetIdentities(ctx context.Context, userID uuid.UUID) ([]userModels.Identity, error)
+	Login(ctx context.Context, email, password string, client authUsecase.ClientInfo) (*authUsecase.LoginResult, error)
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	SendVerificationEmail(ctx context.Context, userID uuid.UUID) error
+	VerifyEmail(ctx context.Context, token string) error
+	EnrollTOTP(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	VerifyMFA(ctx context.Context, challenge, code string, client authUsecase.ClientInfo) (*authUsecase.TokenPair, error)
+	ResetTOTP(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) error
+	UnlockAccount(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) error
+	ListUsers(ctx context.Context, filter userModels.ListFilter) ([]userModels.User, int64, error)
+	SuspendUser(ctx context.Context, actor auditModels.Actor, userID uuid.UUID, reason string) error
+	ReactivateUser(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) error
+	ForcePasswordReset(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) error
+	ChangeRole(ctx context.Context, actor auditModels.Actor, userID uuid.UUID, role string) error
+	Impersonate(ctx context.Context, actor auditModels.Actor, userID uuid.UUID) (*Impersonation, error)
 }
 
 type userUsecase struct {
-	userRepo userRepository.UserRepository
+	userRepo    userRepository.UserRepository
+	authRepo    authRepository.AuthRepository
+	authUsecase authUsecase.AuthUsecase
+	mailer      mailer.Mailer
+	audit       auditUsecase.AuditUsecase
+	account     config.AccountConfig
 }
 
-func NewUserUsecase(userRepo userRepository.UserRepository) UserUsecase {
+func NewUserUsecase(userRepo userRepository.UserRepository, authRepo authRepository.AuthRepository, authUsecase authUsecase.AuthUsecase, mailer mailer.Mailer, audit auditUsecase.AuditUsecase, account config.AccountConfig) UserUsecase {
 	return &userUsecase{
-		userRepo: userRepo,
+		userRepo:    userRepo,
+		authRepo:    authRepo,
+		authUsecase: authUsecase,
+		mailer:      mailer,
+		audit:       audit,
+		account:     account,
 	}
 }
 
@@ -53,27 +111,22 @@ func HashPassword(password string) (string, error) {
 
 func ComparePassword(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	if err != nil {
-		log.Warn().
-			Str("hashedPassword", hashedPassword).
-			Str("inputPassword", password).
-			Err(err).
-			Msg("pkg::ComparePassword - Error while comparing password")
-		return false
-	}
-	return true
+	if err != nil && err != bcrypt.ErrMismatchedHashAndPassword {
+		log.Warn().Err(err).Msg("pkg::ComparePassword - Error while comparing password")
+	}
+	return err == nil
 }
 
-func (u *userUsecase) GetCurrentUser(userID uuid.UUID) (userModels.User, error) {
-	return u.userRepo.GetByID(userID)
+func (u *userUsecase) GetCurrentUser(ctx context.Context, userID uuid.UUID) (userModels.User, error) {
+	return u.GetUserByID(ctx, userID)
 }
 
-func (u *userUsecase) GetUsers() ([]userModels.User, error) {
-	return u.userRepo.GetAll()
+func (u *userUsecase) GetUsers(ctx context.Context) ([]userModels.User, error) {
+	return u.userRepo.GetAll(ctx)
 }
 
-func (u *userUsecase) GetUserByID(id uuid.UUID) (userModels.User, error) {
-	user, err := u.userRepo.GetByID(id)
+func (u *userUsecase) GetUserByID(ctx context.Context, id uuid.UUID) (userModels.User, error) {
+	user, err := u.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return userModels.User{}, err
 	}
@@ -83,25 +136,25 @@ func (u *userUsecase) GetUserByID(id uuid.UUID) (userModels.User, error) {
 	return user, nil
 }
 
-func (u *userUsecase) CreateUser(user userModels.User) (*userModels.User, error) {
+func (u *userUsecase) CreateUser(ctx context.Context, actor auditModels.Actor, user userModels.User) (*userModels.User, error) {
 
 	if user.Name == "" {
 		return nil, ErrUsernameValidate
 	}
 
-	existingUserByUsername, err := u.userRepo.GetByUsername(user.Name)
+	usernameTaken, err := u.userRepo.IsUsernameTaken(ctx, user.Name)
 	if err != nil {
 		return nil, err
 	}
-	if existingUserByUsername != nil {
+	if usernameTaken {
 		return nil, ErrUsernameTaken
 	}
 
-	existingUserByEmail, err := u.userRepo.GetByEmail(user.Email)
+	emailTaken, err := u.userRepo.IsEmailTaken(ctx, user.Email)
 	if err != nil {
 		return nil, err
 	}
-	if existingUserByEmail != nil {
+	if emailTaken {
 		return nil, ErrEmailTaken
 	}
 
@@ -110,117 +163,214 @@ func (u *userUsecase) CreateUser(user userModels.User) (*userModels.User, error)
 		return nil, err
 	}
 	user.Password = hashedPassword
-
-	res, err := u.userRepo.Create(user)
+	user.Role = userModels.RoleUser
+	user.EmailVerifiedAt = nil
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	user.SuspendedAt = nil
+	user.SuspendedReason = ""
+	user.PasswordResetRequired = false
+
+	res, err := u.userRepo.Create(ctx, user)
 	if err != nil {
 		return nil, err
 	}
+	u.audit.Record(ctx, actor, auditModels.ActionCreate, auditModels.EntityUser, res.ID.String(), nil, res)
+
+	if err := u.sendVerificationEmail(ctx, res); err != nil {
+		log.Warn().Err(err).Str("userID", res.ID.String()).Msg("usecase::CreateUser - Verification email not sent")
+	}
 
 	return res, nil
 }
 
-func (u *userUsecase) UpdateUser(user userModels.User) error {
-	existingUser, err := u.userRepo.GetByID(user.ID)
-	if err != nil {
-		return ErrNotFound
+func (u *userUsecase) UpdateUser(ctx context.Context, actor auditModels.Actor, user userModels.User) (*userModels.User, error) {
+	existingUser, err := u.userRepo.GetByID(ctx, user.ID)
+	if err != nil || existingUser.ID == uuid.Nil {
+		return nil, ErrNotFound
 	}
 
 	if existingUser.Name != user.Name {
-		existingUserByUsername, err := u.userRepo.GetByUsername(user.Name)
+		usernameTaken, err := u.userRepo.IsUsernameTaken(ctx, user.Name)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if existingUserByUsername != nil {
-			return ErrUsernameTaken
+		if usernameTaken {
+			return nil, ErrUsernameTaken
 		}
 	}
 
 	if existingUser.Email != user.Email {
-		existingUserByEmail, err := u.userRepo.GetByEmail(user.Email)
+		emailTaken, err := u.userRepo.IsEmailTaken(ctx, user.Email)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if existingUserByEmail != nil {
-			return ErrEmailTaken
+		if emailTaken {
+			return nil, ErrEmailTaken
 		}
 	}
 
 	if user.Password != "" {
 		hashedPassword, err := HashPassword(user.Password)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		user.Password = hashedPassword
 	} else {
 		user.Password = existingUser.Password
 	}
 
+	emailChanged := existingUser.Email != user.Email
+	if emailChanged {
+		user.EmailVerifiedAt = nil
+	} else {
+		user.EmailVerifiedAt = existingUser.EmailVerifiedAt
+	}
+
+	user.Role = existingUser.Role
+	user.TOTPSecret = existingUser.TOTPSecret
+	user.TOTPEnabledAt = existingUser.TOTPEnabledAt
+	user.TOTPLastStep = existingUser.TOTPLastStep
+	user.SuspendedAt = existingUser.SuspendedAt
+	user.SuspendedReason = existingUser.SuspendedReason
+	user.PasswordResetRequired = existingUser.PasswordResetRequired
 	user.CreatedAt = existingUser.CreatedAt
-	return u.userRepo.Update(user)
-}
+	user.DeletedAt = existingUser.DeletedAt
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionUpdate, auditModels.EntityUser, user.ID.String(), existingUser, user)
 
-func (u *userUsecase) DeleteUser(id uuid.UUID) error {
-	_, err := u.userRepo.GetByID(id)
-	if err != nil {
-		return ErrNotFound
+	if emailChanged {
+		if err := u.sendVerificationEmail(ctx, &user); err != nil {
+			log.Warn().Err(err).Str("userID", user.ID.String()).Msg("usecase::UpdateUser - Verification email not sent")
+		}
 	}
-	return u.userRepo.Delete(id)
+	return &user, nil
 }
 
-func (u *userUsecase) LoginOrSignup(googleID, email, name, avatar string) (*userModels.User, error) {
-	var user *userModels.User
-	var err error
+// UpdateProfile is the self-service edit: only the name, email and avatar
+// change, and empty values keep the current ones.
+func (u *userUsecase) UpdateProfile(ctx context.Context, actor auditModels.Actor, userID uuid.UUID, name, email, avatar string) (userModels.User, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return userModels.User{}, err
+	}
+	if user.ID == uuid.Nil {
+		return userModels.User{}, ErrNotFound
+	}
 
-	user, err = u.userRepo.GetByEmail(email)
+	if name != "" {
+		user.Name = name
+	}
+	if email != "" {
+		user.Email = email
+	}
+	if avatar != "" {
+		user.Avatar = avatar
+	}
+	user.Password = ""
+	updated, err := u.UpdateUser(ctx, actor, user)
 	if err != nil {
-		return nil, err
+		return userModels.User{}, err
 	}
+	return *updated, nil
+}
 
-	if user == nil || user.ID == uuid.Nil {
-		user, err = u.userRepo.FindGoogleId(googleID)
-		if err != nil {
-			return nil, err
-		}
+// DeleteUser soft-deletes the account and signs it out everywhere. It can be
+// restored until the purge job removes it.
+func (u *userUsecase) DeleteUser(ctx context.Context, actor auditModels.Actor, id uuid.UUID) error {
+	existingUser, err := u.userRepo.GetByID(ctx, id)
+	if err != nil || existingUser.ID == uuid.Nil {
+		return ErrNotFound
 	}
+	if err := u.userRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := u.authUsecase.RevokeAllSessions(ctx, id); err != nil {
+		return err
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionDelete, auditModels.EntityUser, id.String(), existingUser, nil)
+	return nil
+}
 
-	if user == nil || user.ID == uuid.Nil {
-		user, err = u.userRepo.Create(userModels.User{
-			Name:     name,
-			Email:    email,
-			GoogleID: googleID,
-			Avatar:   avatar,
-		})
-		if err != nil {
-			return nil, err
-		}
+// RestoreUser undoes a soft delete. Sessions ended by the delete stay ended.
+// Erased users cannot be restored.
+func (u *userUsecase) RestoreUser(ctx context.Context, actor auditModels.Actor, id uuid.UUID) error {
+	erased, err := u.userRepo.IsErased(ctx, id)
+	if err != nil {
+		return err
 	}
-	return user, nil
+	if erased {
+		return ErrUserErased
+	}
+	restored, err := u.userRepo.Restore(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrNotFound
+	}
+	u.audit.Record(ctx, actor, auditModels.ActionRestore, auditModels.EntityUser, id.String(), nil, nil)
+	return nil
 }
 
-func (u *userUsecase) SearchUsers(query string) ([]userModels.User, error) {
+func (u *userUsecase) SearchUsers(ctx context.Context, query string) ([]userModels.User, error) {
 	if query == "" {
-		return nil, errors.New("search query cannot be empty")
+		return nil, ErrEmptySearchQuery
 	}
-	return u.userRepo.Search(query)
+	return u.userRepo.Search(ctx, query)
 }
 
-func (u *userUsecase) Login(email, password string) (string, error) {
-	user, err := u.userRepo.GetByEmail(email)
+// Login checks a password. Failed attempts are counted per account and per
+// client IP; once either exceeds its allowance further attempts are refused
+// with ErrTooManyAttempts or ErrAccountLocked until the backoff expires.
+func (u *userUsecase) Login(ctx context.Context, email, password string, client authUsecase.ClientInfo) (*authUsecase.LoginResult, error) {
+	if err := u.checkLocked(ctx, ipThrottleKey(client.IP), ErrTooManyAttempts); err != nil {
+		return nil, err
+	}
+
+	user, err := u.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	if user == nil || user.ID == uuid.Nil {
-		return "", ErrNotFound
+
+	var userID uuid.UUID
+	if user != nil {
+		userID = user.ID
 	}
+	accountKey := accountThrottleKey(userID, email)
 
-	if !ComparePassword(user.Password, password) {
-		return "", ErrInvalidCredentials
+	if err := u.checkLocked(ctx, accountKey, ErrAccountLocked); err != nil {
+		return nil, err
 	}
 
-	token, err := utils.GenerateJWT(user.ID.String())
-	if err != nil {
-		return "", err
+	passwordOK := false
+	if user != nil && user.ID != uuid.Nil && user.Password != "" {
+		passwordOK = ComparePassword(user.Password, password)
+	} else {
+		// Spend the same bcrypt work as for a real account so the response
+		// time does not tell which addresses are registered.
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+	}
+
+	if !passwordOK {
+		if err := u.recordFailure(ctx, accountKey, freeAccountFailures); err != nil {
+			return nil, err
+		}
+		if err := u.recordFailure(ctx, ipThrottleKey(client.IP), freeIPFailures); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := u.authRepo.ClearThrottle(ctx, accountKey); err != nil {
+		return nil, err
+	}
+
+	if user.PasswordResetRequired && !user.Suspended() {
+		return nil, ErrPasswordResetRequired
 	}
 
-	return token, nil
+	return u.authUsecase.CompleteLogin(ctx, user, client)
 }
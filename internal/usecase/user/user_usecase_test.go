@@ -0,0 +1,66 @@
+package Userusecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	auditModels "fiber-crud/internal/domain/audit"
+	authUsecase "fiber-crud/internal/usecase/auth"
+	"fiber-crud/utils"
+
+	"github.com/google/uuid"
+)
+
+func TestDeleteUserIsSoftAndRestorable(t *testing.T) {
+	f := newResetFixture(t, nil)
+	pair, err := authUsecase.NewAuthUsecase(f.auth, f.users).IssueTokens(context.Background(), &f.user, authUsecase.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.usecase.DeleteUser(context.Background(), adminActor(), f.user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := f.usecase.GetUserByID(context.Background(), f.user.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetUserByID after delete = %v, want ErrNotFound", err)
+	}
+	if _, err := authUsecase.NewAuthUsecase(f.auth, f.users).Refresh(context.Background(), pair.RefreshToken, authUsecase.ClientInfo{}); err == nil {
+		t.Fatal("refresh token survived the delete")
+	}
+
+	// The address stays reserved until the account is purged.
+	_, err = f.usecase.LoginWithIdentity(context.Background(), utils.ExternalIdentity{Provider: "github", Subject: "42", Email: f.user.Email, EmailVerified: true})
+	if !errors.Is(err, ErrEmailTaken) {
+		t.Fatalf("signing up with a deleted account's address = %v, want ErrEmailTaken", err)
+	}
+
+	if err := f.usecase.RestoreUser(context.Background(), adminActor(), f.user.ID); err != nil {
+		t.Fatalf("RestoreUser: %v", err)
+	}
+	if _, err := f.usecase.GetUserByID(context.Background(), f.user.ID); err != nil {
+		t.Fatalf("GetUserByID after restore: %v", err)
+	}
+	if err := f.usecase.RestoreUser(context.Background(), adminActor(), f.user.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("restoring a live account = %v, want ErrNotFound", err)
+	}
+
+	if n := len(f.audit.Entries); n != 2 || f.audit.Entries[0].Action != auditModels.ActionDelete || f.audit.Entries[1].Action != auditModels.ActionRestore {
+		t.Fatalf("audit log = %+v, want delete then restore", f.audit.Entries)
+	}
+}
+
+func TestRestoreUserRefusesErasedAccount(t *testing.T) {
+	f := newResetFixture(t, nil)
+	if err := f.usecase.DeleteUser(context.Background(), adminActor(), f.user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	f.users.Erased = map[uuid.UUID]bool{f.user.ID: true}
+
+	if err := f.usecase.RestoreUser(context.Background(), adminActor(), f.user.ID); !errors.Is(err, ErrUserErased) {
+		t.Fatalf("restoring an erased account = %v, want ErrUserErased", err)
+	}
+	if !f.users.Users[f.user.ID].DeletedAt.Valid {
+		t.Fatal("erased account was brought back")
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	apiKeyModels "fiber-crud/internal/domain/apikey"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyAuthenticator resolves a presented API key to the user it acts for.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*apiKeyModels.Principal, error)
+}
+
+var apiKeyAuthenticator APIKeyAuthenticator
+
+// SetAPIKeyAuthenticator installs the store consulted by
+// AuthMiddlewareWithAPIKey. Until it is called API keys are rejected.
+func SetAPIKeyAuthenticator(authenticator APIKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+// AuthMiddlewareWithAPIKey accepts either a JWT, exactly like
+// AuthMiddleware, or a personal API key sent as X-API-Key or as a Bearer
+// token. API key requests get a "scopes" local that CheckPermission enforces
+// and no "claims", so handlers that need a login session should stay on
+// AuthMiddleware.
+func AuthMiddlewareWithAPIKey() fiber.Handler {
+	jwtAuth := AuthMiddleware()
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get("X-API-Key")
+		if key == "" {
+			bearer := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+			if strings.HasPrefix(bearer, apiKeyModels.KeyPrefix) {
+				key = bearer
+			}
+		}
+		if key == "" {
+			return jwtAuth(c)
+		}
+
+		if apiKeyAuthenticator == nil {
+			return apiKeyModels.ErrInvalidAPIKey
+		}
+
+		// Fails with ErrInvalidAPIKey, or userModels.ErrSuspended for a
+		// suspended owner.
+		principal, err := apiKeyAuthenticator.AuthenticateAPIKey(c.UserContext(), key)
+		if err != nil {
+			return err
+		}
+
+		scopes := principal.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+
+		c.Locals("userID", principal.UserID.String())
+		c.Locals("role", principal.Role)
+		c.Locals("scopes", scopes)
+		c.Locals("apiKeyID", principal.KeyID.String())
+		return c.Next()
+	}
+}
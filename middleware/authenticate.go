@@ -1,32 +1,80 @@
 package middleware
 
 import (
+	"context"
+	"fiber-crud/internal/domain/apperror"
 	"fiber-crud/utils"
-	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var (
+	errMissingToken       = apperror.Unauthorized("missing_token", "no token provided")
+	errInvalidToken       = apperror.Unauthorized("invalid_token", "invalid token")
+	errTokenRevoked       = apperror.Unauthorized("token_revoked", "token has been revoked")
+	errImpersonationBlock = apperror.Forbidden("impersonation_not_allowed", "not allowed while impersonating")
+)
+
+// RevocationChecker reports whether an access token, or the session it
+// belongs to, was revoked before the token expired.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims *utils.Claims) (bool, error)
+}
+
+var revocationChecker RevocationChecker
+
+// SetRevocationChecker installs the store consulted by AuthMiddleware. Until
+// it is called every validly signed token is accepted.
+func SetRevocationChecker(checker RevocationChecker) {
+	revocationChecker = checker
+}
+
 func AuthMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		tokenString := c.Get("Authorization")
 
 		if tokenString == "" {
-			return fiber.NewError(fiber.StatusUnauthorized, "No token provided")
+			return errMissingToken
 		}
 
 		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
 			tokenString = tokenString[7:]
 		}
 
-		fmt.Println("Received token:", tokenString)
-
 		claims, err := utils.ParseTokenString(tokenString)
 		if err != nil {
-			return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+			return errInvalidToken
+		}
+
+		if revocationChecker != nil {
+			// IsRevoked fails with userModels.ErrSuspended for suspended
+			// accounts.
+			revoked, err := revocationChecker.IsRevoked(c.UserContext(), claims)
+			if err != nil {
+				return err
+			}
+			if revoked {
+				return errTokenRevoked
+			}
 		}
 
 		c.Locals("userID", claims.Subject)
+		c.Locals("role", claims.Role)
+		c.Locals("claims", claims)
+		if claims.Actor != nil {
+			c.Locals("impersonatorID", claims.Actor.Subject)
+		}
+		return c.Next()
+	}
+}
+
+// RejectImpersonation blocks impersonation tokens from routes that manage
+// the user's own credentials.
+func RejectImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := c.Locals("impersonatorID").(string); ok {
+			return errImpersonationBlock
+		}
 		return c.Next()
 	}
 }
@@ -1,30 +1,58 @@
 package middleware
 
 import (
-	"strings"
+	"fiber-crud/internal/domain/apperror"
+	userModels "fiber-crud/internal/domain/user"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func CheckRole(allowedRoles ...string) fiber.Handler {
+var (
+	errNoRole                  = apperror.Forbidden("no_role", "access denied: no role found")
+	errInsufficientPermissions = apperror.Forbidden("insufficient_permissions", "access denied: insufficient permissions")
+	errMissingScope            = apperror.Forbidden("missing_scope", "access denied: API key lacks the required scope")
+)
+
+func CheckPermission(permission string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		role := c.Locals("role")
+		role, ok := c.Locals("role").(string)
+		if !ok || role == "" {
+			return errNoRole
+		}
 
-		if role == nil {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied: no role found",
-			})
+		if !userModels.HasPermission(role, permission) {
+			return errInsufficientPermissions
 		}
 
-		userRole := role.(string)
-		for _, allowedRole := range allowedRoles {
-			if strings.EqualFold(userRole, allowedRole) {
-				return c.Next()
-			}
+		// Requests authenticated by an API key are further limited to the
+		// key's scopes.
+		if scopes, ok := c.Locals("scopes").([]string); ok && !containsScope(scopes, permission) {
+			return errMissingScope
 		}
 
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Access denied: insufficient permissions",
-		})
+		return c.Next()
+	}
+}
+
+// HasPermission reports whether the authenticated caller holds permission,
+// honouring API key scopes. Handlers use it for checks that depend on the
+// resource, such as moderating another user's content.
+func HasPermission(c *fiber.Ctx, permission string) bool {
+	role, _ := c.Locals("role").(string)
+	if !userModels.HasPermission(role, permission) {
+		return false
+	}
+	if scopes, ok := c.Locals("scopes").([]string); ok {
+		return containsScope(scopes, permission)
+	}
+	return true
+}
+
+func containsScope(scopes []string, permission string) bool {
+	for _, scope := range scopes {
+		if scope == permission {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"fiber-crud/internal/domain/apperror"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Problem is an RFC 7807 problem details body. Code is a stable identifier
+// clients can branch on; Title and Detail are for humans.
+type Problem struct {
+	Type      string                `json:"type"`
+	Title     string                `json:"title"`
+	Status    int                   `json:"status"`
+	Detail    string                `json:"detail,omitempty"`
+	Instance  string                `json:"instance,omitempty"`
+	Code      string                `json:"code"`
+	RequestID string                `json:"request_id,omitempty"`
+	Errors    []apperror.FieldError `json:"errors,omitempty"`
+}
+
+var errRouteNotFound = apperror.NotFound("route_not_found", "route not found")
+
+// NotFound is the fallback for requests no route matched. Register it with
+// app.Use after every route.
+func NotFound() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("routeUnmatched", true)
+		return errRouteNotFound
+	}
+}
+
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindInternal:     fiber.StatusInternalServerError,
+	apperror.KindInvalid:      fiber.StatusBadRequest,
+	apperror.KindUnauthorized: fiber.StatusUnauthorized,
+	apperror.KindForbidden:    fiber.StatusForbidden,
+	apperror.KindNotFound:     fiber.StatusNotFound,
+	apperror.KindConflict:     fiber.StatusConflict,
+	apperror.KindValidation:   fiber.StatusUnprocessableEntity,
+	apperror.KindLocked:       fiber.StatusLocked,
+	apperror.KindRateLimited:  fiber.StatusTooManyRequests,
+	apperror.KindUpstream:     fiber.StatusBadGateway,
+}
+
+const internalDetail = "an unexpected error occurred"
+
+// ErrorHandler writes every error returned by a handler or middleware as
+// application/problem+json. Typed errors keep their code and message;
+// anything else is a 500 whose text is only shown when hideInternal is
+// false. The cause is logged by RequestLogger.
+func ErrorHandler(hideInternal bool) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		problem := Problem{Type: "about:blank", Instance: c.Path()}
+		if requestID, ok := c.Locals("requestid").(string); ok {
+			problem.RequestID = requestID
+		}
+
+		var appErr *apperror.Error
+		var fiberErr *fiber.Error
+		switch {
+		case errors.As(err, &appErr):
+			problem.Status = kindStatus[appErr.Kind]
+			problem.Code = appErr.Code
+			problem.Detail = appErr.Message
+			problem.Errors = appErr.Fields
+			if !hideInternal && appErr.Err != nil {
+				problem.Detail = appErr.Error()
+			}
+			if appErr.RetryAfter > 0 {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+			}
+		case errors.As(err, &fiberErr):
+			problem.Status = fiberErr.Code
+			problem.Code = statusCode(fiberErr.Code)
+			problem.Detail = fiberErr.Message
+		default:
+			problem.Status = fiber.StatusInternalServerError
+			problem.Code = "internal_error"
+			problem.Detail = internalDetail
+			if !hideInternal {
+				problem.Detail = err.Error()
+			}
+		}
+		problem.Title = utils.StatusMessage(problem.Status)
+
+		c.Status(problem.Status)
+		c.Set(fiber.HeaderContentType, "application/problem+json")
+		body, err := c.App().Config().JSONEncoder(problem)
+		if err != nil {
+			return c.SendString(internalDetail)
+		}
+		return c.Send(body)
+	}
+}
+
+// RenderErrors writes an error returned further down the chain through the
+// app's error handler. Register it after RequestLogger and Metrics: they
+// then read the status the client receives, and the error itself from the
+// "error" local.
+func RenderErrors() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err == nil {
+			return nil
+		}
+		c.Locals("error", err)
+		if err := c.App().ErrorHandler(c, err); err != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+		return nil
+	}
+}
+
+// renderedError runs the rest of the chain and returns the error
+// RenderErrors rendered there, if any.
+func renderedError(c *fiber.Ctx) error {
+	if err := c.Next(); err != nil {
+		return err
+	}
+	err, _ := c.Locals("error").(error)
+	return err
+}
+
+// statusCode derives a code such as "method_not_allowed" for errors Fiber
+// raises itself.
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(utils.StatusMessage(status)), " ", "_")
+}
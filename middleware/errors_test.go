@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fiber-crud/internal/domain/apperror"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+// render returns the response the error handler writes for err.
+func render(t *testing.T, hideInternal bool, err error) (*http.Response, Problem) {
+	t.Helper()
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(hideInternal)})
+	app.Use(requestid.New())
+	app.Get("/fail", func(*fiber.Ctx) error { return err })
+
+	resp, testErr := app.Test(httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if testErr != nil {
+		t.Fatal(testErr)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get(fiber.HeaderContentType); got != "application/problem+json" {
+		t.Fatalf("Content-Type = %q, want application/problem+json", got)
+	}
+	var problem Problem
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != resp.StatusCode || problem.Instance != "/fail" || problem.RequestID == "" {
+		t.Fatalf("problem = %+v, want status, instance and request ID filled in", problem)
+	}
+	return resp, problem
+}
+
+func TestTypedErrorKeepsCodeAndMessage(t *testing.T) {
+	resp, problem := render(t, true, apperror.Conflict("email_taken", "email already taken"))
+	if resp.StatusCode != fiber.StatusConflict || problem.Code != "email_taken" || problem.Detail != "email already taken" || problem.Title != "Conflict" {
+		t.Fatalf("response = %d %+v, want 409 email_taken", resp.StatusCode, problem)
+	}
+}
+
+func TestValidationErrorListsFields(t *testing.T) {
+	fields := []apperror.FieldError{{Field: "email", Rule: "email", Message: "must be a valid email address"}}
+	resp, problem := render(t, true, apperror.Validation(fields))
+	if resp.StatusCode != fiber.StatusUnprocessableEntity || problem.Code != "validation_failed" {
+		t.Fatalf("response = %d %+v, want 422 validation_failed", resp.StatusCode, problem)
+	}
+	if len(problem.Errors) != 1 || problem.Errors[0] != fields[0] {
+		t.Fatalf("errors = %+v, want %+v", problem.Errors, fields)
+	}
+}
+
+func TestLockedErrorSetsRetryAfter(t *testing.T) {
+	locked := apperror.Locked("account_locked", "account locked").WithRetryAfter(1500 * time.Millisecond)
+	resp, _ := render(t, true, locked)
+	if resp.StatusCode != fiber.StatusLocked || resp.Header.Get(fiber.HeaderRetryAfter) != "2" {
+		t.Fatalf("response = %d Retry-After %q, want 423 with 2 seconds", resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter))
+	}
+}
+
+func TestInternalDetailsHiddenInProduction(t *testing.T) {
+	cause := errors.New("pq: password authentication failed for user \"app\"")
+	wrapped := apperror.Upstream("storage_error", "image storage failed").Wrap(cause)
+
+	for _, tt := range []struct {
+		name         string
+		hideInternal bool
+		err          error
+		wantStatus   int
+		wantDetail   string
+	}{
+		{"untyped in production", true, cause, fiber.StatusInternalServerError, internalDetail},
+		{"untyped in development", false, cause, fiber.StatusInternalServerError, cause.Error()},
+		{"wrapped cause in production", true, wrapped, fiber.StatusBadGateway, "image storage failed"},
+		{"wrapped cause in development", false, wrapped, fiber.StatusBadGateway, wrapped.Error()},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, problem := render(t, tt.hideInternal, tt.err)
+			if resp.StatusCode != tt.wantStatus || problem.Detail != tt.wantDetail {
+				t.Fatalf("response = %d %q, want %d %q", resp.StatusCode, problem.Detail, tt.wantStatus, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestFiberErrorGetsDerivedCode(t *testing.T) {
+	resp, problem := render(t, true, fiber.ErrMethodNotAllowed)
+	if resp.StatusCode != fiber.StatusMethodNotAllowed || problem.Code != "method_not_allowed" {
+		t.Fatalf("response = %d %+v, want 405 method_not_allowed", resp.StatusCode, problem)
+	}
+}
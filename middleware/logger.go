@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const requestIDHeader = fiber.HeaderXRequestID
+
+// validRequestID limits what a client may pass as its own request ID so the
+// value is safe to echo into logs and responses.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// sensitiveParams are query parameters whose values are never logged.
+var sensitiveParams = map[string]bool{
+	"access_token":  true,
+	"api_key":       true,
+	"code":          true,
+	"password":      true,
+	"refresh_token": true,
+	"secret":        true,
+	"state":         true,
+	"token":         true,
+}
+
+// quietRoutes are probe endpoints, logged at debug so they do not flood the
+// logs.
+var quietRoutes = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// RequestID keeps a well-formed X-Request-ID from the client or generates
+// one, stores it in the "requestid" local and echoes it on the response.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(requestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Locals("requestid", requestID)
+		c.Set(requestIDHeader, requestID)
+		return c.Next()
+	}
+}
+
+// RequestLogger writes one line per request once the response is known.
+func RequestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		chainErr := renderedError(c)
+
+		status := c.Response().StatusCode()
+		route := routeLabel(c)
+
+		var event *zerolog.Event
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			event = log.Error()
+		case status >= fiber.StatusBadRequest:
+			event = log.Warn()
+		case quietRoutes[route]:
+			event = log.Debug()
+		default:
+			event = log.Info()
+		}
+		if !event.Enabled() {
+			return nil
+		}
+
+		requestID, _ := c.Locals("requestid").(string)
+		event.
+			Str("requestID", requestID).
+			Str("method", c.Method()).
+			Str("route", route).
+			Str("path", c.Path()).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Int("bytes", len(c.Response().Body())).
+			Str("ip", c.IP())
+		if query := redactQuery(string(c.Request().URI().QueryString())); query != "" {
+			event.Str("query", query)
+		}
+		if traceID := traceID(c); traceID != "" {
+			event.Str("traceID", traceID)
+		}
+		if userID, ok := c.Locals("userID").(string); ok {
+			event.Str("userID", userID)
+		}
+		if impersonatorID, ok := c.Locals("impersonatorID").(string); ok {
+			event.Str("impersonatorID", impersonatorID)
+		}
+		if chainErr != nil {
+			event.Err(chainErr)
+		}
+		event.Msg("middleware::RequestLogger - Request handled")
+		return nil
+	}
+}
+
+// traceID returns the ID of the trace started by Tracing, or an empty
+// string when there is none.
+func traceID(c *fiber.Ctx) string {
+	spanContext := trace.SpanContextFromContext(c.UserContext())
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+// redactQuery replaces the values of sensitive query parameters. A query
+// that cannot be parsed is dropped rather than logged verbatim.
+func redactQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return "[unparseable]"
+	}
+	for key := range values {
+		if sensitiveParams[strings.ToLower(key)] {
+			values[key] = []string{"REDACTED"}
+		}
+	}
+	return values.Encode()
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fiber-crud/internal/domain/apperror"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// captureLogs sends the global logger to a buffer for the rest of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous, level := log.Logger, zerolog.GlobalLevel()
+	log.Logger = zerolog.New(&buf)
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	t.Cleanup(func() {
+		log.Logger = previous
+		zerolog.SetGlobalLevel(level)
+	})
+	return &buf
+}
+
+func newLoggedApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(false)})
+	app.Use(RequestID(), RequestLogger(), RenderErrors())
+	app.Get("/ok", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/missing", func(*fiber.Ctx) error { return apperror.NotFound("thing_not_found", "thing not found") })
+	app.Get("/healthz", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	return app
+}
+
+func logLine(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log output %q is not a single JSON line: %v", buf.String(), err)
+	}
+	return line
+}
+
+func TestRequestIDKeepsAWellFormedClientID(t *testing.T) {
+	app := newLoggedApp()
+
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "client-id.1")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get(fiber.HeaderXRequestID); got != "client-id.1" {
+		t.Fatalf("X-Request-ID = %q, want the client's", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "bad id\nwith a newline")
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get(fiber.HeaderXRequestID); got == "" || strings.ContainsAny(got, " \n") {
+		t.Fatalf("X-Request-ID = %q, want a generated one", got)
+	}
+}
+
+func TestRequestLoggerRedactsSecretsInTheQuery(t *testing.T) {
+	buf := captureLogs(t)
+
+	if _, err := newLoggedApp().Test(httptest.NewRequest(http.MethodGet, "/ok?token=s3cret&page=2", nil)); err != nil {
+		t.Fatal(err)
+	}
+	line := logLine(t, buf)
+	if strings.Contains(buf.String(), "s3cret") {
+		t.Fatalf("token value logged: %s", buf.String())
+	}
+	if line["query"] != "page=2&token=REDACTED" || line["route"] != "/ok" || line["status"] != float64(200) || line["requestID"] == "" {
+		t.Fatalf("log line = %v", line)
+	}
+}
+
+func TestRequestLoggerLogsTheRenderedErrorStatus(t *testing.T) {
+	buf := captureLogs(t)
+
+	resp, err := newLoggedApp().Test(httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 from the error handler", resp.StatusCode)
+	}
+	line := logLine(t, buf)
+	if line["level"] != "warn" || line["status"] != float64(404) || line["error"] == nil {
+		t.Fatalf("log line = %v, want a warning with the 404 and its error", line)
+	}
+}
+
+func TestRequestLoggerQuietsProbes(t *testing.T) {
+	buf := captureLogs(t)
+
+	if _, err := newLoggedApp().Test(httptest.NewRequest(http.MethodGet, "/healthz", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("probe logged at info: %s", buf.String())
+	}
+}
+
+func TestRedactQueryDropsUnparseableQueries(t *testing.T) {
+	if got := redactQuery("token=%zz"); got != "[unparseable]" {
+		t.Fatalf("redactQuery = %q, want the query dropped", got)
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"fiber-crud/package/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics records the duration of every request by method, route template
+// and status.
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		err := c.Next()
+
+		metrics.HTTPRequestDuration.
+			WithLabelValues(c.Method(), routeLabel(c), strconv.Itoa(c.Response().StatusCode())).
+			Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// routeLabel returns the template of the route that handled the request, or
+// metrics.UnmatchedRoute when the request fell through to NotFound.
+func routeLabel(c *fiber.Ctx) string {
+	if unmatched, _ := c.Locals("routeUnmatched").(bool); unmatched {
+		return metrics.UnmatchedRoute
+	}
+	return c.Route().Path
+}
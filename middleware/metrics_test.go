@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fiber-crud/internal/domain/apperror"
+	"fiber-crud/package/metrics"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func newMeasuredApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(false)})
+	app.Use(Metrics(), RenderErrors())
+	app.Get("/widgets/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return apperror.NotFound("widget_not_found", "widget not found")
+		}
+		return c.SendString("ok")
+	})
+	app.Use(NotFound())
+	return app
+}
+
+// observed returns how many requests were recorded under the labels.
+func observed(t *testing.T, method, route, status string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := metrics.HTTPRequestDuration.WithLabelValues(method, route, status).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMetricsRecordsTheRouteTemplateAndRenderedStatus(t *testing.T) {
+	app := newMeasuredApp()
+	ok, notFound := observed(t, "GET", "/widgets/:id", "200"), observed(t, "GET", "/widgets/:id", "404")
+
+	for _, path := range []string{"/widgets/1", "/widgets/2", "/widgets/missing"} {
+		if _, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := observed(t, "GET", "/widgets/:id", "200") - ok; got != 2 {
+		t.Errorf("200 observations = %d, want 2 under the route template", got)
+	}
+	if got := observed(t, "GET", "/widgets/:id", "404") - notFound; got != 1 {
+		t.Errorf("404 observations = %d, want 1 for the error the handler returned", got)
+	}
+}
+
+func TestMetricsGroupsUnmatchedPaths(t *testing.T) {
+	app := newMeasuredApp()
+	before := observed(t, "GET", metrics.UnmatchedRoute, "404")
+
+	for _, path := range []string{"/wp-admin", "/.env"} {
+		if _, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := observed(t, "GET", metrics.UnmatchedRoute, "404") - before; got != 2 {
+		t.Fatalf("unmatched observations = %d, want both probes under %q", got, metrics.UnmatchedRoute)
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+
+	"fiber-crud/package/tracing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for every request, continuing the trace from
+// a traceparent header when there is one, and stores it in the user context
+// so handlers pass it on with c.UserContext(). Register it before
+// RenderErrors so the span carries the status the client receives.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		carrier := propagation.HeaderCarrier(http.Header(c.GetReqHeaders()))
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), carrier)
+
+		ctx, span := tracing.Tracer().Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+				semconv.ClientAddress(c.IP()),
+				semconv.UserAgentOriginal(c.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		chainErr := renderedError(c)
+
+		route := routeLabel(c)
+		status := c.Response().StatusCode()
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			semconv.HTTPRoute(route),
+			semconv.HTTPResponseStatusCode(status),
+		)
+		if requestID, ok := c.Locals("requestid").(string); ok {
+			span.SetAttributes(attribute.String("http.request.id", requestID))
+		}
+		if userID, ok := c.Locals("userID").(string); ok {
+			span.SetAttributes(semconv.EnduserID(userID))
+		}
+		// Client errors are the caller's problem, only 5xx marks the span as
+		// failed.
+		if status >= fiber.StatusInternalServerError {
+			if chainErr != nil {
+				span.RecordError(chainErr)
+			}
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return nil
+	}
+}
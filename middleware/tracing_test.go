@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordSpans installs a tracer provider that keeps finished spans in
+// memory until the test ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	return recorder
+}
+
+func newTracedApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(false)})
+	app.Use(Tracing(), RenderErrors())
+	app.Get("/widgets/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "broken" {
+			return errors.New("connection reset")
+		}
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingNamesTheSpanAfterTheRoute(t *testing.T) {
+	recorder := recordSpans(t)
+
+	if _, err := newTracedApp().Test(httptest.NewRequest(http.MethodGet, "/widgets/1", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /widgets/:id" {
+		t.Errorf("span name = %q, want the route template", span.Name())
+	}
+	if route := attributeValue(span, "http.route").AsString(); route != "/widgets/:id" {
+		t.Errorf("http.route = %q", route)
+	}
+	if status := attributeValue(span, "http.response.status_code").AsInt64(); status != http.StatusOK {
+		t.Errorf("http.response.status_code = %d, want 200", status)
+	}
+	if span.Status().Code == codes.Error {
+		t.Error("successful request marked as failed")
+	}
+}
+
+func TestTracingContinuesTheCallersTrace(t *testing.T) {
+	recorder := recordSpans(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/widgets/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if _, err := newTracedApp().Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	span := recorder.Ended()[0]
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the caller's", got)
+	}
+	if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("parent span ID = %s, want the caller's", got)
+	}
+}
+
+func TestTracingRecordsServerErrors(t *testing.T) {
+	recorder := recordSpans(t)
+
+	resp, err := newTracedApp().Test(httptest.NewRequest(http.MethodGet, "/widgets/broken", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", resp.StatusCode)
+	}
+
+	span := recorder.Ended()[0]
+	if span.Status().Code != codes.Error {
+		t.Errorf("span status = %v, want Error", span.Status())
+	}
+	if status := attributeValue(span, "http.response.status_code").AsInt64(); status != http.StatusInternalServerError {
+		t.Errorf("http.response.status_code = %d, want 500", status)
+	}
+	events := span.Events()
+	if len(events) != 1 || events[0].Name != "exception" {
+		t.Fatalf("events = %+v, want the handler error recorded", events)
+	}
+	for _, kv := range events[0].Attributes {
+		if kv.Key == "exception.message" && kv.Value.AsString() != "connection reset" {
+			t.Errorf("exception.message = %q, want the handler error", kv.Value.AsString())
+		}
+	}
+}
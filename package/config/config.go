@@ -0,0 +1,866 @@
+// Package config loads the typed settings the service needs at startup.
+//
+// Values are layered, later sources winning: built-in defaults, an optional
+// YAML file (CONFIG_FILE, or ./config.yaml when present), a .env file and
+// finally the process environment. Every secret can also be read from a
+// file by setting the variable with a _FILE suffix, e.g. DB_PASSWORD_FILE,
+// which is how Docker and Kubernetes mount secrets.
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigFile = "config.yaml"
+
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+type Config struct {
+	App        AppConfig        `yaml:"app"`
+	Log        LogConfig        `yaml:"log"`
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	JWT        JWTConfig        `yaml:"jwt"`
+	Cloudinary CloudinaryConfig `yaml:"cloudinary"`
+	Midtrans   MidtransConfig   `yaml:"midtrans"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	Mail       MailConfig       `yaml:"mail"`
+	OAuth      OAuthConfig      `yaml:"oauth"`
+	Account    AccountConfig    `yaml:"account"`
+	Retention  RetentionConfig  `yaml:"retention"`
+	Erasure    ErasureConfig    `yaml:"erasure"`
+}
+
+type AppConfig struct {
+	// Env is development, staging or production (APP_ENV).
+	Env string `yaml:"env"`
+}
+
+// Production reports whether internal details must be kept from clients.
+func (c AppConfig) Production() bool {
+	return c.Env == EnvProduction
+}
+
+type LogConfig struct {
+	// Level is trace, debug, info, warn or error (LOG_LEVEL). SQL
+	// statements are logged at debug.
+	Level string `yaml:"level"`
+	// Format is json or console (LOG_FORMAT); console is for humans.
+	Format string `yaml:"format"`
+}
+
+type ServerConfig struct {
+	Host string `yaml:"host"` // HOST
+	Port int    `yaml:"port"` // PORT
+	// MetricsPort serves /metrics on its own listener (METRICS_PORT) so
+	// scrapes never share the public port; keep it off the load balancer.
+	MetricsPort int `yaml:"metrics_port"`
+
+	ReadTimeout  time.Duration `yaml:"read_timeout"`  // HTTP_READ_TIMEOUT
+	WriteTimeout time.Duration `yaml:"write_timeout"` // HTTP_WRITE_TIMEOUT
+	// IdleTimeout also bounds how long shutdown waits on idle keep-alive
+	// connections (HTTP_IDLE_TIMEOUT).
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// ShutdownDelay is how long /readyz reports not ready before the server
+	// stops accepting connections, giving load balancers time to notice
+	// (SHUTDOWN_DELAY). A few seconds is typical behind Kubernetes.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+	// ShutdownTimeout is how long in-flight requests and background workers
+	// get to finish after SIGINT or SIGTERM (SHUTDOWN_TIMEOUT).
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	// TrustedProxies lists the addresses or CIDR ranges of the reverse
+	// proxies in front of the service (TRUSTED_PROXIES, comma separated).
+	// ProxyHeader is only read on requests coming from one of them; for
+	// everyone else the client address is the peer address.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// ProxyHeader carries the client address set by the proxy (PROXY_HEADER).
+	// The proxy must overwrite it: with several addresses the leftmost one
+	// is used, which the client controls when the proxy only appends.
+	ProxyHeader string `yaml:"proxy_header"`
+}
+
+// Addr is the address passed to app.Listen.
+func (c ServerConfig) Addr() string {
+	return c.Host + ":" + strconv.Itoa(c.Port)
+}
+
+// MetricsAddr is the address the metrics listener binds.
+func (c ServerConfig) MetricsAddr() string {
+	return c.Host + ":" + strconv.Itoa(c.MetricsPort)
+}
+
+type DatabaseConfig struct {
+	// URL is a complete connection string (DATABASE_URL). When set, the
+	// individual connection fields are ignored.
+	URL      string `yaml:"url"`
+	Host     string `yaml:"host"`     // DB_HOST
+	Port     int    `yaml:"port"`     // DB_PORT
+	User     string `yaml:"user"`     // DB_USER
+	Password string `yaml:"password"` // DB_PASSWORD
+	Name     string `yaml:"name"`     // DB_NAME
+	SSLMode  string `yaml:"sslmode"`  // DB_SSLMODE
+
+	MaxOpenConns    int           `yaml:"max_open_conns"`    // DB_MAX_OPEN_CONNS
+	MaxIdleConns    int           `yaml:"max_idle_conns"`    // DB_MAX_IDLE_CONNS
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"` // DB_CONN_MAX_LIFETIME
+	// SlowQueryThreshold logs slower queries as warnings (DB_SLOW_QUERY).
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+
+	// MigrateOnStart applies pending migrations when the server boots
+	// (DB_MIGRATE_ON_START). When off, the server refuses to start until
+	// "migrate up" has been run.
+	MigrateOnStart bool `yaml:"migrate_on_start"`
+}
+
+// DSN returns URL when set, otherwise a key/value connection string built
+// from the individual fields.
+func (c DatabaseConfig) DSN() string {
+	if c.URL != "" {
+		return c.URL
+	}
+
+	parts := []string{
+		"host=" + dsnValue(c.Host),
+		"port=" + strconv.Itoa(c.Port),
+		"user=" + dsnValue(c.User),
+		"dbname=" + dsnValue(c.Name),
+		"sslmode=" + dsnValue(c.SSLMode),
+	}
+	if c.Password != "" {
+		parts = append(parts, "password="+dsnValue(c.Password))
+	}
+	return strings.Join(parts, " ")
+}
+
+// dsnValue quotes a key/value connection string value when it is empty or
+// contains spaces, quotes or backslashes.
+func dsnValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+type JWTConfig struct {
+	// KeysFile points at a JSON key ring (JWT_KEYS_FILE). Without it a
+	// single HS256 key is built from Secret under KID.
+	KeysFile string `yaml:"keys_file"`
+	KID      string `yaml:"kid"`    // JWT_KID
+	Secret   string `yaml:"secret"` // JWT_SECRET
+	// Audience is the aud claim of access tokens (JWT_AUDIENCE). Services
+	// verifying them against the JWKS should require it.
+	Audience string `yaml:"audience"`
+}
+
+// CloudinaryConfig is optional; image uploads are refused when it is unset.
+type CloudinaryConfig struct {
+	CloudName string `yaml:"cloud_name"` // CLOUDINARY_CLOUD_NAME
+	APIKey    string `yaml:"api_key"`    // CLOUDINARY_API_KEY
+	APISecret string `yaml:"api_secret"` // CLOUDINARY_API_SECRET
+}
+
+func (c CloudinaryConfig) Enabled() bool {
+	return c.CloudName != "" || c.APIKey != "" || c.APISecret != ""
+}
+
+type MidtransConfig struct {
+	ServerKey string `yaml:"server_key"` // MIDTRANS_SERVER_KEY
+	ClientKey string `yaml:"client_key"` // MIDTRANS_CLIENT_KEY
+	// Environment is sandbox or production (MIDTRANS_ENV).
+	Environment string `yaml:"environment"`
+}
+
+// TracingConfig selects where spans go. The OTLP exporter reads its
+// endpoint, headers and TLS settings from the standard OTEL_EXPORTER_OTLP_*
+// variables.
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp (TRACING_EXPORTER).
+	Exporter    string `yaml:"exporter"`
+	ServiceName string `yaml:"service_name"` // OTEL_SERVICE_NAME
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1
+	// (TRACING_SAMPLE_RATIO). Requests arriving with a sampled parent are
+	// always recorded.
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+func (c TracingConfig) Enabled() bool {
+	return c.Exporter != "none"
+}
+
+type MailConfig struct {
+	// Driver is smtp or outbox (MAIL_DRIVER) and has no default. The outbox
+	// writes messages to OutboxDir instead of delivering them, so it has to
+	// be chosen explicitly and is refused in production.
+	Driver       string `yaml:"driver"`
+	From         string `yaml:"from"`          // MAIL_FROM
+	OutboxDir    string `yaml:"outbox_dir"`    // MAIL_OUTBOX_DIR
+	SMTPHost     string `yaml:"smtp_host"`     // SMTP_HOST
+	SMTPPort     int    `yaml:"smtp_port"`     // SMTP_PORT
+	SMTPUsername string `yaml:"smtp_username"` // SMTP_USERNAME
+	SMTPPassword string `yaml:"smtp_password"` // SMTP_PASSWORD
+}
+
+type OAuthConfig struct {
+	// StateSecret signs the state cookie (OAUTH_STATE_SECRET). Without it a
+	// random secret is generated, which only works while a single replica
+	// serves both the login and the callback.
+	StateSecret string `yaml:"state_secret"`
+	// AllowedRedirects are the frontend URLs a login may return to, the
+	// first being the default (OAUTH_ALLOWED_REDIRECTS, comma separated).
+	AllowedRedirects []string `yaml:"allowed_redirects"`
+	// CallbackBaseURL builds <base>/auth/oauth/<provider>/callback for providers
+	// without their own redirect URL (OAUTH_CALLBACK_BASE_URL).
+	CallbackBaseURL string `yaml:"callback_base_url"`
+	// CookieSecure marks the state cookie Secure (OAUTH_COOKIE_SECURE).
+	CookieSecure bool `yaml:"cookie_secure"`
+
+	Google OAuthClientConfig `yaml:"google"` // GOOGLE_*
+	GitHub OAuthClientConfig `yaml:"github"` // GITHUB_*
+	// OIDC lists generic OpenID Connect issuers. Their names come from
+	// OIDC_PROVIDERS and their settings from OIDC_<NAME>_*.
+	OIDC []OIDCProviderConfig `yaml:"oidc"`
+}
+
+// OAuthClientConfig is a provider the service is registered with. It is
+// disabled while ClientID is empty.
+type OAuthClientConfig struct {
+	ClientID     string `yaml:"client_id"`     // <PROVIDER>_CLIENT_ID
+	ClientSecret string `yaml:"client_secret"` // <PROVIDER>_CLIENT_SECRET
+	RedirectURL  string `yaml:"redirect_url"`  // <PROVIDER>_REDIRECT_URL
+}
+
+type OIDCProviderConfig struct {
+	Name              string `yaml:"name"`
+	DiscoveryURL      string `yaml:"discovery_url"` // OIDC_<NAME>_DISCOVERY_URL
+	OAuthClientConfig `yaml:",inline"`
+}
+
+// AccountConfig holds the links sent in account emails and the name shown
+// in authenticator apps.
+type AccountConfig struct {
+	PasswordResetURL string `yaml:"password_reset_url"` // PASSWORD_RESET_URL
+	EmailVerifyURL   string `yaml:"email_verify_url"`   // EMAIL_VERIFY_URL
+	TOTPIssuer       string `yaml:"totp_issuer"`        // TOTP_ISSUER
+	// TOTPEncryptionKey seals the TOTP secrets stored in the database
+	// (TOTP_ENCRYPTION_KEY): 32 random bytes, base64 encoded.
+	TOTPEncryptionKey string `yaml:"totp_encryption_key"`
+	// RequireEmailVerification refuses checkout to accounts whose address
+	// is not verified (REQUIRE_EMAIL_VERIFICATION).
+	RequireEmailVerification bool `yaml:"require_email_verification"`
+}
+
+// RetentionConfig drives the purge job for soft-deleted rows.
+type RetentionConfig struct {
+	// SoftDelete is how long deleted users, products and comments can still
+	// be restored (SOFT_DELETE_RETENTION).
+	SoftDelete time.Duration `yaml:"soft_delete"`
+	// PurgeInterval is how often the job runs (PURGE_INTERVAL).
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+// ErasureConfig drives the job that anonymizes accounts whose owners asked
+// for their erasure.
+type ErasureConfig struct {
+	// CoolingOff is how long a request can still be cancelled
+	// (ERASURE_COOLING_OFF).
+	CoolingOff time.Duration `yaml:"cooling_off"`
+	// Interval is how often the job runs (ERASURE_INTERVAL).
+	Interval time.Duration `yaml:"interval"`
+}
+
+func Default() Config {
+	return Config{
+		App: AppConfig{Env: EnvDevelopment},
+		Log: LogConfig{Level: "info", Format: "json"},
+		Server: ServerConfig{
+			Port:            3000,
+			MetricsPort:     9090,
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+			ProxyHeader:     "X-Real-IP",
+		},
+		Database: DatabaseConfig{
+			Host:               "localhost",
+			Port:               5432,
+			User:               "postgres",
+			Name:               "postgres",
+			SSLMode:            "disable",
+			MaxOpenConns:       25,
+			MaxIdleConns:       5,
+			ConnMaxLifetime:    30 * time.Minute,
+			SlowQueryThreshold: 200 * time.Millisecond,
+			MigrateOnStart:     true,
+		},
+		JWT:      JWTConfig{KID: "default", Audience: "fiber-crud"},
+		Midtrans: MidtransConfig{Environment: "sandbox"},
+		Tracing:  TracingConfig{Exporter: "none", ServiceName: "fiber-crud", SampleRatio: 1},
+		Mail:     MailConfig{OutboxDir: "tmp/outbox", SMTPPort: 587},
+		OAuth:    OAuthConfig{CookieSecure: true},
+		Account: AccountConfig{
+			PasswordResetURL: "http://localhost:3000/reset-password",
+			EmailVerifyURL:   "http://localhost:3000/verify-email",
+			TOTPIssuer:       "fiber-crud",
+		},
+		Retention: RetentionConfig{SoftDelete: 30 * 24 * time.Hour, PurgeInterval: time.Hour},
+		Erasure:   ErasureConfig{CoolingOff: 14 * 24 * time.Hour, Interval: time.Hour},
+	}
+}
+
+// Load reads and validates the configuration. The returned error lists
+// every problem found, not only the first one.
+func Load() (Config, error) {
+	cfg, err := Read()
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration:\n%v", err)
+	}
+	return cfg, nil
+}
+
+// Read layers the configuration sources without validating the result.
+// Tools that only need part of the configuration validate that section
+// themselves.
+func Read() (Config, error) {
+	// A missing .env file is fine, the variables may come from the process
+	// environment instead.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("error loading .env file: %v", err)
+	}
+
+	cfg := Default()
+	if err := loadFile(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	env := &envReader{}
+	env.string(&cfg.App.Env, "APP_ENV")
+
+	env.string(&cfg.Log.Level, "LOG_LEVEL")
+	env.string(&cfg.Log.Format, "LOG_FORMAT")
+
+	env.string(&cfg.Server.Host, "HOST")
+	env.int(&cfg.Server.Port, "PORT")
+	env.int(&cfg.Server.MetricsPort, "METRICS_PORT")
+	env.duration(&cfg.Server.ReadTimeout, "HTTP_READ_TIMEOUT")
+	env.duration(&cfg.Server.WriteTimeout, "HTTP_WRITE_TIMEOUT")
+	env.duration(&cfg.Server.IdleTimeout, "HTTP_IDLE_TIMEOUT")
+	env.duration(&cfg.Server.ShutdownDelay, "SHUTDOWN_DELAY")
+	env.duration(&cfg.Server.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+	env.list(&cfg.Server.TrustedProxies, "TRUSTED_PROXIES")
+	env.string(&cfg.Server.ProxyHeader, "PROXY_HEADER")
+
+	env.secret(&cfg.Database.URL, "DATABASE_URL")
+	env.string(&cfg.Database.Host, "DB_HOST")
+	env.int(&cfg.Database.Port, "DB_PORT")
+	env.string(&cfg.Database.User, "DB_USER")
+	env.secret(&cfg.Database.Password, "DB_PASSWORD")
+	env.string(&cfg.Database.Name, "DB_NAME")
+	env.string(&cfg.Database.SSLMode, "DB_SSLMODE")
+	env.int(&cfg.Database.MaxOpenConns, "DB_MAX_OPEN_CONNS")
+	env.int(&cfg.Database.MaxIdleConns, "DB_MAX_IDLE_CONNS")
+	env.duration(&cfg.Database.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME")
+	env.duration(&cfg.Database.SlowQueryThreshold, "DB_SLOW_QUERY")
+	env.bool(&cfg.Database.MigrateOnStart, "DB_MIGRATE_ON_START")
+
+	env.string(&cfg.JWT.KeysFile, "JWT_KEYS_FILE")
+	env.string(&cfg.JWT.KID, "JWT_KID")
+	env.secret(&cfg.JWT.Secret, "JWT_SECRET")
+	env.string(&cfg.JWT.Audience, "JWT_AUDIENCE")
+
+	env.string(&cfg.Cloudinary.CloudName, "CLOUDINARY_CLOUD_NAME")
+	env.string(&cfg.Cloudinary.APIKey, "CLOUDINARY_API_KEY")
+	env.secret(&cfg.Cloudinary.APISecret, "CLOUDINARY_API_SECRET")
+
+	env.secret(&cfg.Midtrans.ServerKey, "MIDTRANS_SERVER_KEY")
+	env.string(&cfg.Midtrans.ClientKey, "MIDTRANS_CLIENT_KEY")
+	env.string(&cfg.Midtrans.Environment, "MIDTRANS_ENV")
+
+	env.string(&cfg.Tracing.Exporter, "TRACING_EXPORTER")
+	env.string(&cfg.Tracing.ServiceName, "OTEL_SERVICE_NAME")
+	env.float(&cfg.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO")
+
+	env.string(&cfg.Mail.Driver, "MAIL_DRIVER")
+	env.string(&cfg.Mail.From, "MAIL_FROM")
+	env.string(&cfg.Mail.OutboxDir, "MAIL_OUTBOX_DIR")
+	env.string(&cfg.Mail.SMTPHost, "SMTP_HOST")
+	env.int(&cfg.Mail.SMTPPort, "SMTP_PORT")
+	env.string(&cfg.Mail.SMTPUsername, "SMTP_USERNAME")
+	env.secret(&cfg.Mail.SMTPPassword, "SMTP_PASSWORD")
+
+	env.secret(&cfg.OAuth.StateSecret, "OAUTH_STATE_SECRET")
+	env.list(&cfg.OAuth.AllowedRedirects, "OAUTH_ALLOWED_REDIRECTS")
+	env.string(&cfg.OAuth.CallbackBaseURL, "OAUTH_CALLBACK_BASE_URL")
+	env.bool(&cfg.OAuth.CookieSecure, "OAUTH_COOKIE_SECURE")
+	env.oauthClient(&cfg.OAuth.Google, "GOOGLE")
+	env.oauthClient(&cfg.OAuth.GitHub, "GITHUB")
+	env.oidcProviders(&cfg.OAuth.OIDC)
+
+	env.string(&cfg.Account.PasswordResetURL, "PASSWORD_RESET_URL")
+	env.string(&cfg.Account.EmailVerifyURL, "EMAIL_VERIFY_URL")
+	env.string(&cfg.Account.TOTPIssuer, "TOTP_ISSUER")
+	env.secret(&cfg.Account.TOTPEncryptionKey, "TOTP_ENCRYPTION_KEY")
+	env.bool(&cfg.Account.RequireEmailVerification, "REQUIRE_EMAIL_VERIFICATION")
+
+	env.duration(&cfg.Retention.SoftDelete, "SOFT_DELETE_RETENTION")
+	env.duration(&cfg.Retention.PurgeInterval, "PURGE_INTERVAL")
+
+	env.duration(&cfg.Erasure.CoolingOff, "ERASURE_COOLING_OFF")
+	env.duration(&cfg.Erasure.Interval, "ERASURE_INTERVAL")
+
+	if err := errors.Join(env.errs...); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration:\n%v", err)
+	}
+	return cfg, nil
+}
+
+// loadFile applies CONFIG_FILE, or config.yaml when it exists. Naming a
+// file in CONFIG_FILE that does not exist is an error.
+func loadFile(cfg *Config) error {
+	path, required := os.Getenv("CONFIG_FILE"), true
+	if path == "" {
+		path, required = defaultConfigFile, false
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// Validate checks the configuration as a whole.
+func (c Config) Validate() error {
+	errs := []error{
+		c.App.Validate(),
+		c.Log.Validate(),
+		c.Server.Validate(),
+		c.Database.Validate(),
+		c.JWT.Validate(),
+		c.Cloudinary.Validate(),
+		c.Midtrans.Validate(),
+		c.Tracing.Validate(),
+		c.Mail.Validate(c.App.Production()),
+		c.OAuth.Validate(),
+		c.Account.Validate(),
+		c.Retention.Validate(),
+		c.Erasure.Validate(),
+	}
+	return errors.Join(errs...)
+}
+
+func (c AppConfig) Validate() error {
+	switch c.Env {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+		return nil
+	}
+	return fmt.Errorf("APP_ENV must be one of development, staging, production; got %q", c.Env)
+}
+
+func (c LogConfig) Validate() error {
+	var p problems
+	switch c.Level {
+	case "trace", "debug", "info", "warn", "error":
+	default:
+		p.add("LOG_LEVEL must be one of trace, debug, info, warn, error; got %q", c.Level)
+	}
+	if c.Format != "json" && c.Format != "console" {
+		p.add("LOG_FORMAT must be json or console; got %q", c.Format)
+	}
+	return p.err()
+}
+
+func (c ServerConfig) Validate() error {
+	var p problems
+	if c.Port < 1 || c.Port > 65535 {
+		p.add("PORT must be between 1 and 65535; got %d", c.Port)
+	}
+	if c.MetricsPort < 1 || c.MetricsPort > 65535 {
+		p.add("METRICS_PORT must be between 1 and 65535; got %d", c.MetricsPort)
+	} else if c.MetricsPort == c.Port {
+		p.add("METRICS_PORT must differ from PORT")
+	}
+	if c.ReadTimeout <= 0 || c.WriteTimeout <= 0 || c.IdleTimeout <= 0 {
+		p.add("HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT must be positive")
+	}
+	if c.ShutdownDelay < 0 {
+		p.add("SHUTDOWN_DELAY must not be negative")
+	}
+	if c.ShutdownTimeout <= 0 {
+		p.add("SHUTDOWN_TIMEOUT must be positive")
+	}
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			p.add("TRUSTED_PROXIES contains an invalid address or CIDR range: %q", proxy)
+		}
+	}
+	if len(c.TrustedProxies) > 0 && c.ProxyHeader == "" {
+		p.add("PROXY_HEADER must not be empty when TRUSTED_PROXIES is set")
+	}
+	return p.err()
+}
+
+func (c DatabaseConfig) Validate() error {
+	var p problems
+	if c.URL == "" {
+		if c.Host == "" {
+			p.add("DB_HOST is required when DATABASE_URL is not set")
+		}
+		if c.User == "" {
+			p.add("DB_USER is required when DATABASE_URL is not set")
+		}
+		if c.Name == "" {
+			p.add("DB_NAME is required when DATABASE_URL is not set")
+		}
+		if c.Port < 1 || c.Port > 65535 {
+			p.add("DB_PORT must be between 1 and 65535; got %d", c.Port)
+		}
+		switch c.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			p.add("DB_SSLMODE must be one of disable, allow, prefer, require, verify-ca, verify-full; got %q", c.SSLMode)
+		}
+	}
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		p.add("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative")
+	}
+	if c.ConnMaxLifetime < 0 {
+		p.add("DB_CONN_MAX_LIFETIME must not be negative")
+	}
+	if c.SlowQueryThreshold < 0 {
+		p.add("DB_SLOW_QUERY must not be negative")
+	}
+	return p.err()
+}
+
+// Validate requires a long secret in every environment: a guessable HS256
+// key would let anyone mint tokens, and staging keys tend to leak.
+func (c JWTConfig) Validate() error {
+	var p problems
+	if c.Audience == "" {
+		p.add("JWT_AUDIENCE must not be empty")
+	}
+	if c.KeysFile != "" {
+		return p.err()
+	}
+
+	if c.Secret == "" {
+		p.add("no JWT signing key configured: set JWT_KEYS_FILE, JWT_SECRET or JWT_SECRET_FILE")
+	} else if len(c.Secret) < 32 {
+		p.add("JWT_SECRET must be at least 32 bytes")
+	}
+	if c.KID == "" {
+		p.add("JWT_KID must not be empty")
+	}
+	return p.err()
+}
+
+func (c CloudinaryConfig) Validate() error {
+	if c.Enabled() && (c.CloudName == "" || c.APIKey == "" || c.APISecret == "") {
+		return errors.New("CLOUDINARY_CLOUD_NAME, CLOUDINARY_API_KEY and CLOUDINARY_API_SECRET must be set together")
+	}
+	return nil
+}
+
+func (c MidtransConfig) Validate() error {
+	var p problems
+	if c.ServerKey == "" {
+		p.add("MIDTRANS_SERVER_KEY is required")
+	}
+	switch c.Environment {
+	case "sandbox", "production":
+	default:
+		p.add("MIDTRANS_ENV must be sandbox or production; got %q", c.Environment)
+	}
+	return p.err()
+}
+
+func (c TracingConfig) Validate() error {
+	var p problems
+	switch c.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		p.add("TRACING_EXPORTER must be one of none, stdout, otlp; got %q", c.Exporter)
+	}
+	if c.ServiceName == "" {
+		p.add("OTEL_SERVICE_NAME must not be empty")
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		p.add("TRACING_SAMPLE_RATIO must be between 0 and 1; got %g", c.SampleRatio)
+	}
+	return p.err()
+}
+
+func (c MailConfig) Validate(production bool) error {
+	var p problems
+	switch c.Driver {
+	case "smtp":
+		if c.SMTPHost == "" || c.From == "" {
+			p.add("SMTP_HOST and MAIL_FROM are required for the smtp mail driver")
+		}
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			p.add("SMTP_PORT must be between 1 and 65535; got %d", c.SMTPPort)
+		}
+	case "outbox":
+		if production {
+			p.add("MAIL_DRIVER=outbox does not deliver email and cannot be used in production")
+		}
+		if c.OutboxDir == "" {
+			p.add("MAIL_OUTBOX_DIR must not be empty for the outbox mail driver")
+		}
+	case "":
+		p.add("MAIL_DRIVER is required: smtp, or outbox to write messages to MAIL_OUTBOX_DIR")
+	default:
+		p.add("MAIL_DRIVER must be smtp or outbox; got %q", c.Driver)
+	}
+	return p.err()
+}
+
+func (c OAuthConfig) Validate() error {
+	var p problems
+	for _, raw := range c.AllowedRedirects {
+		if !absoluteURL(raw) {
+			p.add("OAUTH_ALLOWED_REDIRECTS contains an invalid URL: %q", raw)
+		}
+	}
+
+	c.Google.validate(&p, "GOOGLE", c.CallbackBaseURL)
+	c.GitHub.validate(&p, "GITHUB", c.CallbackBaseURL)
+	for _, provider := range c.OIDC {
+		prefix := "OIDC_" + strings.ToUpper(provider.Name)
+		if provider.DiscoveryURL == "" || provider.ClientID == "" {
+			p.add("%s_DISCOVERY_URL and %s_CLIENT_ID are required", prefix, prefix)
+			continue
+		}
+		provider.OAuthClientConfig.validate(&p, prefix, c.CallbackBaseURL)
+	}
+	return p.err()
+}
+
+func (c OAuthClientConfig) validate(p *problems, prefix, callbackBaseURL string) {
+	if c.ClientID == "" {
+		return
+	}
+	if c.ClientSecret == "" {
+		p.add("%s_CLIENT_SECRET is required when %s_CLIENT_ID is set", prefix, prefix)
+	}
+	if c.RedirectURL == "" && callbackBaseURL == "" {
+		p.add("%s_REDIRECT_URL or OAUTH_CALLBACK_BASE_URL is required", prefix)
+	}
+}
+
+func (c AccountConfig) Validate() error {
+	var p problems
+	if !absoluteURL(c.PasswordResetURL) {
+		p.add("PASSWORD_RESET_URL must be an absolute URL; got %q", c.PasswordResetURL)
+	}
+	if !absoluteURL(c.EmailVerifyURL) {
+		p.add("EMAIL_VERIFY_URL must be an absolute URL; got %q", c.EmailVerifyURL)
+	}
+	if c.TOTPIssuer == "" {
+		p.add("TOTP_ISSUER must not be empty")
+	}
+	if key, err := base64.StdEncoding.DecodeString(c.TOTPEncryptionKey); err != nil || len(key) != 32 {
+		p.add("TOTP_ENCRYPTION_KEY must be 32 bytes, base64 encoded (openssl rand -base64 32)")
+	}
+	return p.err()
+}
+
+func (c RetentionConfig) Validate() error {
+	var p problems
+	if c.SoftDelete <= 0 {
+		p.add("SOFT_DELETE_RETENTION must be positive; got %v", c.SoftDelete)
+	}
+	if c.PurgeInterval <= 0 {
+		p.add("PURGE_INTERVAL must be positive; got %v", c.PurgeInterval)
+	}
+	return p.err()
+}
+
+func (c ErasureConfig) Validate() error {
+	var p problems
+	if c.CoolingOff <= 0 {
+		p.add("ERASURE_COOLING_OFF must be positive; got %v", c.CoolingOff)
+	}
+	if c.Interval <= 0 {
+		p.add("ERASURE_INTERVAL must be positive; got %v", c.Interval)
+	}
+	return p.err()
+}
+
+func absoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// problems collects every failed check of a section.
+type problems []error
+
+func (p *problems) add(format string, args ...interface{}) {
+	*p = append(*p, fmt.Errorf(format, args...))
+}
+
+func (p problems) err() error {
+	return errors.Join(p...)
+}
+
+// envReader overrides config values with environment variables that are
+// set, collecting parse errors instead of stopping at the first one.
+type envReader struct {
+	errs []error
+}
+
+func (r *envReader) string(dst *string, key string) {
+	if value, ok := os.LookupEnv(key); ok {
+		*dst = value
+	}
+}
+
+func (r *envReader) int(dst *int, key string) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be an integer; got %q", key, value))
+		return
+	}
+	*dst = n
+}
+
+func (r *envReader) bool(dst *bool, key string) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be true or false; got %q", key, value))
+		return
+	}
+	*dst = b
+}
+
+// list reads a comma separated value, dropping empty entries.
+func (r *envReader) list(dst *[]string, key string) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	*dst = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*dst = append(*dst, item)
+		}
+	}
+}
+
+func (r *envReader) oauthClient(dst *OAuthClientConfig, prefix string) {
+	r.string(&dst.ClientID, prefix+"_CLIENT_ID")
+	r.secret(&dst.ClientSecret, prefix+"_CLIENT_SECRET")
+	r.string(&dst.RedirectURL, prefix+"_REDIRECT_URL")
+}
+
+// oidcProviders replaces the configured issuers with those named in
+// OIDC_PROVIDERS, keeping file settings for names listed in both.
+func (r *envReader) oidcProviders(dst *[]OIDCProviderConfig) {
+	var names []string
+	r.list(&names, "OIDC_PROVIDERS")
+	if names == nil {
+		return
+	}
+
+	existing := map[string]OIDCProviderConfig{}
+	for _, provider := range *dst {
+		existing[provider.Name] = provider
+	}
+	*dst = nil
+	for _, name := range names {
+		name = strings.ToLower(name)
+		provider := existing[name]
+		provider.Name = name
+		prefix := "OIDC_" + strings.ToUpper(name)
+		r.string(&provider.DiscoveryURL, prefix+"_DISCOVERY_URL")
+		r.oauthClient(&provider.OAuthClientConfig, prefix)
+		*dst = append(*dst, provider)
+	}
+}
+
+func (r *envReader) float(dst *float64, key string) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a number; got %q", key, value))
+		return
+	}
+	*dst = f
+}
+
+func (r *envReader) duration(dst *time.Duration, key string) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a duration such as 30m; got %q", key, value))
+		return
+	}
+	*dst = d
+}
+
+// secret reads key, or the file named by key_FILE. Setting both is an
+// error so a stale value cannot silently shadow the mounted secret.
+func (r *envReader) secret(dst *string, key string) {
+	file, hasFile := os.LookupEnv(key + "_FILE")
+	if !hasFile {
+		r.string(dst, key)
+		return
+	}
+	if _, ok := os.LookupEnv(key); ok {
+		r.errs = append(r.errs, fmt.Errorf("set only one of %s and %s_FILE", key, key))
+		return
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s_FILE: %v", key, err))
+		return
+	}
+	*dst = strings.TrimSpace(string(data))
+}
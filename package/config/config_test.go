@@ -0,0 +1,211 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setValidEnv sets the variables without a usable default, so each test
+// only has to break the setting it is about.
+func setValidEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", strings.Repeat("k", 32))
+	t.Setenv("MIDTRANS_SERVER_KEY", "server-key")
+	t.Setenv("MAIL_DRIVER", "outbox")
+	t.Setenv("TOTP_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadError(t *testing.T) string {
+	t.Helper()
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load accepted an invalid configuration")
+	}
+	return err.Error()
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setValidEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Addr() != ":3000" || cfg.Retention.SoftDelete != 30*24*time.Hour || cfg.Erasure.CoolingOff != 14*24*time.Hour {
+		t.Fatalf("defaults = %+v", cfg)
+	}
+	if !cfg.OAuth.CookieSecure || cfg.Account.RequireEmailVerification {
+		t.Fatalf("OAuth cookie secure = %v, require verification = %v; want true, false", cfg.OAuth.CookieSecure, cfg.Account.RequireEmailVerification)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("PORT", "70000")
+	t.Setenv("PURGE_INTERVAL", "0s")
+	t.Setenv("ERASURE_COOLING_OFF", "-1h")
+	t.Setenv("SHUTDOWN_TIMEOUT", "0s")
+
+	msg := loadError(t)
+	for _, want := range []string{"APP_ENV", "PORT", "PURGE_INTERVAL", "ERASURE_COOLING_OFF", "SHUTDOWN_TIMEOUT"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error does not mention %s:\n%s", want, msg)
+		}
+	}
+}
+
+func TestLoadRejectsUnparsableValues(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("DB_PORT", "five")
+	t.Setenv("OAUTH_COOKIE_SECURE", "sometimes")
+
+	msg := loadError(t)
+	if !strings.Contains(msg, "DB_PORT") || !strings.Contains(msg, "OAUTH_COOKIE_SECURE") {
+		t.Fatalf("error = %s, want both unparsable variables", msg)
+	}
+}
+
+func TestEnvironmentOverridesFile(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", `
+server:
+  port: 4000
+jwt:
+  kid: from-file
+retention:
+  soft_delete: 48h
+`))
+	t.Setenv("PORT", "5000")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Port != 5000 {
+		t.Errorf("port = %d, want 5000 from the environment", cfg.Server.Port)
+	}
+	if cfg.JWT.KID != "from-file" || cfg.Retention.SoftDelete != 48*time.Hour {
+		t.Errorf("kid = %q, retention = %v; want the file values", cfg.JWT.KID, cfg.Retention.SoftDelete)
+	}
+}
+
+func TestFileRejectsUnknownKeys(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", "server:\n  prot: 4000\n"))
+
+	if _, err := Read(); err == nil {
+		t.Fatal("misspelled key in the config file was accepted")
+	}
+}
+
+func TestSecretFromFile(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "s3cret\n"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Database.Password != "s3cret" {
+		t.Fatalf("password = %q, want the trimmed file content", cfg.Database.Password)
+	}
+}
+
+func TestSecretSetTwiceIsAnError(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt_secret", strings.Repeat("f", 32)))
+
+	if msg := loadError(t); !strings.Contains(msg, "set only one of JWT_SECRET and JWT_SECRET_FILE") {
+		t.Fatalf("error = %s", msg)
+	}
+}
+
+func TestShortJWTSecretIsRejectedOutsideProduction(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("APP_ENV", EnvDevelopment)
+	t.Setenv("JWT_SECRET", "too-short")
+
+	if msg := loadError(t); !strings.Contains(msg, "JWT_SECRET must be at least 32 bytes") {
+		t.Fatalf("error = %s", msg)
+	}
+}
+
+func TestMailDriverIsRequired(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("MAIL_DRIVER", "")
+
+	if msg := loadError(t); !strings.Contains(msg, "MAIL_DRIVER is required") {
+		t.Fatalf("error = %s", msg)
+	}
+}
+
+func TestOutboxMailerIsRefusedInProduction(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("APP_ENV", EnvProduction)
+
+	if msg := loadError(t); !strings.Contains(msg, "MAIL_DRIVER=outbox") {
+		t.Fatalf("error = %s", msg)
+	}
+}
+
+func TestTrustedProxiesMustBeAddresses(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, proxy.internal")
+
+	if msg := loadError(t); !strings.Contains(msg, `"proxy.internal"`) || strings.Contains(msg, "10.0.0.0/8") {
+		t.Fatalf("error = %s, want only the host name rejected", msg)
+	}
+}
+
+func TestOIDCProvidersFromEnvironment(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("OIDC_PROVIDERS", "Okta")
+	t.Setenv("OIDC_OKTA_DISCOVERY_URL", "https://okta.example.com/.well-known/openid-configuration")
+	t.Setenv("OIDC_OKTA_CLIENT_ID", "client")
+	t.Setenv("OIDC_OKTA_CLIENT_SECRET", "secret")
+	t.Setenv("OAUTH_CALLBACK_BASE_URL", "https://api.example.com")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.OAuth.OIDC) != 1 || cfg.OAuth.OIDC[0].Name != "okta" || cfg.OAuth.OIDC[0].ClientSecret != "secret" {
+		t.Fatalf("OIDC providers = %+v", cfg.OAuth.OIDC)
+	}
+}
+
+func TestMetricsPortMustDifferFromPort(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("PORT", "9090")
+
+	if msg := loadError(t); !strings.Contains(msg, "METRICS_PORT must differ from PORT") {
+		t.Fatalf("error = %s", msg)
+	}
+}
+
+func TestTracingSettingsAreValidated(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("TRACING_EXPORTER", "jaeger")
+	t.Setenv("TRACING_SAMPLE_RATIO", "1.5")
+
+	msg := loadError(t)
+	for _, want := range []string{"TRACING_EXPORTER must be one of none, stdout, otlp", "TRACING_SAMPLE_RATIO must be between 0 and 1"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("error = %s, want it to mention %q", msg, want)
+		}
+	}
+}
@@ -1,37 +1,32 @@
 package db
 
 import (
-	cartModels "fiber-crud/internal/domain/cart"
-	CommentModels "fiber-crud/internal/domain/comment"
-	paymentModels "fiber-crud/internal/domain/payment"
-	ProductModels "fiber-crud/internal/domain/product"
-	userModels "fiber-crud/internal/domain/user"
+	"fiber-crud/package/config"
+	"fiber-crud/package/logger"
 	"log"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func InitDB() *gorm.DB {
+// InitDB opens the connection pool. The schema is managed by the migrate
+// package, not here.
+func InitDB(cfg config.DatabaseConfig) *gorm.DB {
 
-	dsn := "host=localhost user=postgres password=maulana dbname=jajalaja port=5432 sslmode=disable"
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+		Logger: logger.NewGormLogger(cfg.SlowQueryThreshold),
+	})
 	if err != nil {
 		log.Fatalf("Gagal menghubungkan ke database: %v", err)
 	}
 
-	db = db.Debug()
-
-	if err := db.AutoMigrate(
-		&userModels.User{},
-		&ProductModels.Product{},
-		&CommentModels.Comment{},
-		&cartModels.CartModels{},
-		&paymentModels.PaymentModels{},
-	); err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to access database pool: %v", err)
 	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	return db
 }
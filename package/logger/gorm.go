@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger sends GORM's output to zerolog: statements at debug, slow
+// statements as warnings and failed statements as errors. Bound values are
+// never logged because they include password hashes and token digests.
+type GormLogger struct {
+	slowThreshold time.Duration
+}
+
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{slowThreshold: slowThreshold}
+}
+
+// LogMode is a no-op; the level comes from the global zerolog level.
+func (l *GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	log.Info().Msgf(msg, args...)
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	log.Warn().Msgf(msg, args...)
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	log.Error().Msgf(msg, args...)
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+
+	var event *zerolog.Event
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		event = log.Error().Err(err)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold:
+		event = log.Warn().Bool("slow", true)
+	default:
+		event = log.Debug()
+	}
+	if !event.Enabled() {
+		return
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		event.Str("traceID", spanContext.TraceID().String())
+	}
+	sql, rows := fc()
+	event.Str("sql", sql).
+		Int64("rows", rows).
+		Dur("elapsed", elapsed).
+		Msg("gorm::Trace - Query executed")
+}
+
+// ParamsFilter keeps the placeholders in logged SQL instead of the values.
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+func captureLogs(t *testing.T, level zerolog.Level) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous, previousLevel := log.Logger, zerolog.GlobalLevel()
+	log.Logger = zerolog.New(&buf)
+	zerolog.SetGlobalLevel(level)
+	t.Cleanup(func() {
+		log.Logger = previous
+		zerolog.SetGlobalLevel(previousLevel)
+	})
+	return &buf
+}
+
+func statement() (string, int64) {
+	return `SELECT * FROM "users" WHERE email = $1`, 1
+}
+
+func TestTraceLogsStatementsAtDebugOnly(t *testing.T) {
+	buf := captureLogs(t, zerolog.InfoLevel)
+	NewGormLogger(time.Second).Trace(context.Background(), time.Now(), statement, nil)
+	if buf.Len() != 0 {
+		t.Fatalf("statement logged at info: %s", buf.String())
+	}
+
+	buf = captureLogs(t, zerolog.DebugLevel)
+	NewGormLogger(time.Second).Trace(context.Background(), time.Now(), statement, nil)
+	if !strings.Contains(buf.String(), `"level":"debug"`) || !strings.Contains(buf.String(), "$1") {
+		t.Fatalf("debug output = %s, want the statement with its placeholder", buf.String())
+	}
+}
+
+func TestTraceWarnsAboutSlowStatements(t *testing.T) {
+	buf := captureLogs(t, zerolog.InfoLevel)
+	NewGormLogger(time.Millisecond).Trace(context.Background(), time.Now().Add(-time.Second), statement, nil)
+	if !strings.Contains(buf.String(), `"level":"warn"`) || !strings.Contains(buf.String(), `"slow":true`) {
+		t.Fatalf("output = %s, want a slow warning", buf.String())
+	}
+}
+
+func TestTraceLogsFailuresButNotMissingRecords(t *testing.T) {
+	buf := captureLogs(t, zerolog.InfoLevel)
+	l := NewGormLogger(time.Second)
+
+	l.Trace(context.Background(), time.Now(), statement, gorm.ErrRecordNotFound)
+	if buf.Len() != 0 {
+		t.Fatalf("missing record logged: %s", buf.String())
+	}
+	l.Trace(context.Background(), time.Now(), statement, errors.New("deadlock detected"))
+	if !strings.Contains(buf.String(), `"level":"error"`) || !strings.Contains(buf.String(), "deadlock detected") {
+		t.Fatalf("output = %s, want the failure as an error", buf.String())
+	}
+}
+
+func TestParamsFilterDropsBoundValues(t *testing.T) {
+	sql, params := NewGormLogger(0).ParamsFilter(context.Background(), "UPDATE users SET password = ?", "$2a$10$hash")
+	if sql != "UPDATE users SET password = ?" || params != nil {
+		t.Fatalf("ParamsFilter = %q, %v; want the values dropped", sql, params)
+	}
+}
@@ -0,0 +1,35 @@
+// Package logger configures the global zerolog logger and adapts it for
+// the libraries that bring their own logging interface.
+package logger
+
+import (
+	stdlog "log"
+	"os"
+	"time"
+
+	"fiber-crud/package/config"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Setup applies the configured level and format to the global logger and
+// routes the standard library logger through it.
+func Setup(cfg config.LogConfig) error {
+	level, err := zerolog.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(level)
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+
+	logger := zerolog.New(os.Stderr)
+	if cfg.Format == "console" {
+		logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.TimeOnly})
+	}
+	log.Logger = logger.With().Timestamp().Logger()
+
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(log.Logger)
+	return nil
+}
@@ -0,0 +1,24 @@
+package mailer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	// Nothing listens on port 1; a message that got past the checks would
+	// fail to connect instead.
+	m, err := NewSMTPMailer("127.0.0.1", "1", "", "", "noreply@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []Message{
+		{To: "victim@example.com\r\nBcc: attacker@example.com", Subject: "Reset your password"},
+		{To: "victim@example.com", Subject: "Reset\nBcc: attacker@example.com"},
+	} {
+		if err := m.Send(msg); !errors.Is(err, errHeaderInjection) {
+			t.Errorf("Send(%q, %q) = %v, want errHeaderInjection", msg.To, msg.Subject, err)
+		}
+	}
+}
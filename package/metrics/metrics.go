@@ -0,0 +1,62 @@
+// Package metrics holds the Prometheus collectors exported on /metrics.
+// Everything is registered on the default registry, which also carries the
+// Go runtime and process collectors.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// UnmatchedRoute labels requests that hit no route, so probing random paths
+// cannot grow the route label without bound.
+const UnmatchedRoute = "unmatched"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time spent serving HTTP requests, by route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests currently being served.",
+	})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Time spent in GORM database operations.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "status"})
+
+	CartsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "carts_created_total",
+		Help: "Cart entries created for a product not yet in the user's cart.",
+	})
+
+	PaymentsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "payments_created_total",
+		Help: "Payments created and accepted by the payment gateway.",
+	})
+
+	PaymentsSettled = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "payments_settled_total",
+		Help: "Payments the gateway reported as settled or captured.",
+	})
+
+	PaymentsFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "payments_failed_total",
+		Help: "Payments the gateway reported as denied, cancelled, expired or failed.",
+	})
+
+	StockDecrements = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "product_stock_decrements_total",
+		Help: "Successful stock decrements.",
+	})
+
+	StockUnitsDecremented = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "product_stock_units_decremented_total",
+		Help: "Units removed from product stock.",
+	})
+)
@@ -0,0 +1,214 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func file(body string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(body)}
+}
+
+func TestLoadSortsByVersion(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"0010_later.up.sql":     file("SELECT 10;"),
+		"0010_later.down.sql":   file("SELECT -10;"),
+		"0002_earlier.up.sql":   file("SELECT 2;"),
+		"0002_earlier.down.sql": file("SELECT -2;"),
+		"README.md":             file("not a migration"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Version != 10 {
+		t.Fatalf("migrations = %+v, want versions 2 and 10 in order", migrations)
+	}
+	if migrations[0].Name != "earlier" || migrations[0].Up != "SELECT 2;" || migrations[0].Down != "SELECT -2;" {
+		t.Fatalf("migration 2 = %+v", migrations[0])
+	}
+}
+
+func TestLoadRejectsIncompleteMigrations(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"missing down": {
+			"0001_init.up.sql": file("SELECT 1;"),
+		},
+		"empty up": {
+			"0001_init.up.sql":   file("  \n"),
+			"0001_init.down.sql": file("SELECT 1;"),
+		},
+		"two names": {
+			"0001_init.up.sql":    file("SELECT 1;"),
+			"0001_other.down.sql": file("SELECT 1;"),
+		},
+	}
+	for name, fsys := range cases {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: Load accepted the migrations", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	migrator, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrator.migrations {
+		if m.Version != int64(i+1) {
+			t.Fatalf("migration %04d_%s is out of sequence, want version %d", m.Version, m.Name, i+1)
+		}
+	}
+}
+
+func TestCreateNumbersAfterTheLatest(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"0001_init.up.sql", "0001_init.down.sql", "0007_seven.up.sql", "0007_seven.down.sql"} {
+		if err := os.WriteFile(dir+"/"+name, []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	up, down, err := Create(dir, "Add Orders Table!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(up, "0008_add_orders_table.up.sql") || !strings.HasSuffix(down, "0008_add_orders_table.down.sql") {
+		t.Fatalf("Create wrote %s and %s", up, down)
+	}
+	if _, err := Load(os.DirFS(dir)); err != nil {
+		t.Fatalf("created files do not load: %v", err)
+	}
+
+	if _, _, err := Create(dir, "!!!"); err == nil {
+		t.Fatal("a name without letters or digits was accepted")
+	}
+}
+
+// openTestDB connects to TEST_DATABASE_URL with a single connection whose
+// search path is a fresh schema, dropped when the test ends.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if _, err := db.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`SET search_path TO ` + schema + `, public`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		db.Close()
+	})
+	return db
+}
+
+func TestUpDownRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	total := len(migrator.migrations)
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != total {
+		t.Fatalf("Up applied %d migrations, want %d", len(applied), total)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up = %d applied, %v; want nothing to do", len(applied), err)
+	}
+
+	if _, err := migrator.Down(ctx, 1); err != nil {
+		t.Fatalf("Down 1: %v", err)
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Version != int64(total) {
+		t.Fatalf("pending after Down 1 = %+v, want the latest migration", pending)
+	}
+
+	if reverted, err := migrator.Down(ctx, total); err != nil || len(reverted) != total-1 {
+		t.Fatalf("Down all = %d reverted, %v; want %d", len(reverted), err, total-1)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != total {
+		t.Fatalf("Up after Down = %d applied, %v; want %d", len(applied), err, total)
+	}
+}
+
+func TestAuditLogsAreAppendOnly(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO audit_logs (id, action, entity_type, entity_id, changes, created_at)
+		VALUES (uuid_generate_v4(), 'update', 'product', '1', '{}', now())`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE audit_logs SET action = 'delete'`); err == nil {
+		t.Fatal("audit log row was updated")
+	}
+	if _, err := db.Exec(`DELETE FROM audit_logs`); err == nil {
+		t.Fatal("audit log row was deleted")
+	}
+}
+
+func TestRedactAuditPersonalData(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	// Write an entry the way the application did before 0004, then apply it.
+	if _, err := migrator.Down(ctx, 1); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO audit_logs (id, action, entity_type, entity_id, changes, created_at)
+		VALUES (uuid_generate_v4(), 'update', 'user', '1', '{"email": {"before": "a@example.com", "after": "b@example.com"}, "role": {"before": "user", "after": "admin"}}', now())`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	var changes string
+	if err := db.QueryRow(`SELECT changes::text FROM audit_logs`).Scan(&changes); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(changes, "example.com") || !strings.Contains(changes, "[REDACTED]") || !strings.Contains(changes, "admin") {
+		t.Fatalf("changes = %s, want the email redacted and the role kept", changes)
+	}
+}
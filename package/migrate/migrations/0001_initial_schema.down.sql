@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS erasure_requests;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS login_throttles;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS exchange_codes;
+DROP TABLE IF EXISTS email_verification_tokens;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS payment_models;
+DROP TABLE IF EXISTS cart_models;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS identities;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,233 @@
+-- Baseline matching the schema AutoMigrate used to create. Every statement
+-- is guarded so databases created by AutoMigrate can be migrated in place.
+-- The oldest of those predate roles, verification, 2FA, suspension and
+-- soft deletes, and CREATE TABLE IF NOT EXISTS leaves their tables as they
+-- are, so the columns added since are also added one by one.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS users (
+	id uuid DEFAULT uuid_generate_v4(),
+	name text NOT NULL,
+	email text NOT NULL,
+	email_verified_at timestamptz,
+	password text NOT NULL,
+	avatar text,
+	role text NOT NULL DEFAULT 'user',
+	totp_secret text,
+	totp_enabled_at timestamptz,
+	totp_last_step bigint NOT NULL DEFAULT 0,
+	suspended_at timestamptz,
+	suspended_reason text,
+	password_reset_required boolean NOT NULL DEFAULT false,
+	created_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT uni_users_name UNIQUE (name),
+	CONSTRAINT uni_users_email UNIQUE (email)
+);
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS email_verified_at timestamptz,
+	ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'user',
+	ADD COLUMN IF NOT EXISTS totp_secret text,
+	ADD COLUMN IF NOT EXISTS totp_enabled_at timestamptz,
+	ADD COLUMN IF NOT EXISTS totp_last_step bigint NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS suspended_at timestamptz,
+	ADD COLUMN IF NOT EXISTS suspended_reason text,
+	ADD COLUMN IF NOT EXISTS password_reset_required boolean NOT NULL DEFAULT false,
+	ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_users_suspended_at ON users (suspended_at);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS identities (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	provider text NOT NULL,
+	subject text NOT NULL,
+	email text,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_identities_user_id ON identities (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_identity_provider_subject ON identities (provider, subject);
+
+CREATE TABLE IF NOT EXISTS products (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	name text,
+	description text,
+	price decimal,
+	stock bigint,
+	image_url text,
+	created_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id)
+);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	product_id uuid NOT NULL,
+	content text,
+	created_at timestamptz,
+	deleted_at timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_products_comments FOREIGN KEY (product_id) REFERENCES products (id)
+);
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS cart_models (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	product_id uuid NOT NULL,
+	quantity bigint,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_cart_models_product FOREIGN KEY (product_id) REFERENCES products (id)
+);
+
+CREATE TABLE IF NOT EXISTS payment_models (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	order_id uuid NOT NULL,
+	amount bigint,
+	status text,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	family_id uuid NOT NULL,
+	token_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	revoked_at timestamptz,
+	replaced_by uuid,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti text,
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz,
+	PRIMARY KEY (jti)
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	token_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	used_at timestamptz,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	email text NOT NULL,
+	token_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	used_at timestamptz,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user_id ON email_verification_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_verification_tokens_token_hash ON email_verification_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS exchange_codes (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	code_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	used_at timestamptz,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_codes_code_hash ON exchange_codes (code_hash);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	code_hash text NOT NULL,
+	used_at timestamptz,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+CREATE TABLE IF NOT EXISTS login_throttles (
+	key text,
+	failures bigint NOT NULL DEFAULT 0,
+	last_failure_at timestamptz,
+	locked_until timestamptz,
+	PRIMARY KEY (key)
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id uuid,
+	user_id uuid NOT NULL,
+	user_agent text,
+	ip text,
+	created_at timestamptz,
+	last_seen_at timestamptz,
+	expires_at timestamptz NOT NULL,
+	revoked_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	name text NOT NULL,
+	prefix text NOT NULL,
+	key_hash text NOT NULL,
+	scopes text NOT NULL,
+	expires_at timestamptz,
+	last_used_at timestamptz,
+	revoked_at timestamptz,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+	id uuid DEFAULT uuid_generate_v4(),
+	actor_id uuid,
+	impersonator_id uuid,
+	action text NOT NULL,
+	entity_type text NOT NULL,
+	entity_id text NOT NULL,
+	changes jsonb NOT NULL,
+	ip text,
+	request_id text,
+	created_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+
+CREATE TABLE IF NOT EXISTS erasure_requests (
+	id uuid DEFAULT uuid_generate_v4(),
+	user_id uuid NOT NULL,
+	requested_at timestamptz NOT NULL,
+	scheduled_for timestamptz NOT NULL,
+	cancelled_at timestamptz,
+	completed_at timestamptz,
+	PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_erasure_requests_user_id ON erasure_requests (user_id);
+CREATE INDEX IF NOT EXISTS idx_erasure_requests_scheduled_for ON erasure_requests (scheduled_for);
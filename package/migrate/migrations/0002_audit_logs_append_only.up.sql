@@ -0,0 +1,16 @@
+-- Reject UPDATE and DELETE on audit_logs so application bugs and stray
+-- queries cannot rewrite history. This is not tamper-proofing: the table owner,
+-- usually the same account the application connects with, can still disable
+-- or drop the trigger, and TRUNCATE does not fire row triggers. Protecting the
+-- log from a compromised application account needs a separate owner role and
+-- an application role granted only INSERT and SELECT.
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+CREATE TRIGGER audit_logs_append_only
+	BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
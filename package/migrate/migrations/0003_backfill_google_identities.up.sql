@@ -0,0 +1,16 @@
+-- Copy the legacy users.google_id column into identities. Databases created
+-- after the column was dropped from the model never had it.
+DO $$
+BEGIN
+	IF EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'google_id'
+	) THEN
+		INSERT INTO identities (id, user_id, provider, subject, email, created_at)
+		SELECT uuid_generate_v4(), id, 'google', google_id, email, NOW()
+		FROM users
+		WHERE google_id IS NOT NULL AND google_id <> ''
+		ON CONFLICT (provider, subject) DO NOTHING;
+	END IF;
+END
+$$;
@@ -0,0 +1,2 @@
+-- Redacted values cannot be recovered.
+SELECT 1;
@@ -0,0 +1,25 @@
+-- Redact the personal fields of user and comment entries written before the
+-- application stopped recording them, so erasing an account no longer leaves
+-- its name, email or comments behind in the audit log. Rows that are already
+-- redacted are left alone. The append-only trigger is switched off for this
+-- statement only; the migration runs in a transaction, so it is back on
+-- before anything else can write.
+ALTER TABLE audit_logs DISABLE TRIGGER audit_logs_append_only;
+
+UPDATE audit_logs AS a
+SET changes = a.changes || (
+	SELECT jsonb_object_agg(field.key, COALESCE((
+		SELECT jsonb_object_agg(side.key, CASE WHEN side.value = 'null'::jsonb THEN side.value ELSE '"[REDACTED]"'::jsonb END)
+		FROM jsonb_each(field.value) AS side
+	), '{}'::jsonb))
+	FROM jsonb_each(a.changes) AS field
+	WHERE lower(field.key) = ANY (CASE a.entity_type WHEN 'user' THEN ARRAY['name', 'email', 'avatar'] ELSE ARRAY['content'] END)
+)
+WHERE a.entity_type IN ('user', 'comment')
+	AND EXISTS (
+		SELECT 1 FROM jsonb_each(a.changes) AS field, jsonb_each(field.value) AS side
+		WHERE lower(field.key) = ANY (CASE a.entity_type WHEN 'user' THEN ARRAY['name', 'email', 'avatar'] ELSE ARRAY['content'] END)
+			AND side.value NOT IN ('null'::jsonb, '"[REDACTED]"'::jsonb)
+	);
+
+ALTER TABLE audit_logs ENABLE TRIGGER audit_logs_append_only;
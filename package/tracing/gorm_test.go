@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID   int
+	Name string
+}
+
+// openDryRunDB returns an instrumented DB that builds statements without
+// sending them, so no database is needed.
+func openDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	sqlDB, err := sql.Open("pgx", "postgres://127.0.0.1:1/postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := InstrumentDB(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestQuerySpanIsAChildOfTheRequestSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	db := openDryRunDB(t)
+
+	ctx, request := Tracer().Start(context.Background(), "GET /widgets")
+	var widgets []widget
+	if err := db.WithContext(ctx).Where("name = ?", "secret-name").Find(&widgets).Error; err != nil {
+		t.Fatal(err)
+	}
+	request.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want the query and the request", len(spans))
+	}
+	query := spans[0]
+	if query.Name() != "gorm.query widgets" {
+		t.Errorf("span name = %q", query.Name())
+	}
+	if query.Parent().SpanID() != request.SpanContext().SpanID() || query.SpanContext().TraceID() != request.SpanContext().TraceID() {
+		t.Fatalf("query span parent = %v, want the request span", query.Parent())
+	}
+	var statement string
+	for _, kv := range query.Attributes() {
+		if kv.Key == "db.query.text" {
+			statement = kv.Value.AsString()
+		}
+	}
+	if statement != `SELECT * FROM "widgets" WHERE name = $1` {
+		t.Errorf("db.query.text = %q, want the statement with its placeholder", statement)
+	}
+}
@@ -5,36 +5,164 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var secretKey = []byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+// AccessTokenTTL is kept short because access tokens are only revocable
+// through the revocation list; long sessions are carried by refresh tokens.
+const AccessTokenTTL = 15 * time.Minute
 
-func ParseTokenString(tokenString string) (*jwt.RegisteredClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return secretKey, nil
-	})
+// MFAChallengeTTL bounds the time between the password step and the second
+// factor of a login.
+const MFAChallengeTTL = 5 * time.Minute
+
+const purposeMFA = "mfa"
+
+// Access tokens and MFA challenges are signed with the same keys, so they are
+// told apart by their typ header and audience as well as by the purpose
+// claim. A verifier that only knows the published JWKS then still refuses a
+// challenge where an access token is expected.
+const (
+	accessTokenType  = "at+jwt" // RFC 9068
+	mfaChallengeType = "mfa+jwt"
+)
+
+// mfaAudience is the audience of MFA challenges, which only the /login/mfa
+// endpoint of this service accepts.
+func mfaAudience(ring *KeyRing) string {
+	return ring.audience + "/mfa"
+}
+
+type Claims struct {
+	Role string `json:"role"`
+	// Purpose is empty for access tokens. Tokens minted for another purpose,
+	// such as an MFA challenge, are never accepted as access tokens.
+	Purpose string `json:"purpose,omitempty"`
+	// SessionID ties an access token to the session it was issued for.
+	SessionID string `json:"sid,omitempty"`
+	// Actor is set on impersonation tokens and names the admin acting as the
+	// subject, following the "act" claim of RFC 8693.
+	Actor *ActorClaim `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type ActorClaim struct {
+	Subject string `json:"sub"`
+}
+
+func ParseTokenString(tokenString string) (*Claims, error) {
+	ring, err := currentKeyRing()
+	if err != nil {
+		return nil, err
+	}
+	claims, err := parseClaims(ring, tokenString, accessTokenType, ring.audience)
 	if err != nil {
-		fmt.Println("Error parsing token:", err)
 		return nil, err
 	}
+	if claims.Purpose != "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
 
-	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
+// ParseMFAChallenge validates a challenge token issued by GenerateMFAChallenge.
+// The subject is the user it was issued for; the ID and expiry let the caller
+// record the challenge as used.
+func ParseMFAChallenge(tokenString string) (*Claims, error) {
+	ring, err := currentKeyRing()
+	if err != nil {
+		return nil, err
+	}
+	claims, err := parseClaims(ring, tokenString, mfaChallengeType, mfaAudience(ring))
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purposeMFA || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+func parseClaims(ring *KeyRing, tokenString, typ, audience string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, ring.keyFunc,
+		jwt.WithAudience(audience),
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodHS256.Alg(),
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodEdDSA.Alg(),
+		}))
+	if err != nil {
+		return nil, err
+	}
+	if header, _ := token.Header["typ"].(string); header != typ {
+		return nil, fmt.Errorf("unexpected token type: %q", header)
+	}
+
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	}
 
-	fmt.Println("Invalid token")
 	return nil, fmt.Errorf("invalid token")
 }
 
-func GenerateJWT(userID string) (string, error) {
-	claims := &jwt.RegisteredClaims{
-		Subject:   userID,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(72 * time.Hour)), // Token berlaku selama 72 jam
+func GenerateJWT(userID, role, sessionID string) (string, error) {
+	claims := &Claims{
+		Role:      role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secretKey)
+	return signAccessToken(claims)
+}
+
+func signAccessToken(claims *Claims) (string, error) {
+	ring, err := currentKeyRing()
+	if err != nil {
+		return "", err
+	}
+	claims.Audience = jwt.ClaimStrings{ring.audience}
+	return ring.sign(accessTokenType, claims)
+}
+
+// GenerateImpersonationToken issues an access token for userID that records
+// adminID as the acting party. It has no session and cannot be refreshed.
+func GenerateImpersonationToken(userID, role, adminID string) (string, error) {
+	claims := &Claims{
+		Role:  role,
+		Actor: &ActorClaim{Subject: adminID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
+		},
+	}
+
+	return signAccessToken(claims)
+}
+
+// GenerateMFAChallenge issues the short-lived token returned after a correct
+// password when the account has a second factor enrolled.
+func GenerateMFAChallenge(userID string) (string, error) {
+	claims := &Claims{
+		Purpose: purposeMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFAChallengeTTL)),
+		},
+	}
+
+	ring, err := currentKeyRing()
+	if err != nil {
+		return "", err
+	}
+	claims.Audience = jwt.ClaimStrings{mfaAudience(ring)}
+	return ring.sign(mfaChallengeType, claims)
 }
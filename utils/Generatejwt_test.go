@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"fiber-crud/package/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func initTestKeyRing(t *testing.T) {
+	t.Helper()
+	if err := InitKeyRing(config.JWTConfig{KID: "test", Secret: strings.Repeat("k", 32), Audience: "fiber-crud"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMFAChallengeIsNotAnAccessToken(t *testing.T) {
+	initTestKeyRing(t)
+
+	challenge, err := GenerateMFAChallenge("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTokenString(challenge); err == nil {
+		t.Fatal("MFA challenge accepted as an access token")
+	}
+
+	claims, err := ParseMFAChallenge(challenge)
+	if err != nil {
+		t.Fatalf("ParseMFAChallenge: %v", err)
+	}
+	if claims.Subject != "user-1" || claims.ID == "" {
+		t.Fatalf("challenge claims = %+v", claims)
+	}
+
+	// A verifier that only checks signatures and the audience, as a service
+	// using the JWKS would, must also refuse it.
+	token, _, err := jwt.NewParser().ParseUnverified(challenge, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aud, _ := token.Claims.GetAudience(); len(aud) != 1 || aud[0] == "fiber-crud" {
+		t.Fatalf("challenge audience = %v, want one that is not the access token audience", aud)
+	}
+	if token.Header["typ"] == accessTokenType {
+		t.Fatal("challenge carries the access token typ")
+	}
+}
+
+func TestAccessTokenIsNotAnMFAChallenge(t *testing.T) {
+	initTestKeyRing(t)
+
+	access, err := GenerateJWT("user-1", "user", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseMFAChallenge(access); err == nil {
+		t.Fatal("access token accepted as an MFA challenge")
+	}
+
+	claims, err := ParseTokenString(access)
+	if err != nil {
+		t.Fatalf("ParseTokenString: %v", err)
+	}
+	if aud, _ := claims.GetAudience(); len(aud) != 1 || aud[0] != "fiber-crud" {
+		t.Fatalf("access token audience = %v, want [fiber-crud]", aud)
+	}
+}
+
+func TestAccessTokenRequiresAudienceAndType(t *testing.T) {
+	initTestKeyRing(t)
+	ring, err := currentKeyRing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := func(aud ...string) *Claims {
+		return &Claims{Role: "admin", RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   "user-1",
+			Audience:  aud,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}}
+	}
+
+	noAudience, err := ring.sign(accessTokenType, claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTokenString(noAudience); err == nil {
+		t.Fatal("access token without an audience was accepted")
+	}
+
+	otherService, err := ring.sign(accessTokenType, claims("another-service"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTokenString(otherService); err == nil {
+		t.Fatal("access token for another audience was accepted")
+	}
+
+	untyped, err := ring.sign("JWT", claims("fiber-crud"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTokenString(untyped); err == nil {
+		t.Fatal("token without the access token typ was accepted")
+	}
+}
+
+func TestSealTOTPSecret(t *testing.T) {
+	if err := InitTOTPEncryption(base64.StdEncoding.EncodeToString(make([]byte, 32))); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := SealTOTPSecret(secret, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, secret) {
+		t.Fatal("sealed value contains the secret")
+	}
+
+	opened, err := OpenTOTPSecret(sealed, "user-1")
+	if err != nil || opened != secret {
+		t.Fatalf("OpenTOTPSecret = %q, %v; want the secret back", opened, err)
+	}
+	if _, err := OpenTOTPSecret(sealed, "user-2"); err == nil {
+		t.Fatal("secret sealed for one user opened for another")
+	}
+	if _, err := OpenTOTPSecret(secret, "user-1"); err == nil {
+		t.Fatal("unsealed secret was accepted")
+	}
+}
+
+func TestInitTOTPEncryptionRequires32ByteKey(t *testing.T) {
+	for _, key := range []string{"", "not base64!", base64.StdEncoding.EncodeToString(make([]byte, 16))} {
+		if err := InitTOTPEncryption(key); err == nil {
+			t.Errorf("InitTOTPEncryption(%q) succeeded", key)
+		}
+	}
+}
@@ -2,50 +2,69 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"mime/multipart"
-	"os"
+
+	"fiber-crud/package/config"
+	"fiber-crud/package/tracing"
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
-	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var cld *cloudinary.Cloudinary
 
-func InitCloudinary() error {
-	err := godotenv.Load()
-	if err != nil {
-		return fmt.Errorf("error loading .env file: %v", err)
-	}
-
-	// Ambil kredensial dari variabel lingkungan
-	cloudName := os.Getenv("CLOUDINARY_CLOUD_NAME")
-	apiKey := os.Getenv("CLOUDINARY_API_KEY")
-	apiSecret := os.Getenv("CLOUDINARY_API_SECRET")
+var errCloudinaryDisabled = errors.New("cloudinary is not configured")
 
-	if cloudName == "" || apiKey == "" || apiSecret == "" {
-		return fmt.Errorf("one or more environment variables are not set")
+// InitCloudinary creates the upload client. Without credentials uploads are
+// disabled and UploadImageToCloudinary fails instead.
+func InitCloudinary(cfg config.CloudinaryConfig) error {
+	if !cfg.Enabled() {
+		return nil
 	}
 
-	cld, err = cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	var err error
+	cld, err = cloudinary.NewFromParams(cfg.CloudName, cfg.APIKey, cfg.APISecret)
 	if err != nil {
 		return fmt.Errorf("error creating Cloudinary client: %v", err)
 	}
+	// Cloudinary bounds each call through the request context, so the
+	// client needs no timeout of its own.
+	cld.Upload.Client = *tracing.NewHTTPClient(0)
 
 	return nil
 }
 
+// CloudinaryReady reports whether InitCloudinary created an upload client.
+func CloudinaryReady() error {
+	if cld == nil {
+		return errCloudinaryDisabled
+	}
+	return nil
+}
+
 // UploadImageToCloudinary uploads an image to Cloudinary and returns the URL.
-func UploadImageToCloudinary(file multipart.File) (string, error) {
-	uploadResult, err := cld.Upload.Upload(context.Background(), file, uploader.UploadParams{
+func UploadImageToCloudinary(ctx context.Context, file multipart.File) (url string, err error) {
+	if cld == nil {
+		return "", errCloudinaryDisabled
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "cloudinary.Upload", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { tracing.End(span, err) }()
+
+	uploadResult, err := cld.Upload.Upload(ctx, file, uploader.UploadParams{
 		Folder: "your-folder", // Optional: specify a folder in Cloudinary
 	})
-
-	fmt.Printf("Upload Result: %+v\n", uploadResult)
 	if err != nil {
 		return "", err
 	}
+	// API failures such as bad credentials come back in the result, not
+	// as an error.
+	if uploadResult.Error.Message != "" {
+		return "", fmt.Errorf("cloudinary upload failed: %s", uploadResult.Error.Message)
+	}
 
 	return uploadResult.SecureURL, nil
 }
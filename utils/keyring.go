@@ -0,0 +1,314 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"fiber-crud/package/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is one entry of the key ring. Only the active key signs new
+// tokens; every key that is not retired is still accepted for verification
+// so tokens minted before a rotation keep working until they expire.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Retired   bool
+
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+type KeyRing struct {
+	activeID string
+	keys     map[string]*SigningKey
+	// audience is the aud claim of access tokens.
+	audience string
+}
+
+type keyRingFile struct {
+	ActiveKID string         `json:"active_kid"`
+	Keys      []keyRingEntry `json:"keys"`
+}
+
+type keyRingEntry struct {
+	KID            string `json:"kid"`
+	Alg            string `json:"alg"`
+	Secret         string `json:"secret"`
+	SecretFile     string `json:"secret_file"`
+	PrivateKeyFile string `json:"private_key_file"`
+	PublicKeyFile  string `json:"public_key_file"`
+	Retired        bool   `json:"retired"`
+}
+
+var (
+	keyRingMu sync.RWMutex
+	keyRing   *KeyRing
+)
+
+// InitKeyRing loads the signing keys. KeysFile points at a JSON key ring
+// document; without it a single HS256 key is built from Secret under KID.
+func InitKeyRing(cfg config.JWTConfig) error {
+	var (
+		ring *KeyRing
+		err  error
+	)
+
+	if cfg.KeysFile != "" {
+		ring, err = LoadKeyRingFile(cfg.KeysFile)
+	} else {
+		ring, err = keyRingFromSecret(cfg.KID, cfg.Secret)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Services verifying access tokens against the JWKS should require this
+	// audience.
+	ring.audience = cfg.Audience
+
+	keyRingMu.Lock()
+	defer keyRingMu.Unlock()
+	keyRing = ring
+	return nil
+}
+
+func currentKeyRing() (*KeyRing, error) {
+	keyRingMu.RLock()
+	defer keyRingMu.RUnlock()
+	if keyRing == nil {
+		return nil, errors.New("jwt key ring is not initialised")
+	}
+	return keyRing, nil
+}
+
+func keyRingFromSecret(kid, secret string) (*KeyRing, error) {
+	if secret == "" {
+		return nil, errors.New("no JWT signing key configured: set JWT_KEYS_FILE or JWT_SECRET")
+	}
+
+	return newKeyRing(kid, []keyRingEntry{{KID: kid, Alg: jwt.SigningMethodHS256.Alg(), Secret: secret}})
+}
+
+func LoadKeyRingFile(path string) (*KeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key ring file: %v", err)
+	}
+
+	var file keyRingFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing key ring file: %v", err)
+	}
+
+	return newKeyRing(file.ActiveKID, file.Keys)
+}
+
+func newKeyRing(activeID string, entries []keyRingEntry) (*KeyRing, error) {
+	ring := &KeyRing{activeID: activeID, keys: make(map[string]*SigningKey)}
+
+	for _, entry := range entries {
+		if entry.KID == "" {
+			return nil, errors.New("key ring entry without kid")
+		}
+		if _, exists := ring.keys[entry.KID]; exists {
+			return nil, fmt.Errorf("duplicate kid %q in key ring", entry.KID)
+		}
+
+		key, err := loadSigningKey(entry)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", entry.KID, err)
+		}
+		ring.keys[entry.KID] = key
+	}
+
+	active, ok := ring.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active kid %q not found in key ring", activeID)
+	}
+	if active.Retired || active.signKey == nil {
+		return nil, fmt.Errorf("active kid %q cannot sign tokens", activeID)
+	}
+
+	return ring, nil
+}
+
+func loadSigningKey(entry keyRingEntry) (*SigningKey, error) {
+	key := &SigningKey{ID: entry.KID, Algorithm: entry.Alg, Retired: entry.Retired}
+
+	switch entry.Alg {
+	case jwt.SigningMethodHS256.Alg():
+		secret, err := readSecret(entry.Secret, entry.SecretFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(secret) < 32 {
+			return nil, errors.New("HS256 secret must be at least 32 bytes")
+		}
+		key.signKey = []byte(secret)
+		key.verifyKey = []byte(secret)
+
+	case jwt.SigningMethodRS256.Alg():
+		if entry.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(entry.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = private
+			key.verifyKey = &private.PublicKey
+		} else if entry.PublicKeyFile != "" {
+			pem, err := os.ReadFile(entry.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = public
+		} else {
+			return nil, errors.New("RS256 key requires private_key_file or public_key_file")
+		}
+
+	case jwt.SigningMethodEdDSA.Alg():
+		if entry.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(entry.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = private
+			key.verifyKey = private.(ed25519.PrivateKey).Public()
+		} else if entry.PublicKeyFile != "" {
+			pem, err := os.ReadFile(entry.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseEdPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = public
+		} else {
+			return nil, errors.New("EdDSA key requires private_key_file or public_key_file")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", entry.Alg)
+	}
+
+	return key, nil
+}
+
+func readSecret(value, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("error reading secret file: %v", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (r *KeyRing) Active() *SigningKey {
+	return r.keys[r.activeID]
+}
+
+func (r *KeyRing) sign(typ string, claims jwt.Claims) (string, error) {
+	key := r.Active()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	token.Header["typ"] = typ
+	return token.SignedString(key.signKey)
+}
+
+// keyFunc resolves the verification key from the kid header and refuses
+// tokens whose alg does not match the algorithm registered for that key.
+func (r *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := r.keys[kid]
+	if !ok || key.Retired {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
+
+// JWK is the public representation of a key as published in the JWKS
+// document.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS returns the public keys of every non-retired asymmetric key. HMAC keys
+// are never published.
+func JWKS() ([]JWK, error) {
+	ring, err := currentKeyRing()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []JWK{}
+	for _, key := range ring.keys {
+		if key.Retired {
+			continue
+		}
+		if jwk, ok := publicJWK(key); ok {
+			keys = append(keys, jwk)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+	return keys, nil
+}
+
+func publicJWK(key *SigningKey) (JWK, bool) {
+	switch public := key.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Algorithm,
+			N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Algorithm,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(public),
+		}, true
+	}
+	return JWK{}, false
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testHMACSecret = strings.Repeat("s", 32)
+
+// writePrivateKey stores key as a PKCS #8 PEM file and returns its path.
+func writePrivateKey(t *testing.T, key interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func useKeyRing(t *testing.T, activeID string, entries ...keyRingEntry) {
+	t.Helper()
+	ring, err := newKeyRing(activeID, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring.audience = "fiber-crud"
+	keyRingMu.Lock()
+	keyRing = ring
+	keyRingMu.Unlock()
+}
+
+func TestKeyRotationKeepsOlderTokensValid(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := keyRingEntry{KID: "old", Alg: "HS256", Secret: testHMACSecret}
+	next := keyRingEntry{KID: "new", Alg: "RS256", PrivateKeyFile: writePrivateKey(t, rsaKey)}
+
+	useKeyRing(t, "old", old)
+	oldToken, err := GenerateJWT("user-1", "user", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	useKeyRing(t, "new", old, next)
+	if _, err := ParseTokenString(oldToken); err != nil {
+		t.Fatalf("token signed before the rotation was rejected: %v", err)
+	}
+	newToken, err := GenerateJWT("user-1", "user", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "new" || parsed.Method.Alg() != "RS256" {
+		t.Fatalf("new token header = %v, want kid new signed with RS256", parsed.Header)
+	}
+
+	old.Retired = true
+	useKeyRing(t, "new", old, next)
+	if _, err := ParseTokenString(oldToken); err == nil {
+		t.Fatal("token signed with a retired key was accepted")
+	}
+	if _, err := ParseTokenString(newToken); err != nil {
+		t.Fatalf("token signed with the active key was rejected: %v", err)
+	}
+}
+
+func TestParseRejectsAlgorithmOtherThanTheKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeyRing(t, "rsa", keyRingEntry{KID: "rsa", Alg: "RS256", PrivateKeyFile: writePrivateKey(t, rsaKey)})
+
+	// An HS256 token naming the RSA key, signed with its public modulus, is
+	// the classic algorithm confusion attack.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{Role: "admin"})
+	forged.Header["kid"] = "rsa"
+	signed, err := forged.SignedString(rsaKey.PublicKey.N.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTokenString(signed); err == nil {
+		t.Fatal("HS256 token accepted for an RS256 key")
+	}
+}
+
+func TestJWKSPublishesOnlyPublicKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeyRing(t, "ed",
+		keyRingEntry{KID: "ed", Alg: "EdDSA", PrivateKeyFile: writePrivateKey(t, edKey)},
+		keyRingEntry{KID: "hmac", Alg: "HS256", Secret: testHMACSecret},
+		keyRingEntry{KID: "rsa", Alg: "RS256", PrivateKeyFile: writePrivateKey(t, rsaKey)},
+		keyRingEntry{KID: "retired", Alg: "RS256", PrivateKeyFile: writePrivateKey(t, rsaKey), Retired: true},
+	)
+
+	keys, err := JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].Kid != "ed" || keys[0].Kty != "OKP" || keys[1].Kid != "rsa" || keys[1].Kty != "RSA" {
+		t.Fatalf("JWKS = %+v, want the Ed25519 and RSA keys only", keys)
+	}
+}
+
+func TestHMACSecretMustBeLongEnough(t *testing.T) {
+	if _, err := newKeyRing("short", []keyRingEntry{{KID: "short", Alg: "HS256", Secret: "too-short"}}); err == nil {
+		t.Fatal("a 9 byte HS256 secret was accepted")
+	}
+}
@@ -2,31 +2,294 @@ package utils
 
 import (
 	"context"
-	"os"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"fiber-crud/package/config"
 
-	"github.com/joho/godotenv"
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
 	"golang.org/x/oauth2/google"
 )
 
-var GoogleOauthConfig *oauth2.Config
+// ExternalIdentity is the normalised profile returned by a provider after a
+// successful code exchange.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Avatar        string
+}
+
+type OAuthProvider interface {
+	Name() string
+	AuthCodeURL(state, verifier string) string
+	Exchange(ctx context.Context, code, verifier string) (*ExternalIdentity, error)
+}
+
+var oauthProviders = map[string]OAuthProvider{}
 
-func InitOAuth2() error {
-	if err := godotenv.Load(); err != nil {
+var oauthHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+var oauthCookieSecure = true
+
+// InitOAuth2 registers every provider that has a client ID configured:
+// Google, GitHub and each generic OIDC issuer in cfg.OIDC.
+func InitOAuth2(cfg config.OAuthConfig) error {
+	if err := initOAuthState(cfg); err != nil {
 		return err
 	}
 
-	// Initialize Google OAuth2 configuration
-	GoogleOauthConfig = &oauth2.Config{
-		RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"),
-		ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-		ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-		Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
-		Endpoint:     google.Endpoint,
+	oauthProviders = map[string]OAuthProvider{}
+	oauthCookieSecure = cfg.CookieSecure
+	callbackURL := func(name, configured string) string {
+		if configured != "" {
+			return configured
+		}
+		return strings.TrimRight(cfg.CallbackBaseURL, "/") + OAuthPath(name) + "/callback"
+	}
+
+	if g := cfg.Google; g.ClientID != "" {
+		RegisterOAuthProvider(&userInfoProvider{
+			name: "google",
+			config: &oauth2.Config{
+				RedirectURL:  callbackURL("google", g.RedirectURL),
+				ClientID:     g.ClientID,
+				ClientSecret: g.ClientSecret,
+				Scopes:       []string{"openid", "https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
+				Endpoint:     google.Endpoint,
+			},
+			userInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+		})
 	}
+
+	if gh := cfg.GitHub; gh.ClientID != "" {
+		RegisterOAuthProvider(&githubProvider{
+			config: &oauth2.Config{
+				RedirectURL:  callbackURL("github", gh.RedirectURL),
+				ClientID:     gh.ClientID,
+				ClientSecret: gh.ClientSecret,
+				Scopes:       []string{"read:user", "user:email"},
+				Endpoint:     github.Endpoint,
+			},
+		})
+	}
+
+	for _, oidc := range cfg.OIDC {
+		if !validProviderName.MatchString(oidc.Name) {
+			return fmt.Errorf("OIDC_PROVIDERS: provider name %q must only contain a-z, 0-9 and -", oidc.Name)
+		}
+		provider, err := NewOIDCProvider(context.Background(), oidc.Name, OIDCProviderConfig{
+			DiscoveryURL: oidc.DiscoveryURL,
+			ClientID:     oidc.ClientID,
+			ClientSecret: oidc.ClientSecret,
+			RedirectURL:  callbackURL(oidc.Name, oidc.RedirectURL),
+		})
+		if err != nil {
+			return fmt.Errorf("oidc provider %q: %v", oidc.Name, err)
+		}
+		RegisterOAuthProvider(provider)
+	}
+
 	return nil
 }
 
-func GetGoogleUserInfo(ctx context.Context, code string) (*oauth2.Token, error) {
-	return GoogleOauthConfig.Exchange(ctx, code)
+// OAuthCookieSecure reports whether the state cookie is marked Secure.
+func OAuthCookieSecure() bool {
+	return oauthCookieSecure
+}
+
+// OAuthPath is where the login flow of a provider is mounted; the callback
+// and link routes live below it.
+func OAuthPath(name string) string {
+	return "/auth/oauth/" + name
+}
+
+// validProviderName keeps provider names usable as a single path segment.
+var validProviderName = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+func RegisterOAuthProvider(provider OAuthProvider) {
+	oauthProviders[provider.Name()] = provider
+}
+
+func GetOAuthProvider(name string) (OAuthProvider, bool) {
+	provider, ok := oauthProviders[name]
+	return provider, ok
+}
+
+// userInfoProvider covers OpenID Connect providers: the profile is read from
+// the standard userinfo endpoint with the freshly issued access token.
+type userInfoProvider struct {
+	name        string
+	config      *oauth2.Config
+	userInfoURL string
+}
+
+func (p *userInfoProvider) Name() string {
+	return p.name
+}
+
+func (p *userInfoProvider) AuthCodeURL(state, verifier string) string {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+func (p *userInfoProvider) Exchange(ctx context.Context, code, verifier string) (*ExternalIdentity, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, oauthHTTPClient)
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		Subject       string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+		Picture       string `json:"picture"`
+	}
+	if err := getJSON(p.config.Client(ctx, token), p.userInfoURL, &claims); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("userinfo response has no subject")
+	}
+
+	return &ExternalIdentity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+		Avatar:        claims.Picture,
+	}, nil
+}
+
+type OIDCProviderConfig struct {
+	DiscoveryURL string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// NewOIDCProvider builds a provider for any OpenID Connect issuer from its
+// discovery document.
+func NewOIDCProvider(ctx context.Context, name string, cfg OIDCProviderConfig) (OAuthProvider, error) {
+	if cfg.DiscoveryURL == "" || cfg.ClientID == "" {
+		return nil, errors.New("discovery URL and client ID are required")
+	}
+
+	var discovery struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	if err := getJSON(oauthHTTPClient, cfg.DiscoveryURL, &discovery); err != nil {
+		return nil, fmt.Errorf("error loading discovery document: %v", err)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.UserInfoEndpoint == "" {
+		return nil, errors.New("discovery document is missing required endpoints")
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &userInfoProvider{
+		name: name,
+		config: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  discovery.AuthorizationEndpoint,
+				TokenURL: discovery.TokenEndpoint,
+			},
+		},
+		userInfoURL: discovery.UserInfoEndpoint,
+	}, nil
+}
+
+// githubProvider reads the profile from the REST API because GitHub is not an
+// OIDC provider for user logins. The primary address is used, with its
+// verification state passed on for the usecase to act on.
+type githubProvider struct {
+	config *oauth2.Config
+}
+
+func (p *githubProvider) Name() string {
+	return "github"
+}
+
+func (p *githubProvider) AuthCodeURL(state, verifier string) string {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code, verifier string) (*ExternalIdentity, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, oauthHTTPClient)
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+	client := p.config.Client(ctx, token)
+
+	var profile struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(client, "https://api.github.com/user", &profile); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(client, "https://api.github.com/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &ExternalIdentity{
+		Provider: "github",
+		Subject:  fmt.Sprint(profile.ID),
+		Name:     profile.Name,
+		Avatar:   profile.AvatarURL,
+	}
+	if identity.Name == "" {
+		identity.Name = profile.Login
+	}
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email = e.Email
+			identity.EmailVerified = e.Verified
+		}
+	}
+	return identity, nil
+}
+
+func getJSON(client *http.Client, url string, out interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET %s: unexpected status %d: %s", url, resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
 }
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"fiber-crud/package/config"
+)
+
+const OAuthStateTTL = 10 * time.Minute
+
+var ErrInvalidOAuthState = errors.New("invalid oauth state")
+
+// OAuthState is carried in a signed cookie between the login redirect and
+// the provider callback.
+type OAuthState struct {
+	State     string `json:"s"`
+	Verifier  string `json:"v"`
+	Redirect  string `json:"r,omitempty"`
+	LinkUser  string `json:"l,omitempty"`
+	ExpiresAt int64  `json:"e"`
+}
+
+var (
+	oauthStateSecret []byte
+	allowedRedirects []*url.URL
+)
+
+// initOAuthState loads the state secret and the allowed redirects. Without a
+// configured secret a random one is generated, which only works while a
+// single replica serves both the login and the callback.
+func initOAuthState(cfg config.OAuthConfig) error {
+	oauthStateSecret = []byte(cfg.StateSecret)
+	if len(oauthStateSecret) == 0 {
+		oauthStateSecret = make([]byte, 32)
+		if _, err := rand.Read(oauthStateSecret); err != nil {
+			return err
+		}
+	}
+
+	allowedRedirects = nil
+	for _, raw := range cfg.AllowedRedirects {
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("OAUTH_ALLOWED_REDIRECTS contains an invalid URL: " + raw)
+		}
+		allowedRedirects = append(allowedRedirects, u)
+	}
+	return nil
+}
+
+// ResolveOAuthRedirect returns the frontend URL to send the user back to.
+// An empty request selects the first allow-listed URL; anything not on the
+// allow-list is rejected.
+func ResolveOAuthRedirect(requested string) (string, bool) {
+	if requested == "" {
+		if len(allowedRedirects) == 0 {
+			return "", true
+		}
+		return allowedRedirects[0].String(), true
+	}
+
+	u, err := url.Parse(requested)
+	if err != nil {
+		return "", false
+	}
+	for _, allowed := range allowedRedirects {
+		if u.Scheme == allowed.Scheme && u.Host == allowed.Host && u.Path == allowed.Path {
+			return requested, true
+		}
+	}
+	return "", false
+}
+
+func NewOAuthState(verifier, redirect string) (*OAuthState, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &OAuthState{
+		State:     base64.RawURLEncoding.EncodeToString(b),
+		Verifier:  verifier,
+		Redirect:  redirect,
+		ExpiresAt: time.Now().Add(OAuthStateTTL).Unix(),
+	}, nil
+}
+
+// Encode serialises the state and appends an HMAC so the cookie cannot be
+// forged or altered by the client.
+func (s *OAuthState) Encode() (string, error) {
+	if len(oauthStateSecret) == 0 {
+		return "", errors.New("oauth state secret is not initialised")
+	}
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + signOAuthState(body), nil
+}
+
+func DecodeOAuthState(value string) (*OAuthState, error) {
+	body, sig, ok := strings.Cut(value, ".")
+	if !ok || len(oauthStateSecret) == 0 || !hmac.Equal([]byte(sig), []byte(signOAuthState(body))) {
+		return nil, ErrInvalidOAuthState
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+
+	var state OAuthState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	if time.Now().Unix() > state.ExpiresAt {
+		return nil, ErrInvalidOAuthState
+	}
+	return &state, nil
+}
+
+func signOAuthState(body string) string {
+	mac := hmac.New(sha256.New, oauthStateSecret)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
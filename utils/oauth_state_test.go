@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"fiber-crud/package/config"
+)
+
+func initTestOAuthState(t *testing.T) {
+	t.Helper()
+	err := initOAuthState(config.OAuthConfig{
+		StateSecret:      strings.Repeat("o", 32),
+		AllowedRedirects: []string{"https://app.example.com/oauth/done"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOAuthStateRoundTrip(t *testing.T) {
+	initTestOAuthState(t)
+
+	state, err := NewOAuthState("verifier", "https://app.example.com/oauth/done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := state.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeOAuthState(encoded)
+	if err != nil {
+		t.Fatalf("DecodeOAuthState: %v", err)
+	}
+	if *decoded != *state {
+		t.Fatalf("decoded state = %+v, want %+v", decoded, state)
+	}
+}
+
+func TestOAuthStateRejectsTamperedAndExpired(t *testing.T) {
+	initTestOAuthState(t)
+
+	state, err := NewOAuthState("verifier", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := state.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, sig, _ := strings.Cut(encoded, ".")
+	if _, err := DecodeOAuthState(body + "x." + sig); err != ErrInvalidOAuthState {
+		t.Fatalf("tampered state = %v, want ErrInvalidOAuthState", err)
+	}
+
+	state.ExpiresAt = time.Now().Add(-time.Second).Unix()
+	expired, err := state.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeOAuthState(expired); err != ErrInvalidOAuthState {
+		t.Fatalf("expired state = %v, want ErrInvalidOAuthState", err)
+	}
+}
+
+func TestResolveOAuthRedirectUsesAllowList(t *testing.T) {
+	initTestOAuthState(t)
+
+	tests := []struct {
+		requested string
+		want      string
+		ok        bool
+	}{
+		{"", "https://app.example.com/oauth/done", true},
+		{"https://app.example.com/oauth/done?next=/cart", "https://app.example.com/oauth/done?next=/cart", true},
+		{"https://evil.example.com/oauth/done", "", false},
+		{"https://app.example.com/other", "", false},
+		{"http://app.example.com/oauth/done", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ResolveOAuthRedirect(tt.requested)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ResolveOAuthRedirect(%q) = %q, %v; want %q, %v", tt.requested, got, ok, tt.want, tt.ok)
+		}
+	}
+}